@@ -0,0 +1,110 @@
+package sqlh
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+
+	pkgerrors "github.com/nofeaturesonlybugs/errors"
+)
+
+var (
+	// ErrNotFound is the kind of DBError when a query expected a row and none was found.
+	ErrNotFound error = errors.New("not found")
+	// ErrUniqueViolation is the kind of DBError when a unique or primary key constraint is violated.
+	ErrUniqueViolation error = errors.New("unique violation")
+	// ErrForeignKeyViolation is the kind of DBError when a foreign key constraint is violated.
+	ErrForeignKeyViolation error = errors.New("foreign key violation")
+	// ErrNotNullViolation is the kind of DBError when a NULL is written to a NOT NULL column.
+	ErrNotNullViolation error = errors.New("not null violation")
+	// ErrCheckViolation is the kind of DBError when a check constraint is violated.
+	ErrCheckViolation error = errors.New("check violation")
+	// ErrSerialization is the kind of DBError when a transaction can not be serialized and may be retried.
+	ErrSerialization error = errors.New("serialization failure")
+	// ErrDeadlock is the kind of DBError when a deadlock is detected and may be retried.
+	ErrDeadlock error = errors.New("deadlock")
+)
+
+// DBError is a database error classified by an ErrorClassifier.
+//
+// Is(Kind) is true for a DBError so the sentinel errors of this package can be tested with Is from
+// github.com/nofeaturesonlybugs/errors, which also sees through errors wrapped by its Go function.
+// Use Original from the same package to access the *DBError.
+type DBError struct {
+	// Kind is one of the sentinel errors of this package such as ErrUniqueViolation.
+	Kind error
+	// Code is the SQLSTATE (Postgres) or extended result code (SQLite) if known.
+	Code string
+	// Constraint, Table, and Column describe the violated constraint when reported by the database.
+	Constraint string
+	Table      string
+	Column     string
+	// Index is the index of the element that failed when a query ran for a slice of models;
+	// otherwise it is -1.
+	Index int
+	// Err is the error returned by the driver.
+	Err error
+}
+
+// Error returns the kind followed by the known details and the driver error.
+func (me *DBError) Error() string {
+	parts := []string{me.Kind.Error()}
+	if me.Constraint != "" {
+		parts = append(parts, "constraint "+me.Constraint)
+	}
+	if me.Table != "" {
+		parts = append(parts, "table "+me.Table)
+	}
+	if me.Column != "" {
+		parts = append(parts, "column "+me.Column)
+	}
+	if me.Index >= 0 {
+		parts = append(parts, "index "+strconv.Itoa(me.Index))
+	}
+	rv := strings.Join(parts, "; ")
+	if me.Err != nil {
+		rv = rv + ": " + me.Err.Error()
+	}
+	return rv
+}
+
+// Is returns true if target is Kind.
+func (me *DBError) Is(target error) bool {
+	return target == me.Kind
+}
+
+// Unwrap returns the driver error.
+func (me *DBError) Unwrap() error {
+	return me.Err
+}
+
+// ErrorClassifier is implemented by types, such as the grammars in package grammar, that classify
+// driver errors.  ClassifyError returns a *DBError if err is recognized; otherwise it returns err.
+type ErrorClassifier interface {
+	ClassifyError(err error) error
+}
+
+// ClassifyError classifies err with classifier, which may be nil.  sql.ErrNoRows is classified as
+// ErrNotFound.  Errors that are already a *DBError and errors that are not recognized are returned
+// unchanged.
+func ClassifyError(classifier ErrorClassifier, err error) error {
+	if err == nil {
+		return nil
+	}
+	original := err
+	if typed, ok := pkgerrors.Original(err).(error); ok {
+		original = typed
+	}
+	var classified *DBError
+	if errors.As(original, &classified) {
+		return err
+	} else if errors.Is(original, sql.ErrNoRows) {
+		return &DBError{Kind: ErrNotFound, Index: -1, Err: original}
+	} else if classifier != nil {
+		if classified := classifier.ClassifyError(original); classified != original {
+			return classified
+		}
+	}
+	return err
+}
@@ -0,0 +1,53 @@
+package sqlh_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/nofeaturesonlybugs/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nofeaturesonlybugs/sqlh"
+)
+
+// uniqueClassifier classifies every error as a unique violation.
+type uniqueClassifier struct{}
+
+func (uniqueClassifier) ClassifyError(err error) error {
+	return &sqlh.DBError{Kind: sqlh.ErrUniqueViolation, Column: "email", Index: -1, Err: err}
+}
+
+func TestClassifyError(t *testing.T) {
+	chk := assert.New(t)
+	//
+	chk.NoError(sqlh.ClassifyError(uniqueClassifier{}, nil))
+	//
+	err := sqlh.ClassifyError(nil, errors.Go(sql.ErrNoRows))
+	chk.True(errors.Is(err, sqlh.ErrNotFound))
+	chk.True(errors.Is(err, sql.ErrNoRows))
+	chk.Equal("not found: sql: no rows in result set", err.Error())
+	//
+	boom := errors.Errorf("boom")
+	chk.Equal(boom, sqlh.ClassifyError(nil, boom))
+	//
+	err = sqlh.ClassifyError(uniqueClassifier{}, errors.Go(boom))
+	chk.True(errors.Is(err, sqlh.ErrUniqueViolation))
+	chk.False(errors.Is(err, sqlh.ErrNotFound))
+	// Already classified errors are unchanged.
+	chk.Equal(err, sqlh.ClassifyError(nil, err))
+	wrapped := errors.Go(err)
+	chk.Equal(wrapped, sqlh.ClassifyError(uniqueClassifier{}, wrapped))
+	chk.True(errors.Is(wrapped, sqlh.ErrUniqueViolation))
+}
+
+func TestDBError(t *testing.T) {
+	chk := assert.New(t)
+	//
+	err := &sqlh.DBError{Kind: sqlh.ErrForeignKeyViolation, Constraint: "fk_owner", Table: "pets", Column: "owner_id", Index: 2, Err: errors.Errorf("boom")}
+	chk.Equal("foreign key violation; constraint fk_owner; table pets; column owner_id; index 2: boom", err.Error())
+	chk.Equal("deadlock", (&sqlh.DBError{Kind: sqlh.ErrDeadlock, Index: -1}).Error())
+	chk.True(errors.Is(errors.Go(err), sqlh.ErrForeignKeyViolation))
+	original, ok := errors.Original(errors.Go(err)).(*sqlh.DBError)
+	chk.True(ok)
+	chk.Equal(2, original.Index)
+}
@@ -0,0 +1,43 @@
+//go:build go1.21
+// +build go1.21
+
+package sqlh
+
+import (
+	"database/sql"
+
+	"github.com/nofeaturesonlybugs/errors"
+	"github.com/nofeaturesonlybugs/set"
+)
+
+// DefaultScanner is the Scanner used by Select and Get.  Its Mapper maps columns to fields by the db
+// and json struct tags, in that order, and joins the names of nested structs with an underscore.
+var DefaultScanner = &Scanner{
+	Mapper: &set.Mapper{
+		Join: "_",
+		Tags: []string{"db", "json"},
+	},
+}
+
+// Select uses Q to run the query string with args and returns the rows scanned into a []T with
+// DefaultScanner.  T is a struct or a scalar type; see Scanner.Select.
+func Select[T any](Q IQueries, query string, args ...interface{}) ([]T, error) {
+	var rv []T
+	if err := DefaultScanner.Select(Q, &rv, query, args...); err != nil {
+		return nil, errors.Go(err)
+	}
+	return rv, nil
+}
+
+// Get uses Q to run the query string with args and returns the first row as a T; the remaining rows
+// are not scanned.  T is a struct or a scalar type.  If the query returns no rows the error is a
+// *DBError with Kind ErrNotFound.
+func Get[T any](Q IQueries, query string, args ...interface{}) (T, error) {
+	var rv, zero T
+	if err := DefaultScanner.get(Q, &rv, query, args...); err == sql.ErrNoRows {
+		return zero, ClassifyError(nil, err)
+	} else if err != nil {
+		return zero, errors.Go(err)
+	}
+	return rv, nil
+}
@@ -0,0 +1,92 @@
+//go:build go1.21
+// +build go1.21
+
+package sqlh_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nofeaturesonlybugs/errors"
+	"github.com/nofeaturesonlybugs/sqlh"
+)
+
+func TestSelect(t *testing.T) {
+	chk := assert.New(t)
+	//
+	type Person struct {
+		Id   int    `db:"pk"`
+		Name string `json:"name"`
+	}
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	//
+	mock.ExpectQuery("select +").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"pk", "name"}).AddRow(1, "Bob").AddRow(2, "Sally"))
+	people, err := sqlh.Select[Person](db, "select pk, name from people where pk >= $1", 1)
+	chk.NoError(err)
+	chk.Equal([]Person{{1, "Bob"}, {2, "Sally"}}, people)
+	//
+	mock.ExpectQuery("select +").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(3).AddRow(4))
+	numbers, err := sqlh.Select[int](db, "select n from numbers")
+	chk.NoError(err)
+	chk.Equal([]int{3, 4}, numbers)
+	//
+	mock.ExpectQuery("select +").WillReturnError(errors.Errorf("query error"))
+	people, err = sqlh.Select[Person](db, "select pk, name from people")
+	chk.Error(err)
+	chk.Nil(people)
+	chk.NoError(mock.ExpectationsWereMet())
+}
+
+func TestGet(t *testing.T) {
+	chk := assert.New(t)
+	//
+	type Person struct {
+		Id   int    `db:"pk"`
+		Name string `json:"name"`
+	}
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	//
+	mock.ExpectQuery("select +").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"pk", "name"}).AddRow(1, "Bob"))
+	person, err := sqlh.Get[Person](db, "select pk, name from people where pk = $1", 1)
+	chk.NoError(err)
+	chk.Equal(Person{1, "Bob"}, person)
+	//
+	// Rows after the first are not scanned; the second row could not be scanned into Person.
+	mock.ExpectQuery("select +").
+		WillReturnRows(sqlmock.NewRows([]string{"pk", "name"}).AddRow(1, "Bob").AddRow("x", "Sally"))
+	person, err = sqlh.Get[Person](db, "select pk, name from people")
+	chk.NoError(err)
+	chk.Equal(Person{1, "Bob"}, person)
+	//
+	mock.ExpectQuery("select +").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+	count, err := sqlh.Get[int](db, "select count(*) from people")
+	chk.NoError(err)
+	chk.Equal(7, count)
+	//
+	mock.ExpectQuery("select +").WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"pk", "name"}))
+	person, err = sqlh.Get[Person](db, "select pk, name from people where pk = $1", 2)
+	chk.True(errors.Is(err, sqlh.ErrNotFound))
+	chk.Equal(Person{}, person)
+	//
+	mock.ExpectQuery("select +").WillReturnError(errors.Errorf("query error"))
+	_, err = sqlh.Get[Person](db, "select pk, name from people")
+	chk.Error(err)
+	chk.False(errors.Is(err, sqlh.ErrNotFound))
+	//
+	mock.ExpectQuery("select +").WillReturnRows(sqlmock.NewRows([]string{"count"}))
+	count, err = sqlh.Get[int](db, "select count(*) from people")
+	chk.True(errors.Is(err, sqlh.ErrNotFound))
+	chk.Equal(0, count)
+	chk.NoError(mock.ExpectationsWereMet())
+	//
+	_, err = sqlh.Get[[]int](db, "select n from numbers")
+	chk.Error(err)
+}
@@ -0,0 +1,177 @@
+package grammar
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	pkgerrors "github.com/nofeaturesonlybugs/errors"
+	"github.com/nofeaturesonlybugs/sqlh"
+)
+
+// Drivers are not imported by this package so their errors are inspected through the methods and fields
+// they are known to have:
+//	github.com/jackc/pgx  *pgconn.PgError    SQLState(), ConstraintName, TableName, ColumnName, Detail
+//	github.com/lib/pq     *pq.Error          SQLState(), Constraint, Table, Column, Detail
+//	github.com/mattn/go-sqlite3  sqlite3.Error   Code, ExtendedCode
+//	modernc.org/sqlite    *sqlite.Error      Code()
+
+// postgresKinds maps SQLSTATE codes to error kinds.
+var postgresKinds = map[string]error{
+	"23505": sqlh.ErrUniqueViolation,
+	"23503": sqlh.ErrForeignKeyViolation,
+	"23502": sqlh.ErrNotNullViolation,
+	"23514": sqlh.ErrCheckViolation,
+	"40001": sqlh.ErrSerialization,
+	"40P01": sqlh.ErrDeadlock,
+}
+
+// sqliteKinds maps SQLite extended result codes to error kinds.  SQLITE_LOCKED is a table lock
+// conflict within the same connection or shared cache rather than a deadlock so it is not classified.
+var sqliteKinds = map[int]error{
+	2067: sqlh.ErrUniqueViolation,     // SQLITE_CONSTRAINT_UNIQUE
+	1555: sqlh.ErrUniqueViolation,     // SQLITE_CONSTRAINT_PRIMARYKEY
+	787:  sqlh.ErrForeignKeyViolation, // SQLITE_CONSTRAINT_FOREIGNKEY
+	1299: sqlh.ErrNotNullViolation,    // SQLITE_CONSTRAINT_NOTNULL
+	275:  sqlh.ErrCheckViolation,      // SQLITE_CONSTRAINT_CHECK
+	517:  sqlh.ErrSerialization,       // SQLITE_BUSY_SNAPSHOT
+}
+
+// sqliteMessages maps SQLite constraint messages to error kinds; it is used when the driver
+// only reports the primary result code SQLITE_CONSTRAINT.
+var sqliteMessages = []struct {
+	message string
+	kind    error
+}{
+	{"UNIQUE constraint failed", sqlh.ErrUniqueViolation},
+	{"FOREIGN KEY constraint failed", sqlh.ErrForeignKeyViolation},
+	{"NOT NULL constraint failed", sqlh.ErrNotNullViolation},
+	{"CHECK constraint failed", sqlh.ErrCheckViolation},
+}
+
+// ClassifyError returns a *sqlh.DBError if err is a recognized Postgres error; otherwise it returns err.
+func (me *PostgresGrammar) ClassifyError(err error) error {
+	if classified := sqlh.ClassifyError(nil, err); classified != err {
+		return classified
+	}
+	err = originalError(err)
+	var state interface{ SQLState() string }
+	if !errors.As(err, &state) {
+		return err
+	}
+	code := state.SQLState()
+	kind, ok := postgresKinds[code]
+	if !ok {
+		return err
+	}
+	rv := &sqlh.DBError{Kind: kind, Code: code, Index: -1, Err: err}
+	v := reflect.Indirect(reflect.ValueOf(state))
+	rv.Constraint = stringField(v, "ConstraintName", "Constraint")
+	rv.Table = stringField(v, "TableName", "Table")
+	if rv.Column = stringField(v, "ColumnName", "Column"); rv.Column == "" {
+		// Unique and foreign key violations describe the columns in the detail:
+		//	Key (email)=(a@example.com) already exists.
+		detail := stringField(v, "Detail")
+		if begin, end := strings.Index(detail, "Key ("), strings.Index(detail, ")="); begin != -1 && end > begin {
+			rv.Column = detail[begin+len("Key (") : end]
+		}
+	}
+	return rv
+}
+
+// ClassifyError returns a *sqlh.DBError if err is a recognized SQLite error; otherwise it returns err.
+func (me *SqliteGrammar) ClassifyError(err error) error {
+	if classified := sqlh.ClassifyError(nil, err); classified != err {
+		return classified
+	}
+	err = originalError(err)
+	code, ok := sqliteCode(err)
+	if !ok {
+		return err
+	}
+	kind, message := sqliteKinds[code], err.Error()
+	if kind == nil && code&0xff == 19 { // SQLITE_CONSTRAINT
+		for _, m := range sqliteMessages {
+			if strings.Contains(message, m.message) {
+				kind = m.kind
+				break
+			}
+		}
+	}
+	if kind == nil {
+		return err
+	}
+	rv := &sqlh.DBError{Kind: kind, Code: strconv.Itoa(code), Index: -1, Err: err}
+	//
+	// Constraint messages end with the failed columns or the name of the check constraint:
+	//	UNIQUE constraint failed: people.email
+	//	CHECK constraint failed: age_positive
+	// modernc.org/sqlite also prefixes the message with the primary code and suffixes it with the code.
+	if pos := strings.LastIndex(message, "constraint failed: "); pos != -1 {
+		detail := strings.TrimSuffix(message[pos+len("constraint failed: "):], " ("+strconv.Itoa(code)+")")
+		if kind == sqlh.ErrCheckViolation {
+			rv.Constraint = detail
+		} else {
+			// Multi-column constraints are reported as "t.a, t.b"; the table is taken from the first.
+			columns := strings.Split(detail, ", ")
+			for k, column := range columns {
+				if dot := strings.LastIndex(column, "."); dot != -1 {
+					if rv.Table == "" {
+						rv.Table = column[:dot]
+					}
+					columns[k] = column[dot+1:]
+				}
+			}
+			rv.Column = strings.Join(columns, ", ")
+		}
+	}
+	return rv
+}
+
+// sqliteCode returns the extended result code of a SQLite driver error in the chain of err.
+func sqliteCode(err error) (int, bool) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if coder, ok := err.(interface{ Code() int }); ok {
+			return coder.Code(), true
+		}
+		v := reflect.Indirect(reflect.ValueOf(err))
+		if v.Kind() != reflect.Struct {
+			continue
+		}
+		// go-sqlite3 leaves ExtendedCode zero for some errors; fall back to Code.
+		for _, name := range []string{"ExtendedCode", "Code"} {
+			if f := v.FieldByName(name); f.IsValid() {
+				switch f.Kind() {
+				case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+					if f.Int() != 0 {
+						return int(f.Int()), true
+					}
+				}
+			}
+		}
+	}
+	return 0, false
+}
+
+// originalError returns the error wrapped by github.com/nofeaturesonlybugs/errors, which does not
+// support errors.Unwrap, or err.
+func originalError(err error) error {
+	if original, ok := pkgerrors.Original(err).(error); ok {
+		return original
+	}
+	return err
+}
+
+// stringField returns the first non-empty string field of struct v in names.
+func stringField(v reflect.Value, names ...string) string {
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	for _, name := range names {
+		if f := v.FieldByName(name); f.IsValid() && f.Kind() == reflect.String && f.String() != "" {
+			return f.String()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,132 @@
+package grammar_test
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/nofeaturesonlybugs/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nofeaturesonlybugs/sqlh"
+	"github.com/nofeaturesonlybugs/sqlh/grammar"
+)
+
+// pgxError has the fields and methods of *pgconn.PgError used by the classifier.
+type pgxError struct {
+	Code           string
+	Detail         string
+	TableName      string
+	ColumnName     string
+	ConstraintName string
+}
+
+func (me *pgxError) Error() string    { return "ERROR: (SQLSTATE " + me.Code + ")" }
+func (me *pgxError) SQLState() string { return me.Code }
+
+// pqError has the fields and methods of *pq.Error used by the classifier.
+type pqError struct {
+	Code       string
+	Table      string
+	Column     string
+	Constraint string
+}
+
+func (me *pqError) Error() string    { return "pq: " + me.Code }
+func (me *pqError) SQLState() string { return me.Code }
+
+// sqlite3Error has the fields of sqlite3.Error from github.com/mattn/go-sqlite3.
+type sqlite3Error struct {
+	Code         int
+	ExtendedCode int
+	msg          string
+}
+
+func (me sqlite3Error) Error() string { return me.msg }
+
+// moderncError has the methods of *sqlite.Error from modernc.org/sqlite.
+type moderncError struct {
+	code int
+	msg  string
+}
+
+func (me *moderncError) Error() string { return me.msg }
+func (me *moderncError) Code() int     { return me.code }
+
+func TestPostgresGrammarClassifyError(t *testing.T) {
+	chk := assert.New(t)
+	//
+	g := &grammar.PostgresGrammar{}
+	//
+	err := g.ClassifyError(errors.Go(&pgxError{Code: "23505", TableName: "people", ConstraintName: "people_email_key", Detail: "Key (email)=(a@example.com) already exists."}))
+	chk.True(errors.Is(err, sqlh.ErrUniqueViolation))
+	classified := err.(*sqlh.DBError)
+	chk.Equal("23505", classified.Code)
+	chk.Equal("people_email_key", classified.Constraint)
+	chk.Equal("people", classified.Table)
+	chk.Equal("email", classified.Column)
+	chk.Equal(-1, classified.Index)
+	//
+	err = g.ClassifyError(fmt.Errorf("insert: %w", &pqError{Code: "23502", Table: "people", Column: "name"}))
+	chk.True(errors.Is(err, sqlh.ErrNotNullViolation))
+	chk.Equal("name", err.(*sqlh.DBError).Column)
+	//
+	tests := map[string]error{
+		"23503": sqlh.ErrForeignKeyViolation,
+		"23514": sqlh.ErrCheckViolation,
+		"40001": sqlh.ErrSerialization,
+		"40P01": sqlh.ErrDeadlock,
+	}
+	for code, kind := range tests {
+		chk.True(errors.Is(g.ClassifyError(&pqError{Code: code}), kind), code)
+	}
+	//
+	unknown := &pqError{Code: "42601"}
+	chk.Equal(unknown, g.ClassifyError(unknown))
+	boom := errors.Errorf("boom")
+	chk.Equal(boom, g.ClassifyError(boom))
+	chk.True(errors.Is(g.ClassifyError(sql.ErrNoRows), sqlh.ErrNotFound))
+}
+
+func TestSqliteGrammarClassifyError(t *testing.T) {
+	chk := assert.New(t)
+	//
+	g := &grammar.SqliteGrammar{}
+	//
+	err := g.ClassifyError(sqlite3Error{Code: 19, ExtendedCode: 2067, msg: "UNIQUE constraint failed: people.first, people.last"})
+	chk.True(errors.Is(err, sqlh.ErrUniqueViolation))
+	classified := err.(*sqlh.DBError)
+	chk.Equal("2067", classified.Code)
+	chk.Equal("people", classified.Table)
+	chk.Equal("first, last", classified.Column)
+	//
+	err = g.ClassifyError(errors.Go(&moderncError{code: 275, msg: "constraint failed: CHECK constraint failed: age_positive (275)"}))
+	chk.True(errors.Is(err, sqlh.ErrCheckViolation))
+	chk.Equal("age_positive", err.(*sqlh.DBError).Constraint)
+	// Only the primary result code is known.
+	err = g.ClassifyError(sqlite3Error{Code: 19, msg: "NOT NULL constraint failed: people.name"})
+	chk.True(errors.Is(err, sqlh.ErrNotNullViolation))
+	chk.Equal("name", err.(*sqlh.DBError).Column)
+	//
+	tests := map[int]error{
+		1555: sqlh.ErrUniqueViolation,
+		787:  sqlh.ErrForeignKeyViolation,
+		1299: sqlh.ErrNotNullViolation,
+		517:  sqlh.ErrSerialization,
+	}
+	for code, kind := range tests {
+		chk.True(errors.Is(g.ClassifyError(&moderncError{code: code, msg: "failed"}), kind), code)
+	}
+	// SQLITE_LOCKED and SQLITE_LOCKED_SHAREDCACHE are not deadlocks.
+	for _, code := range []int{6, 262} {
+		locked := &moderncError{code: code, msg: "database table is locked"}
+		chk.Equal(locked, g.ClassifyError(locked), code)
+	}
+	//
+	unknown := sqlite3Error{Code: 1, msg: "SQL logic error"}
+	chk.Equal(unknown, g.ClassifyError(unknown))
+	unknown = sqlite3Error{Code: 19, msg: "constraint failed"}
+	chk.Equal(unknown, g.ClassifyError(unknown))
+	boom := errors.Errorf("boom")
+	chk.Equal(boom, g.ClassifyError(boom))
+}
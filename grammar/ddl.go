@@ -0,0 +1,239 @@
+package grammar
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/nofeaturesonlybugs/errors"
+
+	"github.com/nofeaturesonlybugs/sqlh/internal/types"
+	"github.com/nofeaturesonlybugs/sqlh/schema"
+)
+
+// ddlType is the category of SQL type used for a Go type when generating DDL.
+type ddlType int
+
+const (
+	ddlUnknown ddlType = iota
+	ddlSmallInt
+	ddlInteger
+	ddlBigInt
+	ddlReal
+	ddlDouble
+	ddlBool
+	ddlText
+	ddlTime
+	ddlBytes
+)
+
+// IsInteger returns true if the type is an integer type.
+func (me ddlType) IsInteger() bool {
+	return me == ddlSmallInt || me == ddlInteger || me == ddlBigInt
+}
+
+// goDDLType returns the ddlType for the Go type of value.
+func goDDLType(value interface{}) ddlType {
+	typ := reflect.TypeOf(value)
+	if typ == nil {
+		return ddlUnknown
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ {
+	case types.Time, types.NullTime:
+		return ddlTime
+	case types.Bytes:
+		return ddlBytes
+	case types.NullString:
+		return ddlText
+	case types.NullInt64:
+		return ddlBigInt
+	case types.NullInt32:
+		return ddlInteger
+	case types.NullFloat64:
+		return ddlDouble
+	case types.NullBool:
+		return ddlBool
+	}
+	switch typ.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Uint8:
+		return ddlSmallInt
+	case reflect.Int32, reflect.Uint16:
+		return ddlInteger
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return ddlBigInt
+	case reflect.Float32:
+		return ddlReal
+	case reflect.Float64:
+		return ddlDouble
+	case reflect.Bool:
+		return ddlBool
+	case reflect.String:
+		return ddlText
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return ddlBytes
+		}
+	}
+	return ddlUnknown
+}
+
+// ddlDialect describes how a grammar differs when generating DDL.
+type ddlDialect struct {
+	// Quoter quotes identifiers.
+	Quoter Quoter
+	// Types maps each ddlType to the SQL type.
+	Types map[ddlType]string
+	// AutoKey returns the column definition for an auto incrementing integer key of sqlType; single
+	// is true if the key is the only primary key column.  If inline is true the definition declares
+	// the primary key and the table does not.
+	AutoKey func(sqlType string, single bool) (definition string, inline bool)
+	// IndexSchemaOnName is true if the schema of an index belongs on the index name instead of
+	// the table name in CREATE INDEX.
+	IndexSchemaOnName bool
+	// AlterColumn is true if columns can be altered with ALTER TABLE ... ALTER COLUMN.
+	AlterColumn bool
+	// UniqueConstraints is true if migrations add and drop unique indexes as table constraints
+	// the way createTable declares them.
+	UniqueConstraints bool
+}
+
+// SqlTyper is implemented by grammars that map Go types to SQL types; the mapping is the one used by
+// CreateTable for columns without a SqlType.
+type SqlTyper interface {
+	// SqlType returns the SQL type for the Go type of value or an empty string if the type is unknown.
+	SqlType(value interface{}) string
+}
+
+// sqlType returns the SQL type for the Go type of value or an empty string.
+func (me ddlDialect) sqlType(value interface{}) string {
+	return me.Types[goDDLType(value)]
+}
+
+// createTable returns the CREATE TABLE statement for table.
+func (me ddlDialect) createTable(table schema.Table) (string, error) {
+	name := table.QualifiedName()
+	if table.Name == "" {
+		return "", errors.Go(ErrTableRequired)
+	} else if len(table.PrimaryKey.Columns)+len(table.Columns) == 0 {
+		return "", errors.Go(ErrColumnsRequired).Tag("table", name).Tag("SQL", "CREATE TABLE")
+	}
+	definitions := []string{}
+	inlineKey := false
+	single := len(table.PrimaryKey.Columns) == 1
+	for _, column := range table.PrimaryKey.Columns {
+		definition, inline, err := me.column(column, true, single)
+		if err != nil {
+			return "", errors.Go(err).Tag("table", name)
+		}
+		inlineKey = inlineKey || inline
+		definitions = append(definitions, definition)
+	}
+	for _, column := range table.Columns {
+		definition, _, err := me.column(column, false, false)
+		if err != nil {
+			return "", errors.Go(err).Tag("table", name)
+		}
+		definitions = append(definitions, definition)
+	}
+	if len(table.PrimaryKey.Columns) > 0 && !inlineKey {
+		definitions = append(definitions, me.constraint(table.PrimaryKey.Name, "PRIMARY KEY", table.PrimaryKey.Columns))
+	}
+	for _, index := range table.Unique {
+		definitions = append(definitions, me.constraint(index.Name, "UNIQUE", index.Columns))
+	}
+	for _, foreignKey := range table.ForeignKeys {
+		definitions = append(definitions, me.foreignKey(foreignKey))
+	}
+	parts := []string{
+		"CREATE TABLE " + me.Quoter.Quote(name) + " (",
+		"\t" + strings.Join(definitions, ",\n\t"),
+		")",
+	}
+	return strings.Join(parts, "\n"), nil
+}
+
+// column returns the column definition for column.  key is true if the column is part of the
+// primary key and single is true if it is the only primary key column.
+func (me ddlDialect) column(column schema.Column, key bool, single bool) (definition string, inline bool, err error) {
+	goType := goDDLType(column.GoType)
+	sqlType := column.SqlType
+	if sqlType == "" {
+		if sqlType = me.Types[goType]; sqlType == "" {
+			return "", false, errors.Go(ErrUnknownType).Tag("column", column.Name).Type(column.GoType)
+		}
+	}
+	definition = me.Quoter.Quote(column.Name) + " "
+	// A SqlType equal to the mapped type of a non-integer Go type does not make the key an auto key.
+	if key && column.Auto && (goType.IsInteger() || (goType == ddlUnknown && column.SqlType != "")) {
+		var typeDefinition string
+		typeDefinition, inline = me.AutoKey(sqlType, single)
+		definition = definition + typeDefinition
+	} else {
+		definition = definition + sqlType
+	}
+	if !inline && (key || !column.Nullable) {
+		definition = definition + " NOT NULL"
+	}
+	if column.Default != "" {
+		definition = definition + " DEFAULT " + column.Default
+	} else if column.Auto && !key && goType == ddlTime {
+		definition = definition + " DEFAULT CURRENT_TIMESTAMP"
+	}
+	return definition, inline, nil
+}
+
+// constraint returns a table constraint such as PRIMARY KEY or UNIQUE.
+func (me ddlDialect) constraint(name string, kind string, columns []schema.Column) string {
+	rv := kind + " ( " + strings.Join(me.Quoter.QuoteAll(schema.ColumnNames(columns)), ", ") + " )"
+	if name != "" {
+		rv = "CONSTRAINT " + me.Quoter.Quote(name) + " " + rv
+	}
+	return rv
+}
+
+// foreignKey returns the FOREIGN KEY table constraint for foreignKey.
+func (me ddlDialect) foreignKey(foreignKey schema.ForeignKey) string {
+	rv := me.constraint(foreignKey.Name, "FOREIGN KEY", foreignKey.Columns) +
+		" REFERENCES " + me.Quoter.Quote(foreignKey.RefTable) + " ( " + strings.Join(me.Quoter.QuoteAll(foreignKey.RefColumns), ", ") + " )"
+	if foreignKey.OnDelete != "" {
+		rv = rv + " ON DELETE " + foreignKey.OnDelete
+	}
+	return rv
+}
+
+// createIndex returns the CREATE INDEX statement for index.
+func (me ddlDialect) createIndex(index schema.Index) (string, error) {
+	if index.Table == "" {
+		return "", errors.Go(ErrTableRequired)
+	} else if len(index.Columns) == 0 {
+		return "", errors.Go(ErrColumnsRequired).Tag("table", index.Table).Tag("SQL", "CREATE INDEX")
+	}
+	schemaName, name := indexName(index)
+	columns := schema.ColumnNames(index.Columns)
+	table := index.Table
+	if me.IndexSchemaOnName && schemaName != "" {
+		name = schemaName + "." + name
+		_, table = splitTableName(index.Table)
+	}
+	kind := "CREATE INDEX "
+	if index.IsUnique {
+		kind = "CREATE UNIQUE INDEX "
+	}
+	return kind + me.Quoter.Quote(name) + " ON " + me.Quoter.Quote(table) + " ( " + strings.Join(me.Quoter.QuoteAll(columns), ", ") + " )", nil
+}
+
+// indexName returns the name of index and the schema of its table.  Indexes without a name are
+// named after the table and columns with a suffix of _key for unique indexes and _idx otherwise.
+func indexName(index schema.Index) (schemaName string, name string) {
+	schemaName, table := splitTableName(index.Table)
+	if name = index.Name; name == "" {
+		suffix := "_idx"
+		if index.IsUnique {
+			suffix = "_key"
+		}
+		name = table + "_" + strings.Join(schema.ColumnNames(index.Columns), "_") + suffix
+	}
+	return schemaName, name
+}
@@ -0,0 +1,192 @@
+package grammar_test
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nofeaturesonlybugs/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nofeaturesonlybugs/sqlh/grammar"
+	"github.com/nofeaturesonlybugs/sqlh/schema"
+)
+
+// ddlTable is the table used to test DDL generation.
+var ddlTable = schema.Table{
+	Name: "people",
+	PrimaryKey: schema.Index{
+		Columns:   []schema.Column{{Name: "pk", GoType: int(0), Auto: true}},
+		IsPrimary: true,
+		IsUnique:  true,
+	},
+	Columns: []schema.Column{
+		{Name: "email", GoType: ""},
+		{Name: "age", GoType: int16(0)},
+		{Name: "nickname", GoType: (*string)(nil), Nullable: true},
+		{Name: "middle", GoType: sql.NullString{}, Nullable: true},
+		{Name: "score", GoType: float64(0)},
+		{Name: "active", GoType: false, Default: "true"},
+		{Name: "avatar", GoType: []byte(nil), Nullable: true},
+		{Name: "balance", GoType: int64(0), SqlType: "NUMERIC(12,2)"},
+		{Name: "created", GoType: time.Time{}, Auto: true},
+	},
+	Unique: []schema.Index{
+		{Columns: []schema.Column{{Name: "email"}}, IsUnique: true},
+	},
+}
+
+func TestPostgresGrammarCreateTable(t *testing.T) {
+	chk := assert.New(t)
+	//
+	ddl, err := grammar.Postgres.CreateTable(ddlTable)
+	chk.NoError(err)
+	chk.Equal(strings.Join([]string{
+		"CREATE TABLE people (",
+		"\tpk BIGINT GENERATED BY DEFAULT AS IDENTITY NOT NULL,",
+		"\temail TEXT NOT NULL,",
+		"\tage SMALLINT NOT NULL,",
+		"\tnickname TEXT,",
+		"\tmiddle TEXT,",
+		"\tscore DOUBLE PRECISION NOT NULL,",
+		"\tactive BOOLEAN NOT NULL DEFAULT true,",
+		"\tavatar BYTEA,",
+		"\tbalance NUMERIC(12,2) NOT NULL,",
+		"\tcreated TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,",
+		"\tPRIMARY KEY ( pk ),",
+		"\tUNIQUE ( email )",
+		")",
+	}, "\n"), ddl)
+	//
+	// Composite keys, names, and quoting.
+	table := schema.Table{
+		Schema: "crm",
+		Name:   "person_address",
+		PrimaryKey: schema.Index{
+			Name:      "person_address_pkey",
+			Columns:   []schema.Column{{Name: "person_id", GoType: int32(0)}, {Name: "address_id", GoType: int32(0)}},
+			IsPrimary: true,
+			IsUnique:  true,
+		},
+	}
+	ddl, err = grammar.Postgres.WithQuoting(grammar.QuoteAll).CreateTable(table)
+	chk.NoError(err)
+	chk.Equal(strings.Join([]string{
+		`CREATE TABLE "crm"."person_address" (`,
+		`	"person_id" INTEGER NOT NULL,`,
+		`	"address_id" INTEGER NOT NULL,`,
+		`	CONSTRAINT "person_address_pkey" PRIMARY KEY ( "person_id", "address_id" )`,
+		`)`,
+	}, "\n"), ddl)
+}
+
+func TestSqliteGrammarCreateTable(t *testing.T) {
+	chk := assert.New(t)
+	//
+	ddl, err := grammar.Sqlite.CreateTable(ddlTable)
+	chk.NoError(err)
+	chk.Equal(strings.Join([]string{
+		"CREATE TABLE people (",
+		"\tpk INTEGER PRIMARY KEY AUTOINCREMENT,",
+		"\temail TEXT NOT NULL,",
+		"\tage INTEGER NOT NULL,",
+		"\tnickname TEXT,",
+		"\tmiddle TEXT,",
+		"\tscore REAL NOT NULL,",
+		"\tactive BOOLEAN NOT NULL DEFAULT true,",
+		"\tavatar BLOB,",
+		"\tbalance NUMERIC(12,2) NOT NULL,",
+		"\tcreated DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,",
+		"\tUNIQUE ( email )",
+		")",
+	}, "\n"), ddl)
+}
+
+func TestGrammarCreateTableErrors(t *testing.T) {
+	chk := assert.New(t)
+	//
+	for _, g := range []grammar.Grammar{grammar.Postgres, grammar.Sqlite} {
+		_, err := g.CreateTable(schema.Table{})
+		chk.Equal(grammar.ErrTableRequired, errors.Original(err))
+		_, err = g.CreateTable(schema.Table{Name: "foo"})
+		chk.Equal(grammar.ErrColumnsRequired, errors.Original(err))
+		_, err = g.CreateTable(schema.Table{Name: "foo", Columns: []schema.Column{{Name: "a", GoType: struct{}{}}}})
+		chk.Equal(grammar.ErrUnknownType, errors.Original(err))
+		_, err = g.CreateIndex(schema.Index{Columns: []schema.Column{{Name: "a"}}})
+		chk.Equal(grammar.ErrTableRequired, errors.Original(err))
+		_, err = g.CreateIndex(schema.Index{Table: "foo"})
+		chk.Equal(grammar.ErrColumnsRequired, errors.Original(err))
+	}
+}
+
+func TestGrammarCreateIndex(t *testing.T) {
+	chk := assert.New(t)
+	//
+	index := schema.Index{
+		Table:   "people",
+		Columns: []schema.Column{{Name: "last"}, {Name: "first"}},
+	}
+	ddl, err := grammar.Postgres.CreateIndex(index)
+	chk.NoError(err)
+	chk.Equal("CREATE INDEX people_last_first_idx ON people ( last, first )", ddl)
+	//
+	index.IsUnique, index.Name, index.Table = true, "people_name", "crm.people"
+	ddl, err = grammar.Postgres.CreateIndex(index)
+	chk.NoError(err)
+	chk.Equal("CREATE UNIQUE INDEX people_name ON crm.people ( last, first )", ddl)
+	ddl, err = grammar.Sqlite.CreateIndex(index)
+	chk.NoError(err)
+	chk.Equal("CREATE UNIQUE INDEX crm.people_name ON people ( last, first )", ddl)
+	//
+	index.Name = ""
+	ddl, err = grammar.Sqlite.WithQuoting(grammar.QuoteAll).CreateIndex(index)
+	chk.NoError(err)
+	chk.Equal(`CREATE UNIQUE INDEX "crm"."people_last_first_key" ON "people" ( "last", "first" )`, ddl)
+}
+
+func TestGrammarCreateTableForeignKeys(t *testing.T) {
+	chk := assert.New(t)
+	//
+	table := schema.Table{
+		Name: "person_address",
+		PrimaryKey: schema.Index{
+			Columns:   []schema.Column{{Name: "person_id", GoType: int(0)}, {Name: "address_id", GoType: int(0)}},
+			IsPrimary: true,
+		},
+		ForeignKeys: []schema.ForeignKey{
+			{Columns: []schema.Column{{Name: "person_id"}}, RefTable: "people", RefColumns: []string{"pk"}, OnDelete: "CASCADE"},
+			{Name: "address_fk", Columns: []schema.Column{{Name: "address_id"}}, RefTable: "geo.addresses", RefColumns: []string{"pk"}},
+		},
+	}
+	ddl, err := grammar.Sqlite.WithQuoting(grammar.QuoteAll).CreateTable(table)
+	chk.NoError(err)
+	chk.Equal(strings.Join([]string{
+		`CREATE TABLE "person_address" (`,
+		`	"person_id" INTEGER NOT NULL,`,
+		`	"address_id" INTEGER NOT NULL,`,
+		`	PRIMARY KEY ( "person_id", "address_id" ),`,
+		`	FOREIGN KEY ( "person_id" ) REFERENCES "people" ( "pk" ) ON DELETE CASCADE,`,
+		`	CONSTRAINT "address_fk" FOREIGN KEY ( "address_id" ) REFERENCES "geo"."addresses" ( "pk" )`,
+		`)`,
+	}, "\n"), ddl)
+}
+
+func TestGrammarSqlType(t *testing.T) {
+	chk := assert.New(t)
+	//
+	postgres, sqlite := grammar.Postgres.(grammar.SqlTyper), grammar.Sqlite.(grammar.SqlTyper)
+	chk.Equal("TIMESTAMP WITH TIME ZONE", postgres.SqlType(time.Time{}))
+	chk.Equal("BIGINT", postgres.SqlType(new(int)))
+	chk.Equal("INTEGER", sqlite.SqlType(sql.NullInt32{}))
+	chk.Equal("", sqlite.SqlType(struct{}{}))
+	//
+	// Non-integer auto keys are not auto incrementing even when their SqlType is set.
+	table := schema.Table{
+		Name:       "tokens",
+		PrimaryKey: schema.Index{Columns: []schema.Column{{Name: "token", GoType: "", SqlType: "TEXT", Auto: true}}, IsPrimary: true},
+	}
+	ddl, err := grammar.Postgres.CreateTable(table)
+	chk.NoError(err)
+	chk.Equal("CREATE TABLE tokens (\n\ttoken TEXT NOT NULL,\n\tPRIMARY KEY ( token )\n)", ddl)
+}
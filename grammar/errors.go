@@ -6,4 +6,9 @@ var (
 	ErrTableRequired   error = errors.New("table name is required")
 	ErrColumnsRequired error = errors.New("columns are required")
 	ErrKeysRequired    error = errors.New("keys are required")
+	// ErrUnknownType is returned when DDL is generated for a column whose Go type can not be mapped
+	// to a SQL type; set Column.SqlType for such columns.
+	ErrUnknownType error = errors.New("unknown column type")
+	// ErrUnsupportedChange is returned when a grammar can not render a schema change.
+	ErrUnsupportedChange error = errors.New("unsupported schema change")
 )
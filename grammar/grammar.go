@@ -2,10 +2,16 @@ package grammar
 
 import (
 	"github.com/nofeaturesonlybugs/sqlh/model/statements"
+	"github.com/nofeaturesonlybugs/sqlh/schema"
 )
 
 // Grammar creates SQL queries for a specific database engine.
 type Grammar interface {
+	// Quote quotes the identifier according to the grammar's quoting rules; identifiers
+	// such as schema.table are quoted per part.
+	Quote(identifier string) string
+	// WithQuoting returns a copy of the grammar that uses Quoting q.
+	WithQuoting(q Quoting) Grammar
 	// Delete returns the query type for deleting from the table.
 	Delete(table string, keys []string) (*statements.Query, error)
 	// Insert returns the query type for inserting into table.
@@ -14,6 +20,21 @@ type Grammar interface {
 	Update(table string, columns []string, keys []string, auto []string) (*statements.Query, error)
 	// Upsert returns the query type for upserting (INSERT|UPDATE) a record in a table.
 	Upsert(table string, columns []string, keys []string, auto []string) (*statements.Query, error)
+	// UpsertInsertOnly is the same as Upsert except columns in insertOnly are only written
+	// during the INSERT portion of the query.
+	UpsertInsertOnly(table string, columns []string, insertOnly []string, keys []string, auto []string) (*statements.Query, error)
+	// UpsertSelect is the same as UpsertInsertOnly except the rows are selected from the table
+	// source and the query returns keys followed by auto for every inserted or changed row.
+	UpsertSelect(table string, source string, columns []string, insertOnly []string, keys []string, auto []string) (*statements.Query, error)
+	// CreateTable returns the CREATE TABLE statement for table.  Columns without a SqlType
+	// are given a SQL type based on their GoType.  Unique indexes and foreign keys are created
+	// as table constraints; create other indexes with CreateIndex.
+	CreateTable(table schema.Table) (string, error)
+	// CreateIndex returns the CREATE INDEX statement for index; the index is named after
+	// its table and columns if it has no name.
+	CreateIndex(index schema.Index) (string, error)
+	// Migration returns the statements to apply and revert changes; see schema.Diff.
+	Migration(changes []schema.Change) (Migration, error)
 }
 
 // TODO Implement Driver.
@@ -208,3 +208,71 @@ func TestPostgresGrammarUpsert(t *testing.T) {
 		chk.Nil(query)
 	}
 }
+
+func TestPostgresGrammarUpsertInsertOnly(t *testing.T) {
+	chk := assert.New(t)
+	//
+	g := grammar.Postgres
+	{ // insert only columns are excluded from DO UPDATE
+		columns := []string{"a", "b"}
+		insertOnly := []string{"c"}
+		keys := []string{"key"}
+		query, err := g.UpsertInsertOnly("foo", columns, insertOnly, keys, nil)
+		chk.NoError(err)
+		chk.NotNil(query)
+		parts := []string{
+			"INSERT INTO foo AS dest\n\t\t( key, a, b, c )\n\tVALUES\n\t\t( $1, $2, $3, $4 )",
+			"\tON CONFLICT( key ) DO UPDATE SET",
+			"\t\ta = EXCLUDED.a, b = EXCLUDED.b",
+			"\t\tWHERE (\n\t\t\tdest.a <> EXCLUDED.a OR dest.b <> EXCLUDED.b\n\t\t)",
+		}
+		chk.Equal(strings.Join(parts, "\n"), query.SQL)
+		chk.Equal([]string{"key", "a", "b", "c"}, query.Arguments)
+	}
+	{ // only insert only columns does nothing on conflict
+		query, err := g.UpsertInsertOnly("foo", nil, []string{"c"}, []string{"key"}, []string{"created"})
+		chk.NoError(err)
+		chk.NotNil(query)
+		parts := []string{
+			"INSERT INTO foo AS dest\n\t\t( key, c )\n\tVALUES\n\t\t( $1, $2 )",
+			"\tON CONFLICT( key ) DO NOTHING",
+			"\tRETURNING created",
+		}
+		chk.Equal(strings.Join(parts, "\n"), query.SQL)
+		chk.Equal([]string{"key", "c"}, query.Arguments)
+		chk.Equal([]string{"created"}, query.Scan)
+		chk.Equal(statements.ExpectRowOrNone, query.Expect)
+	}
+	{ // no columns at all
+		query, err := g.UpsertInsertOnly("foo", nil, nil, []string{"key"}, nil)
+		chk.Error(err)
+		chk.Nil(query)
+	}
+}
+
+func TestPostgresGrammarUpsertSelect(t *testing.T) {
+	chk := assert.New(t)
+	//
+	g := grammar.Postgres
+	{
+		query, err := g.UpsertSelect("foo", "staging", []string{"a"}, []string{"c"}, []string{"key"}, []string{"modified"})
+		chk.NoError(err)
+		chk.NotNil(query)
+		parts := []string{
+			"INSERT INTO foo AS dest\n\t\t( key, a, c )\n\tSELECT key, a, c FROM staging",
+			"\tON CONFLICT( key ) DO UPDATE SET",
+			"\t\ta = EXCLUDED.a",
+			"\t\tWHERE (\n\t\t\tdest.a <> EXCLUDED.a\n\t\t)",
+			"\tRETURNING key, modified",
+		}
+		chk.Equal(strings.Join(parts, "\n"), query.SQL)
+		chk.Nil(query.Arguments)
+		chk.Equal([]string{"key", "modified"}, query.Scan)
+		chk.Equal(statements.ExpectRows, query.Expect)
+	}
+	{ // source is required
+		query, err := g.UpsertSelect("foo", "", []string{"a"}, nil, []string{"key"}, nil)
+		chk.Error(err)
+		chk.Nil(query)
+	}
+}
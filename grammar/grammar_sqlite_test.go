@@ -208,3 +208,70 @@ func TestDefaultGrammarUpsert(t *testing.T) {
 		chk.Nil(query)
 	}
 }
+
+func TestDefaultGrammarUpsertInsertOnly(t *testing.T) {
+	chk := assert.New(t)
+	//
+	g := grammar.Sqlite
+	{ // insert only columns are excluded from DO UPDATE
+		columns := []string{"a", "b"}
+		insertOnly := []string{"c"}
+		keys := []string{"key"}
+		query, err := g.UpsertInsertOnly("foo", columns, insertOnly, keys, nil)
+		chk.NoError(err)
+		chk.NotNil(query)
+		parts := []string{
+			"INSERT INTO foo\n\t\t( key, a, b, c )\n\tVALUES\n\t\t( ?, ?, ?, ? )",
+			"\tON CONFLICT( key ) DO UPDATE SET",
+			"\t\tfoo.a = EXCLUDED.a, foo.b = EXCLUDED.b",
+			"\t\tWHERE (\n\t\t\tfoo.a <> EXCLUDED.a OR foo.b <> EXCLUDED.b\n\t\t)",
+		}
+		chk.Equal(strings.Join(parts, "\n"), query.SQL)
+		chk.Equal([]string{"key", "a", "b", "c"}, query.Arguments)
+	}
+	{ // only insert only columns does nothing on conflict
+		query, err := g.UpsertInsertOnly("foo", nil, []string{"c"}, []string{"key"}, []string{"created"})
+		chk.NoError(err)
+		chk.NotNil(query)
+		parts := []string{
+			"INSERT INTO foo\n\t\t( key, c )\n\tVALUES\n\t\t( ?, ? )",
+			"\tON CONFLICT( key ) DO NOTHING",
+			"\tRETURNING created",
+		}
+		chk.Equal(strings.Join(parts, "\n"), query.SQL)
+		chk.Equal([]string{"key", "c"}, query.Arguments)
+		chk.Equal([]string{"created"}, query.Scan)
+		chk.Equal(statements.ExpectRowOrNone, query.Expect)
+	}
+	{ // no columns at all
+		query, err := g.UpsertInsertOnly("foo", nil, nil, []string{"key"}, nil)
+		chk.Error(err)
+		chk.Nil(query)
+	}
+}
+
+func TestDefaultGrammarUpsertSelect(t *testing.T) {
+	chk := assert.New(t)
+	//
+	g := grammar.Sqlite
+	{
+		query, err := g.UpsertSelect("foo", "staging", []string{"a"}, nil, []string{"key"}, nil)
+		chk.NoError(err)
+		chk.NotNil(query)
+		parts := []string{
+			"INSERT INTO foo\n\t\t( key, a )\n\tSELECT key, a FROM staging WHERE true",
+			"\tON CONFLICT( key ) DO UPDATE SET",
+			"\t\tfoo.a = EXCLUDED.a",
+			"\t\tWHERE (\n\t\t\tfoo.a <> EXCLUDED.a\n\t\t)",
+			"\tRETURNING key",
+		}
+		chk.Equal(strings.Join(parts, "\n"), query.SQL)
+		chk.Equal([]string{"key"}, query.Scan)
+		chk.Equal(statements.ExpectRows, query.Expect)
+	}
+	{ // source is required
+		query, err := g.UpsertSelect("foo", "", []string{"a"}, nil, []string{"key"}, nil)
+		chk.Error(err)
+		chk.Nil(query)
+	}
+}
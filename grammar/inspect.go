@@ -0,0 +1,132 @@
+package grammar
+
+import (
+	"strings"
+
+	"github.com/nofeaturesonlybugs/errors"
+
+	"github.com/nofeaturesonlybugs/sqlh"
+	"github.com/nofeaturesonlybugs/sqlh/schema"
+)
+
+// inspectedIndex is an index read from the database before it is assembled into a schema.Table.
+type inspectedIndex struct {
+	Name    string
+	Primary bool
+	Unique  bool
+	Columns []string
+}
+
+// inspectedForeignKey is a foreign key read from the database before it is assembled into a schema.Table.
+type inspectedForeignKey struct {
+	Name       string
+	Columns    []string
+	RefTable   string
+	RefColumns []string
+	OnDelete   string
+}
+
+// splitTableName splits a possibly schema qualified table name into its schema and name.
+func splitTableName(table string) (schemaName string, name string) {
+	if n := strings.LastIndex(table, "."); n > 0 {
+		return table[:n], table[n+1:]
+	}
+	return "", table
+}
+
+// queryStrings runs the query and returns the first column of every row.
+func queryStrings(Q sqlh.IQueries, query string, args ...interface{}) ([]string, error) {
+	rows, err := Q.Query(query, args...)
+	if err != nil {
+		return nil, errors.Go(err)
+	}
+	defer rows.Close()
+	var rv []string
+	for rows.Next() {
+		var value string
+		if err = rows.Scan(&value); err != nil {
+			return nil, errors.Go(err)
+		}
+		rv = append(rv, value)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Go(err)
+	}
+	return rv, nil
+}
+
+// namedColumns returns columns with only their names set.
+func namedColumns(names []string) []schema.Column {
+	rv := make([]schema.Column, len(names))
+	for k, name := range names {
+		rv[k] = schema.Column{Name: name}
+	}
+	return rv
+}
+
+// assembleTable creates a schema.Table from inspected columns, indexes, and foreign keys.  Primary key columns
+// are moved from columns into the PrimaryKey index; foreign key columns have only their names.
+func assembleTable(schemaName string, name string, columns []schema.Column, indexes []inspectedIndex, foreignKeys []inspectedForeignKey) schema.Table {
+	byName := map[string]schema.Column{}
+	for _, column := range columns {
+		byName[column.Name] = column
+	}
+	indexColumns := func(names []string) []schema.Column {
+		rv := make([]schema.Column, 0, len(names))
+		for _, name := range names {
+			if column, ok := byName[name]; ok {
+				rv = append(rv, column)
+			}
+		}
+		return rv
+	}
+	rv := schema.Table{
+		Schema: schemaName,
+		Name:   name,
+	}
+	table := rv.QualifiedName()
+	primary := map[string]bool{}
+	for _, index := range indexes {
+		switch {
+		case index.Primary:
+			rv.PrimaryKey = schema.Index{
+				Name:      index.Name,
+				Table:     table,
+				Columns:   indexColumns(index.Columns),
+				IsPrimary: true,
+				IsUnique:  true,
+			}
+			for _, column := range index.Columns {
+				primary[column] = true
+			}
+		case index.Unique:
+			rv.Unique = append(rv.Unique, schema.Index{
+				Name:     index.Name,
+				Table:    table,
+				Columns:  indexColumns(index.Columns),
+				IsUnique: true,
+			})
+		default:
+			rv.Indexes = append(rv.Indexes, schema.Index{
+				Name:    index.Name,
+				Table:   table,
+				Columns: indexColumns(index.Columns),
+			})
+		}
+	}
+	for _, column := range columns {
+		if !primary[column.Name] {
+			rv.Columns = append(rv.Columns, column)
+		}
+	}
+	for _, foreignKey := range foreignKeys {
+		rv.ForeignKeys = append(rv.ForeignKeys, schema.ForeignKey{
+			Name:       foreignKey.Name,
+			Columns:    namedColumns(foreignKey.Columns),
+			RefTable:   foreignKey.RefTable,
+			RefColumns: foreignKey.RefColumns,
+			OnDelete:   foreignKey.OnDelete,
+		})
+	}
+	return rv
+}
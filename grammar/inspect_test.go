@@ -0,0 +1,233 @@
+package grammar_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/nofeaturesonlybugs/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nofeaturesonlybugs/sqlh/grammar"
+	"github.com/nofeaturesonlybugs/sqlh/schema"
+)
+
+func TestPostgresGrammarInspect(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	//
+	mock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.tables")).
+		WillReturnRows(sqlmock.NewRows([]string{"table_name"}).AddRow("people"))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pg_catalog.pg_attribute a")).
+		WithArgs("", "people").
+		WillReturnRows(sqlmock.NewRows([]string{"attname", "format_type", "nullable", "default"}).
+			AddRow("pk", "integer", false, "nextval('people_pk_seq'::regclass)").
+			AddRow("email", "character varying(64)", false, "").
+			AddRow("first", "text", true, "").
+			AddRow("last", "text", true, ""))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pg_catalog.pg_index x")).
+		WithArgs("", "people").
+		WillReturnRows(sqlmock.NewRows([]string{"relname", "indisprimary", "indisunique", "attname"}).
+			AddRow("people_email_key", false, true, "email").
+			AddRow("people_name_idx", false, false, "last").
+			AddRow("people_name_idx", false, false, "first").
+			AddRow("people_pkey", true, true, "pk"))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pg_catalog.pg_constraint c")).
+		WithArgs("", "people").
+		WillReturnRows(sqlmock.NewRows([]string{"conname", "attname", "relname", "refname", "confdeltype"}).
+			AddRow("people_team_fkey", "team_id", "teams", "id", "c").
+			AddRow("people_team_fkey", "team_org", "teams", "org", "c").
+			AddRow("people_region_fkey", "region", "geo.regions", "code", "a"))
+	//
+	tables, err := schema.Inspect(db, grammar.Postgres)
+	chk.NoError(err)
+	chk.NoError(mock.ExpectationsWereMet())
+	chk.Len(tables, 1)
+	table := tables[0]
+	chk.Equal("", table.Schema)
+	chk.Equal("people", table.Name)
+	chk.Equal("people_pkey", table.PrimaryKey.Name)
+	chk.True(table.PrimaryKey.IsPrimary)
+	chk.Equal([]schema.Column{{Name: "pk", SqlType: "integer", Default: "nextval('people_pk_seq'::regclass)"}}, table.PrimaryKey.Columns)
+	chk.Equal([]schema.Column{
+		{Name: "email", SqlType: "character varying(64)"},
+		{Name: "first", SqlType: "text", Nullable: true},
+		{Name: "last", SqlType: "text", Nullable: true},
+	}, table.Columns)
+	if chk.Len(table.Unique, 1) {
+		chk.Equal("people_email_key", table.Unique[0].Name)
+		chk.True(table.Unique[0].IsUnique)
+		chk.Equal("email", table.Unique[0].Columns[0].Name)
+	}
+	if chk.Len(table.Indexes, 1) {
+		chk.Equal("people_name_idx", table.Indexes[0].Name)
+		chk.False(table.Indexes[0].IsUnique)
+		if chk.Len(table.Indexes[0].Columns, 2) {
+			chk.Equal("last", table.Indexes[0].Columns[0].Name)
+			chk.Equal("first", table.Indexes[0].Columns[1].Name)
+		}
+	}
+	chk.Equal([]schema.ForeignKey{
+		{
+			Name:       "people_team_fkey",
+			Columns:    []schema.Column{{Name: "team_id"}, {Name: "team_org"}},
+			RefTable:   "teams",
+			RefColumns: []string{"id", "org"},
+			OnDelete:   "CASCADE",
+		},
+		{
+			Name:       "people_region_fkey",
+			Columns:    []schema.Column{{Name: "region"}},
+			RefTable:   "geo.regions",
+			RefColumns: []string{"code"},
+		},
+	}, table.ForeignKeys)
+}
+
+func TestPostgresGrammarInspectQualified(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	//
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pg_catalog.pg_attribute a")).
+		WithArgs("sales", "orders").
+		WillReturnRows(sqlmock.NewRows([]string{"attname", "format_type", "nullable", "default"}).
+			AddRow("id", "bigint", false, ""))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pg_catalog.pg_index x")).
+		WithArgs("sales", "orders").
+		WillReturnRows(sqlmock.NewRows([]string{"relname", "indisprimary", "indisunique", "attname"}))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pg_catalog.pg_constraint c")).
+		WithArgs("sales", "orders").
+		WillReturnRows(sqlmock.NewRows([]string{"conname", "attname", "relname", "refname", "confdeltype"}))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pg_catalog.pg_attribute a")).
+		WithArgs("", "missing").
+		WillReturnRows(sqlmock.NewRows([]string{"attname", "format_type", "nullable", "default"}))
+	//
+	tables, err := schema.Inspect(db, grammar.Postgres, "sales.orders")
+	chk.NoError(err)
+	if chk.Len(tables, 1) {
+		chk.Equal("sales", tables[0].Schema)
+		chk.Equal("orders", tables[0].Name)
+		chk.Equal("sales.orders", tables[0].QualifiedName())
+		chk.Equal([]schema.Column{{Name: "id", SqlType: "bigint"}}, tables[0].Columns)
+	}
+	//
+	tables, err = schema.Inspect(db, grammar.Postgres, "missing")
+	chk.Equal(schema.ErrTableNotFound, errors.Original(err))
+	chk.Nil(tables)
+	chk.NoError(mock.ExpectationsWereMet())
+}
+
+func TestSqliteGrammarInspect(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	//
+	mock.ExpectQuery(regexp.QuoteMeta("FROM sqlite_master")).
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("person_address"))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pragma_table_info( ? )")).
+		WithArgs("person_address").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "type", "notnull", "dflt_value", "pk"}).
+			AddRow("address_id", "INTEGER", true, "", int64(2)).
+			AddRow("person_id", "INTEGER", true, "", int64(1)).
+			AddRow("created", "DATETIME", false, "CURRENT_TIMESTAMP", int64(0)).
+			AddRow("label", "TEXT", false, "", int64(0)))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pragma_index_list( ? )")).
+		WithArgs("person_address").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "unique", "origin"}).
+			AddRow("label_idx", false, "c").
+			AddRow("sqlite_autoindex_person_address_1", true, "pk"))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pragma_index_info( ? )")).
+		WithArgs("label_idx").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("label"))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pragma_foreign_key_list( ? )")).
+		WithArgs("person_address").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "table", "from", "to", "on_delete"}).
+			AddRow(int64(0), "people", "person_id", "pk", "CASCADE").
+			AddRow(int64(1), "addresses", "address_id", "", "NO ACTION"))
+	//
+	tables, err := schema.Inspect(db, grammar.Sqlite)
+	chk.NoError(err)
+	chk.NoError(mock.ExpectationsWereMet())
+	if !chk.Len(tables, 1) {
+		return
+	}
+	table := tables[0]
+	chk.Equal("person_address", table.Name)
+	chk.Equal("sqlite_autoindex_person_address_1", table.PrimaryKey.Name)
+	if chk.Len(table.PrimaryKey.Columns, 2) {
+		chk.Equal("person_id", table.PrimaryKey.Columns[0].Name)
+		chk.Equal("address_id", table.PrimaryKey.Columns[1].Name)
+	}
+	chk.Equal([]schema.Column{
+		{Name: "created", SqlType: "DATETIME", Nullable: true, Default: "CURRENT_TIMESTAMP"},
+		{Name: "label", SqlType: "TEXT", Nullable: true},
+	}, table.Columns)
+	chk.Empty(table.Unique)
+	if chk.Len(table.Indexes, 1) {
+		chk.Equal("label_idx", table.Indexes[0].Name)
+		chk.Equal("label", table.Indexes[0].Columns[0].Name)
+	}
+	chk.Equal([]schema.ForeignKey{
+		{Columns: []schema.Column{{Name: "person_id"}}, RefTable: "people", RefColumns: []string{"pk"}, OnDelete: "CASCADE"},
+		{Columns: []schema.Column{{Name: "address_id"}}, RefTable: "addresses", RefColumns: []string{""}},
+	}, table.ForeignKeys)
+}
+
+func TestSqliteGrammarInspectQualified(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	//
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pragma_table_info( ?, ? )")).
+		WithArgs("logs", "aux").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "type", "notnull", "dflt_value", "pk"}).
+			AddRow("id", "INTEGER", false, "", int64(1)).
+			AddRow("message", "TEXT", true, "", int64(0)))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pragma_index_list( ?, ? )")).
+		WithArgs("logs", "aux").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "unique", "origin"}).
+			AddRow("logs_message", true, "u"))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pragma_index_info( ?, ? )")).
+		WithArgs("logs_message", "aux").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("message"))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pragma_foreign_key_list( ?, ? )")).
+		WithArgs("logs", "aux").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "table", "from", "to", "on_delete"}))
+	//
+	tables, err := schema.Inspect(db, grammar.Sqlite, "aux.logs")
+	chk.NoError(err)
+	chk.NoError(mock.ExpectationsWereMet())
+	if !chk.Len(tables, 1) {
+		return
+	}
+	chk.Equal("aux", tables[0].Schema)
+	chk.Equal("", tables[0].PrimaryKey.Name)
+	chk.True(tables[0].PrimaryKey.IsPrimary)
+	chk.Equal("id", tables[0].PrimaryKey.Columns[0].Name)
+	if chk.Len(tables[0].Unique, 1) {
+		chk.Equal("logs_message", tables[0].Unique[0].Name)
+	}
+}
+
+func TestInspectUnsupported(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, _, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	//
+	tables, err := schema.Inspect(db, struct{}{})
+	chk.Equal(schema.ErrInspectUnsupported, errors.Original(err))
+	chk.Nil(tables)
+	//
+}
@@ -0,0 +1,155 @@
+package grammar
+
+import (
+	"strings"
+
+	"github.com/nofeaturesonlybugs/errors"
+
+	"github.com/nofeaturesonlybugs/sqlh/schema"
+)
+
+// Migration contains the statements to apply and revert a list of schema changes.
+type Migration struct {
+	// Up contains the statements that apply the changes.
+	Up []string
+	// Down contains the statements that revert the changes.
+	Down []string
+}
+
+// UpScript returns the Up statements as a SQL script suitable for a migration file.
+func (me Migration) UpScript() string {
+	return script(me.Up)
+}
+
+// DownScript returns the Down statements as a SQL script suitable for a migration file.
+func (me Migration) DownScript() string {
+	return script(me.Down)
+}
+
+// script joins statements into a SQL script where each statement ends with a semicolon.
+func script(statements []string) string {
+	if len(statements) == 0 {
+		return ""
+	}
+	return strings.Join(statements, ";\n\n") + ";\n"
+}
+
+// migration returns the Migration for changes; the Down statements revert schema.Inverse(changes).
+func (me ddlDialect) migration(changes []schema.Change) (Migration, error) {
+	var rv Migration
+	for _, change := range changes {
+		statements, err := me.change(change)
+		if err != nil {
+			return Migration{}, errors.Go(err).Tag("change", change.String())
+		}
+		rv.Up = append(rv.Up, statements...)
+	}
+	for _, change := range schema.Inverse(changes) {
+		statements, err := me.change(change)
+		if err != nil {
+			return Migration{}, errors.Go(err).Tag("change", change.String())
+		}
+		rv.Down = append(rv.Down, statements...)
+	}
+	return rv, nil
+}
+
+// change returns the statements for a single change.
+func (me ddlDialect) change(change schema.Change) ([]string, error) {
+	table := me.Quoter.Quote(change.Table.QualifiedName())
+	switch change.Kind {
+	case schema.CreateTable:
+		statement, err := me.createTable(change.Table)
+		if err != nil {
+			return nil, errors.Go(err)
+		}
+		return []string{statement}, nil
+	case schema.DropTable:
+		return []string{"DROP TABLE " + table}, nil
+	case schema.AddColumn:
+		definition, _, err := me.column(change.Column, false, false)
+		if err != nil {
+			return nil, errors.Go(err)
+		}
+		return []string{"ALTER TABLE " + table + " ADD COLUMN " + definition}, nil
+	case schema.DropColumn:
+		return []string{"ALTER TABLE " + table + " DROP COLUMN " + me.Quoter.Quote(change.Column.Name)}, nil
+	case schema.AlterColumn:
+		return me.alterColumn(table, change.From, change.Column)
+	case schema.AddIndex, schema.DropIndex:
+		index := change.Index
+		if index.Table == "" {
+			index.Table = change.Table.QualifiedName()
+		}
+		if index.IsUnique {
+			return me.uniqueIndex(table, change.Kind, index)
+		} else if change.Kind == schema.DropIndex {
+			return []string{"DROP INDEX " + me.Quoter.Quote(qualifiedIndexName(index))}, nil
+		}
+		statement, err := me.createIndex(index)
+		if err != nil {
+			return nil, errors.Go(err)
+		}
+		return []string{statement}, nil
+	}
+	return nil, errors.Go(ErrUnsupportedChange).Tag("kind", change.Kind.String())
+}
+
+// uniqueIndex returns the statement that adds or drops the unique index of table.  createTable declares
+// unique indexes as table constraints; Postgres can not drop the index of a constraint with DROP INDEX
+// and SQLite can not drop the automatic index of a constraint at all, which returns ErrUnsupportedChange.
+func (me ddlDialect) uniqueIndex(table string, kind schema.ChangeKind, index schema.Index) ([]string, error) {
+	_, name := indexName(index)
+	switch {
+	case me.UniqueConstraints && kind == schema.AddIndex:
+		return []string{"ALTER TABLE " + table + " ADD " + me.constraint(name, "UNIQUE", index.Columns)}, nil
+	case me.UniqueConstraints:
+		return []string{"ALTER TABLE " + table + " DROP CONSTRAINT " + me.Quoter.Quote(name)}, nil
+	case kind == schema.AddIndex:
+		statement, err := me.createIndex(index)
+		if err != nil {
+			return nil, errors.Go(err)
+		}
+		return []string{statement}, nil
+	case strings.HasPrefix(name, "sqlite_autoindex_"):
+		return nil, errors.Go(ErrUnsupportedChange).Tag("kind", kind.String()).Tag("index", name).Tag("reason", "the index of a table constraint can only be dropped by rebuilding the table")
+	}
+	return []string{"DROP INDEX " + me.Quoter.Quote(qualifiedIndexName(index))}, nil
+}
+
+// qualifiedIndexName returns the name of index qualified by the schema of its table.
+func qualifiedIndexName(index schema.Index) string {
+	schemaName, name := indexName(index)
+	if schemaName != "" {
+		name = schemaName + "." + name
+	}
+	return name
+}
+
+// alterColumn returns the statement that alters column from into column to.
+func (me ddlDialect) alterColumn(table string, from, to schema.Column) ([]string, error) {
+	if !me.AlterColumn {
+		return nil, errors.Go(ErrUnsupportedChange).Tag("kind", schema.AlterColumn.String())
+	}
+	column := "ALTER COLUMN " + me.Quoter.Quote(to.Name)
+	var actions []string
+	if from.SqlType != "" && to.SqlType != "" && !strings.EqualFold(from.SqlType, to.SqlType) {
+		actions = append(actions, column+" TYPE "+to.SqlType)
+	}
+	if from.Nullable && !to.Nullable {
+		actions = append(actions, column+" SET NOT NULL")
+	} else if !from.Nullable && to.Nullable {
+		actions = append(actions, column+" DROP NOT NULL")
+	}
+	if !from.Auto && !to.Auto && from.Default != to.Default {
+		if to.Default == "" {
+			actions = append(actions, column+" DROP DEFAULT")
+		} else {
+			actions = append(actions, column+" SET DEFAULT "+to.Default)
+		}
+	}
+	if len(actions) == 0 {
+		return nil, nil
+	}
+	return []string{"ALTER TABLE " + table + "\n\t" + strings.Join(actions, ",\n\t")}, nil
+}
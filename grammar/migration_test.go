@@ -0,0 +1,106 @@
+package grammar_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nofeaturesonlybugs/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nofeaturesonlybugs/sqlh/grammar"
+	"github.com/nofeaturesonlybugs/sqlh/schema"
+)
+
+// migrationTables returns the from and to tables used to test migrations.
+func migrationTables() (from []schema.Table, to []schema.Table) {
+	pk := schema.Index{Columns: []schema.Column{{Name: "pk", SqlType: "INTEGER"}}, IsPrimary: true, IsUnique: true}
+	from = []schema.Table{
+		{
+			Schema:     "crm",
+			Name:       "people",
+			PrimaryKey: pk,
+			Columns: []schema.Column{
+				{Name: "email", SqlType: "TEXT"},
+				{Name: "ssn", SqlType: "TEXT", Nullable: true},
+			},
+			Indexes: []schema.Index{
+				{Name: "people_ssn", Columns: []schema.Column{{Name: "ssn"}}},
+			},
+		},
+	}
+	to = []schema.Table{
+		{
+			Schema:     "crm",
+			Name:       "people",
+			PrimaryKey: pk,
+			Columns: []schema.Column{
+				{Name: "email", SqlType: "TEXT"},
+				{Name: "age", GoType: int32(0), Nullable: true},
+			},
+			Unique: []schema.Index{
+				{Columns: []schema.Column{{Name: "email"}}, IsUnique: true},
+			},
+		},
+		{
+			Name:       "tags",
+			PrimaryKey: pk,
+			Columns: []schema.Column{
+				{Name: "label", GoType: ""},
+			},
+		},
+	}
+	return from, to
+}
+
+func TestPostgresGrammarMigration(t *testing.T) {
+	chk := assert.New(t)
+	//
+	from, to := migrationTables()
+	migration, err := grammar.Postgres.Migration(schema.Diff(from, to))
+	chk.NoError(err)
+	chk.Equal([]string{
+		"DROP INDEX crm.people_ssn",
+		"CREATE TABLE tags (\n\tpk INTEGER NOT NULL,\n\tlabel TEXT NOT NULL,\n\tPRIMARY KEY ( pk )\n)",
+		"ALTER TABLE crm.people ADD COLUMN age INTEGER",
+		"ALTER TABLE crm.people DROP COLUMN ssn",
+		"ALTER TABLE crm.people ADD CONSTRAINT people_email_key UNIQUE ( email )",
+	}, migration.Up)
+	chk.Equal([]string{
+		"ALTER TABLE crm.people DROP CONSTRAINT people_email_key",
+		"ALTER TABLE crm.people ADD COLUMN ssn TEXT",
+		"ALTER TABLE crm.people DROP COLUMN age",
+		"DROP TABLE tags",
+		"CREATE INDEX people_ssn ON crm.people ( ssn )",
+	}, migration.Down)
+	chk.True(strings.HasPrefix(migration.UpScript(), "DROP INDEX crm.people_ssn;\n\nCREATE TABLE tags ("))
+	chk.True(strings.HasSuffix(migration.DownScript(), "CREATE INDEX people_ssn ON crm.people ( ssn );\n"))
+	chk.Equal("", grammar.Migration{}.UpScript())
+	//
+	// Altered columns.
+	from[0].Columns[0].Nullable, to[0].Columns[0].SqlType, to[0].Columns[0].Default = true, "VARCHAR(64)", "''"
+	migration, err = grammar.Postgres.Migration(schema.Diff(from[:1], to[:1]))
+	chk.NoError(err)
+	chk.Contains(migration.Up, "ALTER TABLE crm.people\n\tALTER COLUMN email TYPE VARCHAR(64),\n\tALTER COLUMN email SET NOT NULL,\n\tALTER COLUMN email SET DEFAULT ''")
+	chk.Contains(migration.Down, "ALTER TABLE crm.people\n\tALTER COLUMN email TYPE TEXT,\n\tALTER COLUMN email DROP NOT NULL,\n\tALTER COLUMN email DROP DEFAULT")
+}
+
+func TestSqliteGrammarMigration(t *testing.T) {
+	chk := assert.New(t)
+	//
+	from, to := migrationTables()
+	migration, err := grammar.Sqlite.Migration(schema.Diff(from, to))
+	chk.NoError(err)
+	chk.Equal("DROP INDEX crm.people_ssn", migration.Up[0])
+	chk.Equal("CREATE UNIQUE INDEX crm.people_email_key ON people ( email )", migration.Up[4])
+	chk.Equal("DROP INDEX crm.people_email_key", migration.Down[0])
+	//
+	// Unique constraints declared by CREATE TABLE can not be dropped.
+	unique := schema.Index{Name: "sqlite_autoindex_people_1", Columns: []schema.Column{{Name: "email"}}, IsUnique: true}
+	_, err = grammar.Sqlite.Migration([]schema.Change{{Kind: schema.DropIndex, Table: to[0], Index: unique}})
+	chk.Equal(grammar.ErrUnsupportedChange, errors.Original(err))
+	//
+	// SQLite can not alter columns.
+	from[0].Columns[0].Nullable = true
+	_, err = grammar.Sqlite.Migration(schema.Diff(from, to))
+	chk.Equal(grammar.ErrUnsupportedChange, errors.Original(err))
+}
@@ -13,6 +13,26 @@ var Postgres Grammar = &PostgresGrammar{}
 
 // PostgresGrammar defines a grammar for Postgres.
 type PostgresGrammar struct {
+	// Quoting determines which identifiers are quoted with double quotes; the zero value
+	// does not quote identifiers.
+	Quoting Quoting
+}
+
+// quoter returns the Quoter for the grammar.
+func (me *PostgresGrammar) quoter() Quoter {
+	return DoubleQuotes.With(me.Quoting)
+}
+
+// Quote quotes identifier according to the grammar's Quoting.
+func (me *PostgresGrammar) Quote(identifier string) string {
+	return me.quoter().Quote(identifier)
+}
+
+// WithQuoting returns a copy of the grammar using Quoting q.
+func (me *PostgresGrammar) WithQuoting(q Quoting) Grammar {
+	rv := *me
+	rv.Quoting = q
+	return &rv
 }
 
 // ParamN returns the string for parameter N where N is zero-based and return value is one-based.
@@ -33,12 +53,12 @@ func (me *PostgresGrammar) Delete(table string, keys []string) (*statements.Quer
 	}
 	wheres := make([]string, keySize)
 	for k, key := range keys {
-		wheres[k] = key + " = " + me.ParamN(k)
+		wheres[k] = me.Quote(key) + " = " + me.ParamN(k)
 		rv.Arguments[k] = key
 	}
 	//
 	parts := []string{
-		"DELETE FROM " + table,
+		"DELETE FROM " + me.Quote(table),
 		"\tWHERE",
 		"\t\t" + strings.Join(wheres, " AND "),
 	}
@@ -64,13 +84,13 @@ func (me *PostgresGrammar) Insert(table string, columns []string, auto []string)
 	}
 	//
 	parts := []string{
-		"INSERT INTO " + table,
-		"\t\t( " + strings.Join(columns, ", ") + " )",
+		"INSERT INTO " + me.Quote(table),
+		"\t\t( " + strings.Join(me.quoter().QuoteAll(columns), ", ") + " )",
 		"\tVALUES",
 		"\t\t( " + strings.Join(values, ", ") + " )",
 	}
 	if len(auto) > 0 {
-		parts = append(parts, "\tRETURNING "+strings.Join(auto, ", "))
+		parts = append(parts, "\tRETURNING "+strings.Join(me.quoter().QuoteAll(auto), ", "))
 		rv.Scan = append([]string{}, auto...)
 		rv.Expect = statements.ExpectRow
 	}
@@ -94,23 +114,23 @@ func (me *PostgresGrammar) Update(table string, columns []string, keys []string,
 	//
 	sets, wheres := make([]string, colSize), make([]string, keySize)
 	for k, column := range columns {
-		sets[k] = column + " = " + me.ParamN(k)
+		sets[k] = me.Quote(column) + " = " + me.ParamN(k)
 		rv.Arguments[k] = column
 	}
 	for k, key := range keys {
 		total := colSize + k
-		wheres[k] = key + " = " + me.ParamN(total)
+		wheres[k] = me.Quote(key) + " = " + me.ParamN(total)
 		rv.Arguments[total] = key
 	}
 	//
 	parts := []string{
-		"UPDATE " + table + " SET",
+		"UPDATE " + me.Quote(table) + " SET",
 		"\t\t" + strings.Join(sets, ",\n\t\t"),
 		"\tWHERE",
 		"\t\t" + strings.Join(wheres, " AND "),
 	}
 	if len(auto) > 0 {
-		parts = append(parts, "\tRETURNING "+strings.Join(auto, ", "))
+		parts = append(parts, "\tRETURNING "+strings.Join(me.quoter().QuoteAll(auto), ", "))
 		rv.Scan = append([]string{}, auto...)
 		rv.Expect = statements.ExpectRowOrNone
 	}
@@ -120,49 +140,88 @@ func (me *PostgresGrammar) Update(table string, columns []string, keys []string,
 
 // Upsert returns the query type for upserting (INSERT|UPDATE) a record in a table.
 func (me *PostgresGrammar) Upsert(table string, columns []string, keys []string, auto []string) (*statements.Query, error) {
-	var colSize, keySize int
+	return me.UpsertInsertOnly(table, columns, nil, keys, auto)
+}
+
+// UpsertInsertOnly returns the query type for upserting (INSERT|UPDATE) a record in a table where
+// the columns in insertOnly are written during INSERT but are not part of the DO UPDATE SET list.
+func (me *PostgresGrammar) UpsertInsertOnly(table string, columns []string, insertOnly []string, keys []string, auto []string) (*statements.Query, error) {
+	return me.upsert(table, "", columns, insertOnly, keys, auto)
+}
+
+// UpsertSelect returns the query type for upserting every row of the source table into table with
+// INSERT ... SELECT.  source must have the columns keys, columns, and insertOnly.  The query returns
+// the keys followed by auto for each inserted or changed row so rows can be matched to their values.
+func (me *PostgresGrammar) UpsertSelect(table string, source string, columns []string, insertOnly []string, keys []string, auto []string) (*statements.Query, error) {
+	if source == "" {
+		return nil, errors.Go(ErrTableRequired).Tag("table", table).Tag("SQL", "SELECT")
+	}
+	return me.upsert(table, source, columns, insertOnly, keys, auto)
+}
+
+// upsert returns the query type for UpsertInsertOnly when source is empty and UpsertSelect otherwise.
+func (me *PostgresGrammar) upsert(table string, source string, columns []string, insertOnly []string, keys []string, auto []string) (*statements.Query, error) {
+	var colSize, insertOnlySize, keySize int
 	if table == "" {
 		return nil, errors.Go(ErrTableRequired)
-	} else if colSize = len(columns); colSize == 0 {
+	} else if colSize, insertOnlySize = len(columns), len(insertOnly); colSize+insertOnlySize == 0 {
 		return nil, errors.Go(ErrColumnsRequired).Tag("table", table).Tag("SQL", "UPDATE")
 	} else if keySize = len(keys); keySize == 0 {
 		return nil, errors.Go(ErrKeysRequired).Tag("table", table).Tag("SQL", "UPDATE")
 	}
-	// Both keys + columns are combined for the INSERT portion of the query.
-	sizeInsert := colSize + keySize
+	// Keys, columns, and insertOnly are combined for the INSERT portion of the query.
+	sizeInsert := colSize + insertOnlySize + keySize
 	rv := &statements.Query{
 		Arguments: make([]string, sizeInsert),
 	}
 	copy(rv.Arguments[0:], keys)
 	copy(rv.Arguments[keySize:], columns)
-	// INSERT...VALUES portion.
-	values := make([]string, sizeInsert)
-	for k := range rv.Arguments {
-		values[k] = me.ParamN(k)
-	}
+	copy(rv.Arguments[keySize+colSize:], insertOnly)
 	// Create an AS alias for the target table.
 	alias := "dest"
 	// Only columns are used for the DO UPDATE portion of the query.
 	updateColumns := make([]string, colSize)
 	whereColumns := make([]string, colSize)
 	for k, column := range columns {
+		column = me.Quote(column)
 		updateColumns[k] = column + " = EXCLUDED." + column
 		whereColumns[k] = alias + "." + column + " <> EXCLUDED." + column
 	}
 	//
 	parts := []string{
-		"INSERT INTO " + table + " AS " + alias,
-		"\t\t( " + strings.Join(rv.Arguments, ", ") + " )",
-		"\tVALUES",
-		"\t\t( " + strings.Join(values, ", ") + " )",
-		"\tON CONFLICT( " + strings.Join(keys, ", ") + " ) DO UPDATE SET",
-		"\t\t" + strings.Join(updateColumns, ", "),
-		"\t\tWHERE (",
-		"\t\t\t" + strings.Join(whereColumns, " OR "),
-		"\t\t)",
-	}
-	if len(auto) > 0 {
-		parts = append(parts, "\tRETURNING "+strings.Join(auto, ", "))
+		"INSERT INTO " + me.Quote(table) + " AS " + alias,
+		"\t\t( " + strings.Join(me.quoter().QuoteAll(rv.Arguments), ", ") + " )",
+	}
+	if source == "" {
+		// INSERT...VALUES portion.
+		values := make([]string, sizeInsert)
+		for k := range rv.Arguments {
+			values[k] = me.ParamN(k)
+		}
+		parts = append(parts, "\tVALUES", "\t\t( "+strings.Join(values, ", ")+" )")
+	} else {
+		parts = append(parts, "\tSELECT "+strings.Join(me.quoter().QuoteAll(rv.Arguments), ", ")+" FROM "+me.Quote(source))
+	}
+	if colSize == 0 {
+		// Every non-key column is insert only so there is nothing to update.
+		parts = append(parts, "\tON CONFLICT( "+strings.Join(me.quoter().QuoteAll(keys), ", ")+" ) DO NOTHING")
+	} else {
+		parts = append(parts,
+			"\tON CONFLICT( "+strings.Join(me.quoter().QuoteAll(keys), ", ")+" ) DO UPDATE SET",
+			"\t\t"+strings.Join(updateColumns, ", "),
+			"\t\tWHERE (",
+			"\t\t\t"+strings.Join(whereColumns, " OR "),
+			"\t\t)",
+		)
+	}
+	if source != "" {
+		// Keys are returned so each row can be matched to its value.
+		rv.Scan = append(append([]string{}, keys...), auto...)
+		rv.Expect = statements.ExpectRows
+		parts = append(parts, "\tRETURNING "+strings.Join(me.quoter().QuoteAll(rv.Scan), ", "))
+		rv.Arguments = nil
+	} else if len(auto) > 0 {
+		parts = append(parts, "\tRETURNING "+strings.Join(me.quoter().QuoteAll(auto), ", "))
 		rv.Scan = append([]string{}, auto...)
 		rv.Expect = statements.ExpectRowOrNone
 	}
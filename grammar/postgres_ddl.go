@@ -0,0 +1,63 @@
+package grammar
+
+import (
+	"github.com/nofeaturesonlybugs/errors"
+
+	"github.com/nofeaturesonlybugs/sqlh/schema"
+)
+
+// ddl returns the ddlDialect for the grammar.
+func (me *PostgresGrammar) ddl() ddlDialect {
+	return ddlDialect{
+		Quoter: me.quoter(),
+		Types: map[ddlType]string{
+			ddlSmallInt: "SMALLINT",
+			ddlInteger:  "INTEGER",
+			ddlBigInt:   "BIGINT",
+			ddlReal:     "REAL",
+			ddlDouble:   "DOUBLE PRECISION",
+			ddlBool:     "BOOLEAN",
+			ddlText:     "TEXT",
+			ddlTime:     "TIMESTAMP WITH TIME ZONE",
+			ddlBytes:    "BYTEA",
+		},
+		AlterColumn:       true,
+		UniqueConstraints: true,
+		AutoKey: func(sqlType string, single bool) (string, bool) {
+			return sqlType + " GENERATED BY DEFAULT AS IDENTITY", false
+		},
+	}
+}
+
+// CreateTable returns the CREATE TABLE statement for table.  Auto keys are IDENTITY columns.
+func (me *PostgresGrammar) CreateTable(table schema.Table) (string, error) {
+	rv, err := me.ddl().createTable(table)
+	if err != nil {
+		return "", errors.Go(err)
+	}
+	return rv, nil
+}
+
+// SqlType returns the SQL type CreateTable uses for the Go type of value or an empty string if the
+// type is unknown.
+func (me *PostgresGrammar) SqlType(value interface{}) string {
+	return me.ddl().sqlType(value)
+}
+
+// CreateIndex returns the CREATE INDEX statement for index.
+func (me *PostgresGrammar) CreateIndex(index schema.Index) (string, error) {
+	rv, err := me.ddl().createIndex(index)
+	if err != nil {
+		return "", errors.Go(err)
+	}
+	return rv, nil
+}
+
+// Migration returns the statements to apply and revert changes.
+func (me *PostgresGrammar) Migration(changes []schema.Change) (Migration, error) {
+	rv, err := me.ddl().migration(changes)
+	if err != nil {
+		return Migration{}, errors.Go(err)
+	}
+	return rv, nil
+}
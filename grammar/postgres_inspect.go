@@ -0,0 +1,161 @@
+package grammar
+
+import (
+	"strings"
+
+	"github.com/nofeaturesonlybugs/errors"
+
+	"github.com/nofeaturesonlybugs/sqlh"
+	"github.com/nofeaturesonlybugs/sqlh/schema"
+)
+
+var (
+	// pgInspectTables lists the tables in the current schema.
+	pgInspectTables = strings.Join([]string{
+		"SELECT table_name FROM information_schema.tables",
+		"\tWHERE table_schema = current_schema() AND table_type = 'BASE TABLE'",
+		"\tORDER BY table_name",
+	}, "\n")
+	// pgInspectColumns lists the columns of a table; $1 is the schema or empty for the current schema.
+	pgInspectColumns = strings.Join([]string{
+		"SELECT a.attname, pg_catalog.format_type( a.atttypid, a.atttypmod ), NOT a.attnotnull,",
+		"\t\tCOALESCE( pg_catalog.pg_get_expr( d.adbin, d.adrelid ), '' )",
+		"\tFROM pg_catalog.pg_attribute a",
+		"\tINNER JOIN pg_catalog.pg_class c ON c.oid = a.attrelid",
+		"\tINNER JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace",
+		"\tLEFT JOIN pg_catalog.pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum",
+		"\tWHERE n.nspname = COALESCE( NULLIF( $1, '' ), current_schema() ) AND c.relname = $2",
+		"\t\tAND c.relkind IN ( 'r', 'p' ) AND a.attnum > 0 AND NOT a.attisdropped",
+		"\tORDER BY a.attnum",
+	}, "\n")
+	// pgInspectIndexes lists the index columns of a table; $1 is the schema or empty for the current schema.
+	pgInspectIndexes = strings.Join([]string{
+		"SELECT i.relname, x.indisprimary, x.indisunique, a.attname",
+		"\tFROM pg_catalog.pg_index x",
+		"\tINNER JOIN pg_catalog.pg_class t ON t.oid = x.indrelid",
+		"\tINNER JOIN pg_catalog.pg_class i ON i.oid = x.indexrelid",
+		"\tINNER JOIN pg_catalog.pg_namespace n ON n.oid = t.relnamespace",
+		"\tINNER JOIN LATERAL unnest( x.indkey ) WITH ORDINALITY AS k( attnum, position ) ON true",
+		"\tINNER JOIN pg_catalog.pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum",
+		"\tWHERE n.nspname = COALESCE( NULLIF( $1, '' ), current_schema() ) AND t.relname = $2",
+		"\tORDER BY i.relname, k.position",
+	}, "\n")
+	// pgInspectForeignKeys lists the foreign key columns of a table; $1 is the schema or empty for the
+	// current schema.  Referenced tables in other schemas are schema qualified.
+	pgInspectForeignKeys = strings.Join([]string{
+		"SELECT c.conname, a.attname,",
+		"\t\tCASE WHEN rn.nspname = n.nspname THEN rc.relname ELSE rn.nspname || '.' || rc.relname END,",
+		"\t\tra.attname, c.confdeltype",
+		"\tFROM pg_catalog.pg_constraint c",
+		"\tINNER JOIN pg_catalog.pg_class t ON t.oid = c.conrelid",
+		"\tINNER JOIN pg_catalog.pg_namespace n ON n.oid = t.relnamespace",
+		"\tINNER JOIN pg_catalog.pg_class rc ON rc.oid = c.confrelid",
+		"\tINNER JOIN pg_catalog.pg_namespace rn ON rn.oid = rc.relnamespace",
+		"\tINNER JOIN LATERAL unnest( c.conkey, c.confkey ) WITH ORDINALITY AS k( attnum, refnum, position ) ON true",
+		"\tINNER JOIN pg_catalog.pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum",
+		"\tINNER JOIN pg_catalog.pg_attribute ra ON ra.attrelid = c.confrelid AND ra.attnum = k.refnum",
+		"\tWHERE c.contype = 'f' AND n.nspname = COALESCE( NULLIF( $1, '' ), current_schema() ) AND t.relname = $2",
+		"\tORDER BY c.conname, k.position",
+	}, "\n")
+	// pgOnDelete maps pg_constraint.confdeltype to the ON DELETE action.
+	pgOnDelete = map[string]string{
+		"a": "",
+		"r": "RESTRICT",
+		"c": "CASCADE",
+		"n": "SET NULL",
+		"d": "SET DEFAULT",
+	}
+)
+
+// Inspect returns the named tables from information_schema and pg_catalog; if no names are given
+// then every table in the current schema is returned.
+func (me *PostgresGrammar) Inspect(Q sqlh.IQueries, tables ...string) ([]schema.Table, error) {
+	var err error
+	if len(tables) == 0 {
+		if tables, err = queryStrings(Q, pgInspectTables); err != nil {
+			return nil, errors.Go(err)
+		}
+	}
+	rv := make([]schema.Table, 0, len(tables))
+	for _, table := range tables {
+		schemaName, name := splitTableName(table)
+		inspected, err := me.inspectTable(Q, schemaName, name)
+		if err != nil {
+			return nil, errors.Go(err).Tag("table", table)
+		}
+		rv = append(rv, inspected)
+	}
+	return rv, nil
+}
+
+// inspectTable reads a single table.
+func (me *PostgresGrammar) inspectTable(Q sqlh.IQueries, schemaName string, name string) (schema.Table, error) {
+	var columns []schema.Column
+	rows, err := Q.Query(pgInspectColumns, schemaName, name)
+	if err != nil {
+		return schema.Table{}, errors.Go(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var column schema.Column
+		if err = rows.Scan(&column.Name, &column.SqlType, &column.Nullable, &column.Default); err != nil {
+			return schema.Table{}, errors.Go(err)
+		}
+		columns = append(columns, column)
+	}
+	if err = rows.Err(); err != nil {
+		return schema.Table{}, errors.Go(err)
+	} else if len(columns) == 0 {
+		return schema.Table{}, errors.Go(schema.ErrTableNotFound)
+	}
+	rows.Close()
+	//
+	var indexes []inspectedIndex
+	if rows, err = Q.Query(pgInspectIndexes, schemaName, name); err != nil {
+		return schema.Table{}, errors.Go(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var index inspectedIndex
+		var column string
+		if err = rows.Scan(&index.Name, &index.Primary, &index.Unique, &column); err != nil {
+			return schema.Table{}, errors.Go(err)
+		}
+		// Rows are ordered by index name so consecutive rows belong to the same index.
+		if size := len(indexes); size > 0 && indexes[size-1].Name == index.Name {
+			indexes[size-1].Columns = append(indexes[size-1].Columns, column)
+		} else {
+			index.Columns = []string{column}
+			indexes = append(indexes, index)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return schema.Table{}, errors.Go(err)
+	}
+	rows.Close()
+	//
+	var foreignKeys []inspectedForeignKey
+	if rows, err = Q.Query(pgInspectForeignKeys, schemaName, name); err != nil {
+		return schema.Table{}, errors.Go(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var foreignKey inspectedForeignKey
+		var column, refColumn, onDelete string
+		if err = rows.Scan(&foreignKey.Name, &column, &foreignKey.RefTable, &refColumn, &onDelete); err != nil {
+			return schema.Table{}, errors.Go(err)
+		}
+		// Rows are ordered by constraint name so consecutive rows belong to the same foreign key.
+		if size := len(foreignKeys); size > 0 && foreignKeys[size-1].Name == foreignKey.Name {
+			foreignKeys[size-1].Columns = append(foreignKeys[size-1].Columns, column)
+			foreignKeys[size-1].RefColumns = append(foreignKeys[size-1].RefColumns, refColumn)
+		} else {
+			foreignKey.Columns, foreignKey.RefColumns, foreignKey.OnDelete = []string{column}, []string{refColumn}, pgOnDelete[onDelete]
+			foreignKeys = append(foreignKeys, foreignKey)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return schema.Table{}, errors.Go(err)
+	}
+	return assembleTable(schemaName, name, columns, indexes, foreignKeys), nil
+}
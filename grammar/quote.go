@@ -0,0 +1,157 @@
+package grammar
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Quoting describes which identifiers a grammar quotes when generating SQL.
+type Quoting int
+
+const (
+	// QuoteDefault is the zero value.  Grammars treat QuoteDefault as QuoteNone; model.Models
+	// treats QuoteDefault as "use the quoting of the grammar".
+	QuoteDefault Quoting = iota
+	// QuoteNone never quotes identifiers.
+	QuoteNone
+	// QuoteRequired quotes identifiers that are reserved words, contain upper case letters,
+	// or contain characters other than lower case letters, digits, and underscores.
+	QuoteRequired
+	// QuoteAll quotes every identifier.
+	QuoteAll
+)
+
+// String returns the Quoting value as a string.
+func (me Quoting) String() string {
+	if names := [...]string{"Default", "None", "Required", "All"}; me >= 0 && int(me) < len(names) {
+		return names[me]
+	}
+	return fmt.Sprintf("Quoting(%d)", int(me))
+}
+
+// Quoter quotes SQL identifiers.  Identifiers containing a period are treated as qualified
+// names (i.e. schema.table) and each part is quoted separately.
+type Quoter struct {
+	// Open and Close are placed around quoted identifiers.
+	Open, Close string
+	// Quoting determines which identifiers are quoted.
+	Quoting Quoting
+}
+
+var (
+	// DoubleQuotes quotes identifiers as "name" and is used by Postgres and SQLite.
+	DoubleQuotes = Quoter{Open: `"`, Close: `"`, Quoting: QuoteAll}
+	// Backticks quotes identifiers as `name` and is intended for grammars targeting MySQL or MariaDB.
+	Backticks = Quoter{Open: "`", Close: "`", Quoting: QuoteAll}
+)
+
+// With returns a copy of the Quoter with its Quoting set to q.
+func (me Quoter) With(q Quoting) Quoter {
+	me.Quoting = q
+	return me
+}
+
+// Quote returns identifier quoted according to the Quoter's Quoting.  An identifier that is already
+// quoted, where every part is enclosed in Open and Close with any Close within it doubled, is returned
+// unchanged; otherwise Close is escaped in every quoted part.
+func (me Quoter) Quote(identifier string) string {
+	if me.Quoting == QuoteDefault || me.Quoting == QuoteNone || identifier == "" {
+		return identifier
+	} else if me.quoted(identifier) {
+		return identifier
+	}
+	parts := strings.Split(identifier, ".")
+	for k, part := range parts {
+		if me.Quoting == QuoteAll || requiresQuote(part) {
+			parts[k] = me.Open + strings.ReplaceAll(part, me.Close, me.Close+me.Close) + me.Close
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// quoted returns true if every period separated part of identifier is a correctly terminated
+// quoted identifier.
+func (me Quoter) quoted(identifier string) bool {
+	if me.Open == "" || me.Close == "" {
+		return false
+	}
+	for rest := identifier; ; {
+		if !strings.HasPrefix(rest, me.Open) {
+			return false
+		}
+		rest = rest[len(me.Open):]
+		for {
+			end := strings.Index(rest, me.Close)
+			if end == -1 {
+				return false
+			} else if rest = rest[end+len(me.Close):]; strings.HasPrefix(rest, me.Close) {
+				rest = rest[len(me.Close):] // Escaped Close.
+				continue
+			}
+			break
+		}
+		if rest == "" {
+			return true
+		} else if !strings.HasPrefix(rest, ".") {
+			return false
+		}
+		rest = rest[1:]
+	}
+}
+
+// QuoteAll returns a new slice with every identifier quoted.
+func (me Quoter) QuoteAll(identifiers []string) []string {
+	rv := make([]string, len(identifiers))
+	for k, identifier := range identifiers {
+		rv[k] = me.Quote(identifier)
+	}
+	return rv
+}
+
+// requiresQuote returns true if identifier must be quoted to be used as-is.
+func requiresQuote(identifier string) bool {
+	if identifier == "" || reserved[strings.ToLower(identifier)] {
+		return true
+	}
+	for k, r := range identifier {
+		switch {
+		case r >= 'a' && r <= 'z', r == '_':
+		case r >= '0' && r <= '9':
+			if k == 0 {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// reserved contains the reserved words of Postgres and SQLite that can not be used as
+// unquoted identifiers.
+var reserved = map[string]bool{}
+
+func init() {
+	words := []string{
+		"abort", "action", "add", "after", "all", "alter", "analyse", "analyze", "and", "any", "array",
+		"as", "asc", "asymmetric", "attach", "authorization", "autoincrement", "before", "begin", "between",
+		"binary", "both", "by", "cascade", "case", "cast", "check", "collate", "collation", "column", "commit",
+		"concurrently", "conflict", "constraint", "create", "cross", "current_catalog", "current_date",
+		"current_role", "current_schema", "current_time", "current_timestamp", "current_user", "database",
+		"default", "deferrable", "deferred", "delete", "desc", "detach", "distinct", "do", "drop", "each",
+		"else", "end", "escape", "except", "exclusive", "exists", "explain", "fail", "false", "fetch", "for",
+		"foreign", "freeze", "from", "full", "glob", "grant", "group", "having", "if", "ignore", "ilike",
+		"immediate", "in", "index", "indexed", "initially", "inner", "insert", "instead", "intersect", "into",
+		"is", "isnull", "join", "key", "lateral", "leading", "left", "like", "limit", "localtime",
+		"localtimestamp", "match", "natural", "no", "not", "notnull", "null", "of", "offset", "on", "only",
+		"or", "order", "outer", "overlaps", "placing", "plan", "pragma", "primary", "query", "raise",
+		"recursive", "references", "regexp", "reindex", "release", "rename", "replace", "restrict",
+		"returning", "right", "rollback", "row", "savepoint", "select", "session_user", "set", "similar",
+		"some", "symmetric", "table", "tablesample", "temp", "temporary", "then", "to", "trailing",
+		"transaction", "trigger", "true", "union", "unique", "update", "user", "using", "vacuum", "values",
+		"variadic", "verbose", "view", "virtual", "when", "where", "window", "with",
+	}
+	for _, word := range words {
+		reserved[word] = true
+	}
+}
@@ -0,0 +1,115 @@
+package grammar_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nofeaturesonlybugs/sqlh/grammar"
+)
+
+func TestQuoter(t *testing.T) {
+	tests := []struct {
+		Quoting    grammar.Quoting
+		Identifier string
+		Expect     string
+	}{
+		{grammar.QuoteDefault, "order", "order"},
+		{grammar.QuoteNone, "order", "order"},
+		{grammar.QuoteRequired, "", ""},
+		{grammar.QuoteRequired, "name", "name"},
+		{grammar.QuoteRequired, "first_name2", "first_name2"},
+		{grammar.QuoteRequired, "order", `"order"`},
+		{grammar.QuoteRequired, "USER", `"USER"`},
+		{grammar.QuoteRequired, "FirstName", `"FirstName"`},
+		{grammar.QuoteRequired, "2fa", `"2fa"`},
+		{grammar.QuoteRequired, "has space", `"has space"`},
+		{grammar.QuoteRequired, "audit.order", `audit."order"`},
+		{grammar.QuoteAll, "audit.people", `"audit"."people"`},
+		{grammar.QuoteAll, `say"what`, `"say""what"`},
+		{grammar.QuoteAll, `"already"`, `"already"`},
+		{grammar.QuoteAll, `"audit"."say""what"`, `"audit"."say""what"`},
+		{grammar.QuoteAll, `"x"; DROP TABLE t; --`, `"""x""; DROP TABLE t; --"`},
+		{grammar.QuoteRequired, `"x"; DROP TABLE t; --`, `"""x""; DROP TABLE t; --"`},
+		{grammar.QuoteAll, `"unterminated`, `"""unterminated"`},
+		{grammar.QuoteAll, `"a"b`, `"""a""b"`},
+	}
+	for _, test := range tests {
+		t.Run(test.Quoting.String()+" "+test.Identifier, func(t *testing.T) {
+			chk := assert.New(t)
+			chk.Equal(test.Expect, grammar.DoubleQuotes.With(test.Quoting).Quote(test.Identifier))
+		})
+	}
+	t.Run("string", func(t *testing.T) {
+		chk := assert.New(t)
+		chk.Equal("All", grammar.QuoteAll.String())
+		chk.Equal("Quoting(7)", grammar.Quoting(7).String())
+		chk.Equal("Quoting(-1)", grammar.Quoting(-1).String())
+	})
+	t.Run("backticks", func(t *testing.T) {
+		chk := assert.New(t)
+		chk.Equal("`db`.`order`", grammar.Backticks.Quote("db.order"))
+		chk.Equal([]string{"`a`", "`b`"}, grammar.Backticks.QuoteAll([]string{"a", "b"}))
+	})
+}
+
+func TestGrammarsQuoting(t *testing.T) {
+	chk := assert.New(t)
+	//
+	columns, keys, auto := []string{"user", "name"}, []string{"order"}, []string{"created"}
+	{
+		g := grammar.Postgres.WithQuoting(grammar.QuoteRequired)
+		chk.Equal(grammar.QuoteDefault, grammar.Postgres.(*grammar.PostgresGrammar).Quoting)
+		//
+		query, err := g.Insert("shop.order", append(append([]string{}, keys...), columns...), auto)
+		chk.NoError(err)
+		chk.Equal("INSERT INTO shop.\"order\"\n\t\t( \"order\", \"user\", name )\n\tVALUES\n\t\t( $1, $2, $3 )\n\tRETURNING created", query.SQL)
+		chk.Equal([]string{"order", "user", "name"}, query.Arguments)
+		//
+		query, err = g.Update("shop.order", columns, keys, nil)
+		chk.NoError(err)
+		chk.Equal("UPDATE shop.\"order\" SET\n\t\t\"user\" = $1,\n\t\tname = $2\n\tWHERE\n\t\t\"order\" = $3", query.SQL)
+		//
+		query, err = g.Delete("shop.order", keys)
+		chk.NoError(err)
+		chk.Equal("DELETE FROM shop.\"order\"\n\tWHERE\n\t\t\"order\" = $1", query.SQL)
+		//
+		query, err = g.Upsert("shop.order", columns, keys, nil)
+		chk.NoError(err)
+		parts := []string{
+			"INSERT INTO shop.\"order\" AS dest\n\t\t( \"order\", \"user\", name )\n\tVALUES\n\t\t( $1, $2, $3 )",
+			"\tON CONFLICT( \"order\" ) DO UPDATE SET",
+			"\t\t\"user\" = EXCLUDED.\"user\", name = EXCLUDED.name",
+			"\t\tWHERE (\n\t\t\tdest.\"user\" <> EXCLUDED.\"user\" OR dest.name <> EXCLUDED.name\n\t\t)",
+		}
+		chk.Equal(strings.Join(parts, "\n"), query.SQL)
+		chk.Equal([]string{"order", "user", "name"}, query.Arguments)
+	}
+	{
+		g := grammar.Sqlite.WithQuoting(grammar.QuoteAll)
+		chk.Equal(grammar.QuoteDefault, grammar.Sqlite.(*grammar.SqliteGrammar).Quoting)
+		//
+		query, err := g.Insert("order", columns, auto)
+		chk.NoError(err)
+		chk.Equal("INSERT INTO \"order\"\n\t\t( \"user\", \"name\" )\n\tVALUES\n\t\t( ?, ? )\n\tRETURNING \"created\"", query.SQL)
+		//
+		query, err = g.Update("order", columns, keys, nil)
+		chk.NoError(err)
+		chk.Equal("UPDATE \"order\" SET\n\t\t\"user\" = ?,\n\t\t\"name\" = ?\n\tWHERE\n\t\t\"order\" = ?", query.SQL)
+		//
+		query, err = g.Delete("order", keys)
+		chk.NoError(err)
+		chk.Equal("DELETE FROM \"order\"\n\tWHERE\n\t\t\"order\" = ?", query.SQL)
+		//
+		query, err = g.Upsert("order", columns, keys, nil)
+		chk.NoError(err)
+		parts := []string{
+			"INSERT INTO \"order\"\n\t\t( \"order\", \"user\", \"name\" )\n\tVALUES\n\t\t( ?, ?, ? )",
+			"\tON CONFLICT( \"order\" ) DO UPDATE SET",
+			"\t\t\"order\".\"user\" = EXCLUDED.\"user\", \"order\".\"name\" = EXCLUDED.\"name\"",
+			"\t\tWHERE (\n\t\t\t\"order\".\"user\" <> EXCLUDED.\"user\" OR \"order\".\"name\" <> EXCLUDED.\"name\"\n\t\t)",
+		}
+		chk.Equal(strings.Join(parts, "\n"), query.SQL)
+	}
+}
@@ -12,6 +12,26 @@ var Sqlite Grammar = &SqliteGrammar{}
 
 // SqliteGrammar defines a grammar for SQLite v2.35+.
 type SqliteGrammar struct {
+	// Quoting determines which identifiers are quoted with double quotes; the zero value
+	// does not quote identifiers.
+	Quoting Quoting
+}
+
+// quoter returns the Quoter for the grammar.
+func (me *SqliteGrammar) quoter() Quoter {
+	return DoubleQuotes.With(me.Quoting)
+}
+
+// Quote quotes identifier according to the grammar's Quoting.
+func (me *SqliteGrammar) Quote(identifier string) string {
+	return me.quoter().Quote(identifier)
+}
+
+// WithQuoting returns a copy of the grammar using Quoting q.
+func (me *SqliteGrammar) WithQuoting(q Quoting) Grammar {
+	rv := *me
+	rv.Quoting = q
+	return &rv
 }
 
 // Delete returns the query type for deleting from the table.
@@ -28,12 +48,12 @@ func (me *SqliteGrammar) Delete(table string, keys []string) (*statements.Query,
 	//
 	wheres := make([]string, keySize)
 	for k, key := range keys {
-		wheres[k] = key + " = ?"
+		wheres[k] = me.Quote(key) + " = ?"
 		rv.Arguments[k] = key
 	}
 	//
 	parts := []string{
-		"DELETE FROM " + table,
+		"DELETE FROM " + me.Quote(table),
 		"\tWHERE",
 		"\t\t" + strings.Join(wheres, " AND "),
 	}
@@ -56,13 +76,13 @@ func (me *SqliteGrammar) Insert(table string, columns []string, auto []string) (
 	values := "?" + strings.Repeat(", ?", colSize-1)
 	//
 	parts := []string{
-		"INSERT INTO " + table,
-		"\t\t( " + strings.Join(columns, ", ") + " )",
+		"INSERT INTO " + me.Quote(table),
+		"\t\t( " + strings.Join(me.quoter().QuoteAll(columns), ", ") + " )",
 		"\tVALUES",
 		"\t\t( " + values + " )",
 	}
 	if len(auto) > 0 {
-		parts = append(parts, "\tRETURNING "+strings.Join(auto, ", "))
+		parts = append(parts, "\tRETURNING "+strings.Join(me.quoter().QuoteAll(auto), ", "))
 		rv.Scan = append([]string{}, auto...)
 		rv.Expect = statements.ExpectRow
 	}
@@ -85,22 +105,22 @@ func (me *SqliteGrammar) Update(table string, columns []string, keys []string, a
 	}
 	sets, wheres := make([]string, colSize), make([]string, keySize)
 	for k, column := range columns {
-		sets[k] = column + " = ?"
+		sets[k] = me.Quote(column) + " = ?"
 		rv.Arguments[k] = column
 	}
 	for k, key := range keys {
-		wheres[k] = key + " = ?"
+		wheres[k] = me.Quote(key) + " = ?"
 		rv.Arguments[colSize+k] = key
 	}
 	//
 	parts := []string{
-		"UPDATE " + table + " SET",
+		"UPDATE " + me.Quote(table) + " SET",
 		"\t\t" + strings.Join(sets, ",\n\t\t"),
 		"\tWHERE",
 		"\t\t" + strings.Join(wheres, " AND "),
 	}
 	if len(auto) > 0 {
-		parts = append(parts, "\tRETURNING "+strings.Join(auto, ", "))
+		parts = append(parts, "\tRETURNING "+strings.Join(me.quoter().QuoteAll(auto), ", "))
 		rv.Scan = append([]string{}, auto...)
 		rv.Expect = statements.ExpectRow
 	}
@@ -110,45 +130,85 @@ func (me *SqliteGrammar) Update(table string, columns []string, keys []string, a
 
 // Upsert returns the query type for upserting (INSERT|UPDATE) a record in a table.
 func (me *SqliteGrammar) Upsert(table string, columns []string, keys []string, auto []string) (*statements.Query, error) {
-	var colSize, keySize int
+	return me.UpsertInsertOnly(table, columns, nil, keys, auto)
+}
+
+// UpsertInsertOnly returns the query type for upserting (INSERT|UPDATE) a record in a table where
+// the columns in insertOnly are written during INSERT but are not part of the DO UPDATE SET list.
+func (me *SqliteGrammar) UpsertInsertOnly(table string, columns []string, insertOnly []string, keys []string, auto []string) (*statements.Query, error) {
+	return me.upsert(table, "", columns, insertOnly, keys, auto)
+}
+
+// UpsertSelect returns the query type for upserting every row of the source table into table with
+// INSERT ... SELECT.  source must have the columns keys, columns, and insertOnly.  The query returns
+// the keys followed by auto for each inserted or changed row so rows can be matched to their values.
+func (me *SqliteGrammar) UpsertSelect(table string, source string, columns []string, insertOnly []string, keys []string, auto []string) (*statements.Query, error) {
+	if source == "" {
+		return nil, errors.Go(ErrTableRequired).Tag("table", table).Tag("SQL", "SELECT")
+	}
+	return me.upsert(table, source, columns, insertOnly, keys, auto)
+}
+
+// upsert returns the query type for UpsertInsertOnly when source is empty and UpsertSelect otherwise.
+func (me *SqliteGrammar) upsert(table string, source string, columns []string, insertOnly []string, keys []string, auto []string) (*statements.Query, error) {
+	var colSize, insertOnlySize, keySize int
 	if table == "" {
 		return nil, errors.Go(ErrTableRequired)
-	} else if colSize = len(columns); colSize == 0 {
+	} else if colSize, insertOnlySize = len(columns), len(insertOnly); colSize+insertOnlySize == 0 {
 		return nil, errors.Go(ErrColumnsRequired).Tag("table", table).Tag("SQL", "UPDATE")
 	} else if keySize = len(keys); keySize == 0 {
 		return nil, errors.Go(ErrKeysRequired).Tag("table", table).Tag("SQL", "UPDATE")
 	}
-	// Both keys + columns are combined for the INSERT portion of the query.
-	sizeInsert := colSize + keySize
+	// Keys, columns, and insertOnly are combined for the INSERT portion of the query.
+	sizeInsert := colSize + insertOnlySize + keySize
 	rv := &statements.Query{
 		Arguments: make([]string, sizeInsert),
 	}
 	copy(rv.Arguments[0:], keys)
 	copy(rv.Arguments[keySize:], columns)
+	copy(rv.Arguments[keySize+colSize:], insertOnly)
 	// Only columns are used for the DO UPDATE portion of the query.
 	updateColumns := make([]string, colSize)
 	whereColumns := make([]string, colSize)
+	quotedTable := me.Quote(table)
 	for k, column := range columns {
-		updateColumns[k] = table + "." + column + " = EXCLUDED." + column
-		whereColumns[k] = table + "." + column + " <> EXCLUDED." + column
+		column = me.Quote(column)
+		updateColumns[k] = quotedTable + "." + column + " = EXCLUDED." + column
+		whereColumns[k] = quotedTable + "." + column + " <> EXCLUDED." + column
 	}
 	//
-	// The INSERT...VALUES portion of the query
-	values := "?" + strings.Repeat(", ?", sizeInsert-1)
-	//
 	parts := []string{
-		"INSERT INTO " + table,
-		"\t\t( " + strings.Join(rv.Arguments, ", ") + " )",
-		"\tVALUES",
-		"\t\t( " + values + " )",
-		"\tON CONFLICT( " + strings.Join(keys, ", ") + " ) DO UPDATE SET",
-		"\t\t" + strings.Join(updateColumns, ", "),
-		"\t\tWHERE (",
-		"\t\t\t" + strings.Join(whereColumns, " OR "),
-		"\t\t)",
-	}
-	if len(auto) > 0 {
-		parts = append(parts, "\tRETURNING "+strings.Join(auto, ", "))
+		"INSERT INTO " + quotedTable,
+		"\t\t( " + strings.Join(me.quoter().QuoteAll(rv.Arguments), ", ") + " )",
+	}
+	if source == "" {
+		// The INSERT...VALUES portion of the query
+		values := "?" + strings.Repeat(", ?", sizeInsert-1)
+		parts = append(parts, "\tVALUES", "\t\t( "+values+" )")
+	} else {
+		// SQLite requires a WHERE clause to tell ON CONFLICT apart from a join constraint.
+		parts = append(parts, "\tSELECT "+strings.Join(me.quoter().QuoteAll(rv.Arguments), ", ")+" FROM "+me.Quote(source)+" WHERE true")
+	}
+	if colSize == 0 {
+		// Every non-key column is insert only so there is nothing to update.
+		parts = append(parts, "\tON CONFLICT( "+strings.Join(me.quoter().QuoteAll(keys), ", ")+" ) DO NOTHING")
+	} else {
+		parts = append(parts,
+			"\tON CONFLICT( "+strings.Join(me.quoter().QuoteAll(keys), ", ")+" ) DO UPDATE SET",
+			"\t\t"+strings.Join(updateColumns, ", "),
+			"\t\tWHERE (",
+			"\t\t\t"+strings.Join(whereColumns, " OR "),
+			"\t\t)",
+		)
+	}
+	if source != "" {
+		// Keys are returned so each row can be matched to its value.
+		rv.Scan = append(append([]string{}, keys...), auto...)
+		rv.Expect = statements.ExpectRows
+		parts = append(parts, "\tRETURNING "+strings.Join(me.quoter().QuoteAll(rv.Scan), ", "))
+		rv.Arguments = nil
+	} else if len(auto) > 0 {
+		parts = append(parts, "\tRETURNING "+strings.Join(me.quoter().QuoteAll(auto), ", "))
 		rv.Scan = append([]string{}, auto...)
 		rv.Expect = statements.ExpectRowOrNone
 	}
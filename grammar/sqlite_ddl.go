@@ -0,0 +1,69 @@
+package grammar
+
+import (
+	"github.com/nofeaturesonlybugs/errors"
+
+	"github.com/nofeaturesonlybugs/sqlh/schema"
+)
+
+// ddl returns the ddlDialect for the grammar.
+func (me *SqliteGrammar) ddl() ddlDialect {
+	return ddlDialect{
+		Quoter: me.quoter(),
+		Types: map[ddlType]string{
+			ddlSmallInt: "INTEGER",
+			ddlInteger:  "INTEGER",
+			ddlBigInt:   "INTEGER",
+			ddlReal:     "REAL",
+			ddlDouble:   "REAL",
+			ddlBool:     "BOOLEAN",
+			ddlText:     "TEXT",
+			ddlTime:     "DATETIME",
+			ddlBytes:    "BLOB",
+		},
+		IndexSchemaOnName: true,
+		AlterColumn:       false,
+		AutoKey: func(sqlType string, single bool) (string, bool) {
+			// AUTOINCREMENT is only allowed on an INTEGER PRIMARY KEY column.
+			if single {
+				return "INTEGER PRIMARY KEY AUTOINCREMENT", true
+			}
+			return sqlType, false
+		},
+	}
+}
+
+// CreateTable returns the CREATE TABLE statement for table.  An auto key is an INTEGER PRIMARY KEY
+// AUTOINCREMENT column if it is the only primary key column.
+func (me *SqliteGrammar) CreateTable(table schema.Table) (string, error) {
+	rv, err := me.ddl().createTable(table)
+	if err != nil {
+		return "", errors.Go(err)
+	}
+	return rv, nil
+}
+
+// SqlType returns the SQL type CreateTable uses for the Go type of value or an empty string if the
+// type is unknown.
+func (me *SqliteGrammar) SqlType(value interface{}) string {
+	return me.ddl().sqlType(value)
+}
+
+// CreateIndex returns the CREATE INDEX statement for index.
+func (me *SqliteGrammar) CreateIndex(index schema.Index) (string, error) {
+	rv, err := me.ddl().createIndex(index)
+	if err != nil {
+		return "", errors.Go(err)
+	}
+	return rv, nil
+}
+
+// Migration returns the statements to apply and revert changes.  SQLite can not alter columns; such
+// changes return ErrUnsupportedChange.
+func (me *SqliteGrammar) Migration(changes []schema.Change) (Migration, error) {
+	rv, err := me.ddl().migration(changes)
+	if err != nil {
+		return Migration{}, errors.Go(err)
+	}
+	return rv, nil
+}
@@ -0,0 +1,173 @@
+package grammar
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/nofeaturesonlybugs/errors"
+
+	"github.com/nofeaturesonlybugs/sqlh"
+	"github.com/nofeaturesonlybugs/sqlh/schema"
+)
+
+const (
+	// sqliteInspectTables lists the tables in the main schema.
+	sqliteInspectTables = "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name"
+	// sqliteInspectColumns lists the columns of a table.
+	sqliteInspectColumns = `SELECT name, type, "notnull", COALESCE( dflt_value, '' ), pk FROM pragma_table_info( ? )`
+	// sqliteInspectIndexes lists the indexes of a table.
+	sqliteInspectIndexes = `SELECT name, "unique", origin FROM pragma_index_list( ? )`
+	// sqliteInspectIndexColumns lists the columns of an index.
+	sqliteInspectIndexColumns = "SELECT COALESCE( name, '' ) FROM pragma_index_info( ? ) ORDER BY seqno"
+	// sqliteInspectForeignKeys lists the foreign key columns of a table; the referenced column is empty
+	// when the foreign key references the primary key implicitly.
+	sqliteInspectForeignKeys = `SELECT id, "table", "from", COALESCE( "to", '' ), on_delete FROM pragma_foreign_key_list( ? ) ORDER BY id, seq`
+)
+
+// sqlitePragma returns the pragma query with a schema argument added when schemaName is not empty.
+func sqlitePragma(query string, schemaName string, name string) (string, []interface{}) {
+	if schemaName == "" {
+		return query, []interface{}{name}
+	}
+	return strings.Replace(query, "( ? )", "( ?, ? )", 1), []interface{}{name, schemaName}
+}
+
+// Inspect returns the named tables from sqlite_master and the table_info, index_list, and index_info
+// pragmas; if no names are given then every table in the main schema is returned.
+func (me *SqliteGrammar) Inspect(Q sqlh.IQueries, tables ...string) ([]schema.Table, error) {
+	var err error
+	if len(tables) == 0 {
+		if tables, err = queryStrings(Q, sqliteInspectTables); err != nil {
+			return nil, errors.Go(err)
+		}
+	}
+	rv := make([]schema.Table, 0, len(tables))
+	for _, table := range tables {
+		schemaName, name := splitTableName(table)
+		inspected, err := me.inspectTable(Q, schemaName, name)
+		if err != nil {
+			return nil, errors.Go(err).Tag("table", table)
+		}
+		rv = append(rv, inspected)
+	}
+	return rv, nil
+}
+
+// inspectTable reads a single table.
+func (me *SqliteGrammar) inspectTable(Q sqlh.IQueries, schemaName string, name string) (schema.Table, error) {
+	type pkColumn struct {
+		Name     string
+		Position int
+	}
+	var columns []schema.Column
+	var pk []pkColumn
+	query, args := sqlitePragma(sqliteInspectColumns, schemaName, name)
+	rows, err := Q.Query(query, args...)
+	if err != nil {
+		return schema.Table{}, errors.Go(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var column schema.Column
+		var notNull bool
+		var position int
+		if err = rows.Scan(&column.Name, &column.SqlType, &notNull, &column.Default, &position); err != nil {
+			return schema.Table{}, errors.Go(err)
+		}
+		column.Nullable = !notNull
+		columns = append(columns, column)
+		if position > 0 {
+			pk = append(pk, pkColumn{Name: column.Name, Position: position})
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return schema.Table{}, errors.Go(err)
+	} else if len(columns) == 0 {
+		return schema.Table{}, errors.Go(schema.ErrTableNotFound)
+	}
+	rows.Close()
+	//
+	// A rowid primary key has no entry in index_list so the primary key is built from table_info.
+	var indexes []inspectedIndex
+	if len(pk) > 0 {
+		sort.Slice(pk, func(i, j int) bool { return pk[i].Position < pk[j].Position })
+		primary := inspectedIndex{Primary: true, Unique: true}
+		for _, column := range pk {
+			primary.Columns = append(primary.Columns, column.Name)
+		}
+		indexes = append(indexes, primary)
+	}
+	query, args = sqlitePragma(sqliteInspectIndexes, schemaName, name)
+	if rows, err = Q.Query(query, args...); err != nil {
+		return schema.Table{}, errors.Go(err)
+	}
+	defer rows.Close()
+	var listed []inspectedIndex
+	for rows.Next() {
+		var index inspectedIndex
+		var origin string
+		if err = rows.Scan(&index.Name, &index.Unique, &origin); err != nil {
+			return schema.Table{}, errors.Go(err)
+		}
+		if origin == "pk" {
+			if len(indexes) > 0 {
+				indexes[0].Name = index.Name
+			}
+			continue
+		}
+		listed = append(listed, index)
+	}
+	if err = rows.Err(); err != nil {
+		return schema.Table{}, errors.Go(err)
+	}
+	rows.Close()
+	//
+	for _, index := range listed {
+		query, args = sqlitePragma(sqliteInspectIndexColumns, schemaName, index.Name)
+		names, err := queryStrings(Q, query, args...)
+		if err != nil {
+			return schema.Table{}, errors.Go(err).Tag("index", index.Name)
+		}
+		for _, column := range names {
+			if column != "" { // Expressions have no column name.
+				index.Columns = append(index.Columns, column)
+			}
+		}
+		indexes = append(indexes, index)
+	}
+	//
+	var foreignKeys []inspectedForeignKey
+	query, args = sqlitePragma(sqliteInspectForeignKeys, schemaName, name)
+	if rows, err = Q.Query(query, args...); err != nil {
+		return schema.Table{}, errors.Go(err)
+	}
+	defer rows.Close()
+	lastID := int64(-1)
+	for rows.Next() {
+		var id int64
+		var refTable, column, refColumn, onDelete string
+		if err = rows.Scan(&id, &refTable, &column, &refColumn, &onDelete); err != nil {
+			return schema.Table{}, errors.Go(err)
+		}
+		if onDelete == "NO ACTION" {
+			onDelete = ""
+		}
+		// Rows are ordered by id so consecutive rows belong to the same foreign key.
+		if size := len(foreignKeys); size > 0 && id == lastID {
+			foreignKeys[size-1].Columns = append(foreignKeys[size-1].Columns, column)
+			foreignKeys[size-1].RefColumns = append(foreignKeys[size-1].RefColumns, refColumn)
+		} else {
+			foreignKeys = append(foreignKeys, inspectedForeignKey{
+				Columns:    []string{column},
+				RefTable:   refTable,
+				RefColumns: []string{refColumn},
+				OnDelete:   onDelete,
+			})
+		}
+		lastID = id
+	}
+	if err = rows.Err(); err != nil {
+		return schema.Table{}, errors.Go(err)
+	}
+	return assembleTable(schemaName, name, columns, indexes, foreignKeys), nil
+}
@@ -0,0 +1,109 @@
+package instrument
+
+import (
+	"context"
+	"time"
+
+	"github.com/nofeaturesonlybugs/sqlh"
+)
+
+// Attribute keys set on spans.
+const (
+	// DBSystem is the database management system such as postgresql or sqlite.
+	DBSystem = "db.system"
+	// DBStatement is the SQL statement.
+	DBStatement = "db.statement"
+	// DBOperation is the operation such as SELECT or INSERT.
+	DBOperation = "db.operation"
+	// DBTable is the table of the model when the call is made by model.Models.
+	DBTable = "db.sql.table"
+	// DBRowsAffected is the number of rows affected when it is known.
+	DBRowsAffected = "db.rows_affected"
+	// Model is the Go type of the model when the call is made by model.Models.
+	Model = "sqlh.model"
+)
+
+// Attribute is a key value pair set on a span.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Span is the subset of an OpenTelemetry span used by Observer.
+type Span interface {
+	SetAttributes(attributes ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// Tracer starts spans; the returned context carries the span.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Wrap returns Q observed by an Observer with tracer and system; see Observer.  Its spans are started
+// from context.Background() so they are root spans; use WrapContext to start them within a trace.
+func Wrap(Q sqlh.IQueries, tracer Tracer, system string) sqlh.IQueries {
+	return sqlh.Observe(Q, &Observer{Tracer: tracer, System: system})
+}
+
+// WrapContext is the same as Wrap except the spans are started from ctx, such as the context of a
+// request, so they are children of the span carried by ctx.
+func WrapContext(ctx context.Context, Q sqlh.IQueries, tracer Tracer, system string) sqlh.IQueries {
+	return sqlh.ObserveContext(ctx, Q, &Observer{Tracer: tracer, System: system})
+}
+
+// Observer is a sqlh.Observer that creates a span for each database call.  It can be given directly to
+// sqlh.Scanner.Observer or model.Models.Observer.
+//
+// Spans are named after the operation followed by the table when known, such as "INSERT addresses".
+type Observer struct {
+	// Tracer starts the spans; if nil no spans are created.
+	Tracer Tracer
+	// System is the value of the db.system attribute such as postgresql or sqlite.
+	System string
+}
+
+// spanKey is the context key for the span started by BeforeQuery.
+type spanKey struct{}
+
+// BeforeQuery starts a span.
+func (me *Observer) BeforeQuery(ctx context.Context, query string, args []interface{}) context.Context {
+	if me == nil || me.Tracer == nil {
+		return ctx
+	}
+	operation, op := sqlh.OperationOf(ctx, query)
+	name := operation
+	if op.Table != "" {
+		name = name + " " + op.Table
+	}
+	ctx, span := me.Tracer.Start(ctx, name)
+	attributes := []Attribute{
+		{Key: DBSystem, Value: me.System},
+		{Key: DBStatement, Value: query},
+		{Key: DBOperation, Value: operation},
+	}
+	if op.Table != "" {
+		attributes = append(attributes, Attribute{Key: DBTable, Value: op.Table})
+	}
+	if op.Model != "" {
+		attributes = append(attributes, Attribute{Key: Model, Value: op.Model})
+	}
+	span.SetAttributes(attributes...)
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// AfterQuery records the result and ends the span.
+func (me *Observer) AfterQuery(ctx context.Context, query string, args []interface{}, duration time.Duration, rowsAffected int64, err error) {
+	span, ok := ctx.Value(spanKey{}).(Span)
+	if !ok {
+		return
+	}
+	if rowsAffected >= 0 {
+		span.SetAttributes(Attribute{Key: DBRowsAffected, Value: rowsAffected})
+	}
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
@@ -0,0 +1,132 @@
+package instrument_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/nofeaturesonlybugs/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nofeaturesonlybugs/sqlh/instrument"
+	"github.com/nofeaturesonlybugs/sqlh/model/examples"
+)
+
+// span records attributes for tests.
+type span struct {
+	name       string
+	attributes map[string]interface{}
+	err        error
+	ended      bool
+}
+
+func (me *span) SetAttributes(attributes ...instrument.Attribute) {
+	for _, attribute := range attributes {
+		me.attributes[attribute.Key] = attribute.Value
+	}
+}
+func (me *span) RecordError(err error) { me.err = err }
+func (me *span) End()                  { me.ended = true }
+
+// tracer records spans for tests.
+type tracer struct {
+	spans    []*span
+	contexts []context.Context
+}
+
+func (me *tracer) Start(ctx context.Context, name string) (context.Context, instrument.Span) {
+	rv := &span{name: name, attributes: map[string]interface{}{}}
+	me.spans, me.contexts = append(me.spans, rv), append(me.contexts, ctx)
+	return ctx, rv
+}
+
+func TestWrap(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	tr := &tracer{}
+	Q := instrument.Wrap(db, tr, "postgresql")
+	//
+	mock.ExpectExec("update").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery("select").WillReturnError(errors.Errorf("syntax error"))
+	_, err = Q.Exec("update t set a = 1")
+	chk.NoError(err)
+	_, err = Q.Query("select * from t")
+	chk.Error(err)
+	chk.NoError(mock.ExpectationsWereMet())
+	if chk.Len(tr.spans, 2) {
+		chk.Equal("UPDATE", tr.spans[0].name)
+		chk.Equal(map[string]interface{}{
+			instrument.DBSystem:       "postgresql",
+			instrument.DBStatement:    "update t set a = 1",
+			instrument.DBOperation:    "UPDATE",
+			instrument.DBRowsAffected: int64(2),
+		}, tr.spans[0].attributes)
+		chk.True(tr.spans[0].ended)
+		chk.Equal("SELECT", tr.spans[1].name)
+		chk.Error(tr.spans[1].err)
+		chk.NotContains(tr.spans[1].attributes, instrument.DBRowsAffected)
+		chk.True(tr.spans[1].ended)
+	}
+}
+
+func TestWrapContext(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	type key struct{}
+	tr := &tracer{}
+	Q := instrument.WrapContext(context.WithValue(context.Background(), key{}, "request"), db, tr, "sqlite")
+	//
+	mock.ExpectExec("delete").WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = Q.Exec("delete from t")
+	chk.NoError(err)
+	chk.NoError(mock.ExpectationsWereMet())
+	if chk.Len(tr.contexts, 1) {
+		chk.Equal("request", tr.contexts[0].Value(key{}))
+	}
+}
+
+func TestWrap_Models(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	tr := &tracer{}
+	Q := instrument.Wrap(db, tr, "postgresql")
+	mdb := examples.NewModels()
+	//
+	mock.ExpectBegin()
+	prepare := mock.ExpectPrepare("INSERT INTO upsertable")
+	prepare.ExpectQuery().
+		WillReturnRows(sqlmock.NewRows([]string{"created_tmz", "modified_tmz"}).AddRow(time.Now(), time.Now()))
+	prepare.ExpectQuery().
+		WillReturnRows(sqlmock.NewRows([]string{"created_tmz", "modified_tmz"}).AddRow(time.Now(), time.Now()))
+	mock.ExpectCommit()
+	values := []*examples.Upsertable{{Id: "a"}, {Id: "b"}}
+	chk.NoError(mdb.Upsert(Q, values))
+	chk.NoError(mock.ExpectationsWereMet())
+	names := []string{}
+	for _, span := range tr.spans {
+		names = append(names, span.name)
+	}
+	// The prepared statement and each of its queries are spans.
+	chk.Equal([]string{"BEGIN upsertable", "UPSERT upsertable", "UPSERT upsertable", "UPSERT upsertable", "COMMIT upsertable"}, names)
+	if chk.Len(tr.spans, 5) {
+		chk.Equal("UPSERT", tr.spans[2].attributes[instrument.DBOperation])
+		chk.Equal("upsertable", tr.spans[2].attributes[instrument.DBTable])
+		chk.Equal("examples.Upsertable", tr.spans[2].attributes[instrument.Model])
+		chk.Equal("BEGIN", tr.spans[0].attributes[instrument.DBOperation])
+	}
+	//
+	// Observers without a tracer do not create spans.
+	observer := &instrument.Observer{}
+	ctx := observer.BeforeQuery(context.Background(), "SELECT 1", nil)
+	observer.AfterQuery(ctx, "SELECT 1", nil, 0, -1, nil)
+}
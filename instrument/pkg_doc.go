@@ -0,0 +1,40 @@
+// Package instrument emits tracing spans for database calls made through sqlh.
+//
+// Wrap returns a sqlh.IQueries that starts a span for each Exec, Query, QueryRow, Prepare, and
+// CopyFrom call as well as the BEGIN, COMMIT, and ROLLBACK of transactions started by
+// sqlh.Transact.  Spans carry the attributes of the OpenTelemetry database semantic conventions:
+// db.system, db.statement, db.operation, and db.sql.table.  When the calls are made by
+// model.Models db.operation is the model operation (INSERT, UPDATE, UPSERT, SELECT, DELETE, or
+// COPY) and sqlh.model is the Go type of the model.
+//
+// Wrap starts root spans; WrapContext starts the spans from a context, such as the context of a
+// request, so they belong to its trace.
+//
+// Spans are created through the small Tracer and Span interfaces so this package does not
+// depend on OpenTelemetry and tests do not need a collector.  An adapter for an OpenTelemetry
+// trace.Tracer is a few lines:
+//
+//	type otelTracer struct{ trace.Tracer }
+//
+//	func (me otelTracer) Start(ctx context.Context, name string) (context.Context, instrument.Span) {
+//		ctx, span := me.Tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+//		return ctx, otelSpan{span}
+//	}
+//
+//	type otelSpan struct{ trace.Span }
+//
+//	func (me otelSpan) SetAttributes(attributes ...instrument.Attribute) {
+//		for _, a := range attributes {
+//			me.Span.SetAttributes(attribute.String(a.Key, fmt.Sprint(a.Value)))
+//		}
+//	}
+//
+//	func (me otelSpan) RecordError(err error) {
+//		me.Span.RecordError(err)
+//		me.Span.SetStatus(codes.Error, err.Error())
+//	}
+//
+//	func (me otelSpan) End() {
+//		me.Span.End()
+//	}
+package instrument
@@ -29,3 +29,25 @@ type IIterates interface {
 type IBegins interface {
 	Begin() (*sql.Tx, error)
 }
+
+// ICopies defines the method required to bulk load rows such as with Postgres COPY ... FROM STDIN.
+//
+// table is the unquoted and possibly schema qualified table name and columns are the unquoted
+// column names; implementations quote them as required.  CopyFrom returns the number of rows copied.
+//
+// The method mirrors CopyFrom from github.com/jackc/pgx so adapters are small; an adapter for
+// github.com/lib/pq can prepare pq.CopyIn(table, columns...) inside a transaction, call Exec once
+// per row of source, and call Exec without arguments to flush.
+type ICopies interface {
+	CopyFrom(table string, columns []string, source ICopySource) (int64, error)
+}
+
+// ICopySource defines the methods required to iterate the rows given to ICopies.
+type ICopySource interface {
+	// Next advances to the next row and returns false when there are no more rows or an error occurred.
+	Next() bool
+	// Values returns the column values of the current row in the order of the columns.
+	Values() ([]interface{}, error)
+	// Err returns the error, if any, that stopped iteration.
+	Err() error
+}
@@ -0,0 +1,21 @@
+// Package types holds the reflect types of Go column types that grammar and model recognize by
+// their type rather than their kind.  It is internal so the values can not be reassigned by
+// importers.
+package types
+
+import (
+	"database/sql"
+	"reflect"
+	"time"
+)
+
+var (
+	Time        = reflect.TypeOf(time.Time{})
+	Bytes       = reflect.TypeOf([]byte(nil))
+	NullString  = reflect.TypeOf(sql.NullString{})
+	NullInt64   = reflect.TypeOf(sql.NullInt64{})
+	NullInt32   = reflect.TypeOf(sql.NullInt32{})
+	NullFloat64 = reflect.TypeOf(sql.NullFloat64{})
+	NullBool    = reflect.TypeOf(sql.NullBool{})
+	NullTime    = reflect.TypeOf(sql.NullTime{})
+)
@@ -0,0 +1,76 @@
+package sqlh
+
+import (
+	"context"
+	"time"
+)
+
+// StructuredLogger is the subset of *slog.Logger from log/slog used by LogObserver and SlowQueryObserver.
+// args are alternating keys and values.
+type StructuredLogger interface {
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// LogObserver is an Observer that logs every database call to Logger; calls that fail are logged with
+// Logger.Error and other calls with Logger.Info.
+//
+// Each entry has the keys sql, duration, and rows; failed calls also have the key error.  Query arguments
+// are logged with the key args only if Args is true since they may contain sensitive values.
+type LogObserver struct {
+	Logger StructuredLogger
+	Args   bool
+}
+
+// BeforeQuery returns ctx.
+func (me *LogObserver) BeforeQuery(ctx context.Context, query string, args []interface{}) context.Context {
+	return ctx
+}
+
+// AfterQuery logs the call.
+func (me *LogObserver) AfterQuery(ctx context.Context, query string, args []interface{}, duration time.Duration, rowsAffected int64, err error) {
+	if me == nil || me.Logger == nil {
+		return
+	}
+	attrs := logAttrs(query, args, me.Args, duration, rowsAffected)
+	if err != nil {
+		me.Logger.Error("sqlh query failed", append(attrs, "error", err.Error())...)
+		return
+	}
+	me.Logger.Info("sqlh query", attrs...)
+}
+
+// SlowQueryObserver is an Observer that logs database calls lasting at least Threshold to Logger.Warn.
+// The entries have the same keys as LogObserver.
+type SlowQueryObserver struct {
+	Logger    StructuredLogger
+	Threshold time.Duration
+	Args      bool
+}
+
+// BeforeQuery returns ctx.
+func (me *SlowQueryObserver) BeforeQuery(ctx context.Context, query string, args []interface{}) context.Context {
+	return ctx
+}
+
+// AfterQuery logs the call if it is slow.
+func (me *SlowQueryObserver) AfterQuery(ctx context.Context, query string, args []interface{}, duration time.Duration, rowsAffected int64, err error) {
+	if me == nil || me.Logger == nil || duration < me.Threshold {
+		return
+	}
+	attrs := logAttrs(query, args, me.Args, duration, rowsAffected)
+	if err != nil {
+		attrs = append(attrs, "error", err.Error())
+	}
+	me.Logger.Warn("sqlh slow query", append(attrs, "threshold", me.Threshold)...)
+}
+
+// logAttrs returns the key value pairs logged for a call.
+func logAttrs(query string, args []interface{}, withArgs bool, duration time.Duration, rowsAffected int64) []interface{} {
+	rv := []interface{}{"sql", query, "duration", duration, "rows", rowsAffected}
+	if withArgs && len(args) > 0 {
+		rv = append(rv, "args", args)
+	}
+	return rv
+}
@@ -0,0 +1,205 @@
+package metrics
+
+import (
+	"expvar"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the upper bounds in seconds of the latency histograms of Memory; they are the
+// default buckets of the Prometheus client libraries.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Key identifies the operation and model of a metric.
+type Key struct {
+	Operation string
+	Model     string
+}
+
+// String returns the key as operation or operation/model.
+func (me Key) String() string {
+	if me.Model == "" {
+		return me.Operation
+	}
+	return me.Operation + "/" + me.Model
+}
+
+// ErrorKey identifies the operation and error class of failed calls.
+type ErrorKey struct {
+	Operation string
+	Class     string
+}
+
+// String returns the key as operation/class.
+func (me ErrorKey) String() string {
+	return me.Operation + "/" + me.Class
+}
+
+// Histogram is a latency histogram in seconds.  Counts[k] is the number of observations less than or
+// equal to Buckets[k]; Count includes observations greater than every bucket.
+type Histogram struct {
+	Buckets []float64 `json:"buckets"`
+	Counts  []uint64  `json:"counts"`
+	Count   uint64    `json:"count"`
+	Sum     float64   `json:"sum"`
+}
+
+// observe adds seconds to the histogram.
+func (me *Histogram) observe(seconds float64) {
+	for k, bound := range me.Buckets {
+		if seconds <= bound {
+			me.Counts[k]++
+		}
+	}
+	me.Count++
+	me.Sum += seconds
+}
+
+// Snapshot is a copy of the metrics in Memory.
+type Snapshot struct {
+	// Queries are latency histograms by operation and model.
+	Queries map[Key]Histogram
+	// Errors are failed calls by operation and error class.
+	Errors map[ErrorKey]uint64
+	// Rows are the rows scanned by model.
+	Rows map[string]uint64
+	// Transactions are transaction outcomes.
+	Transactions map[string]uint64
+}
+
+// Memory is a Recorder that keeps metrics in memory.  The zero value is ready to use and records
+// latency with DefaultBuckets.
+type Memory struct {
+	// Buckets are the upper bounds in seconds of latency histograms; if empty DefaultBuckets are used.
+	// Buckets must be sorted and must not change once recording begins.
+	Buckets []float64
+
+	mu           sync.Mutex
+	queries      map[Key]*Histogram
+	errors       map[ErrorKey]uint64
+	rows         map[string]uint64
+	transactions map[string]uint64
+}
+
+// RecordQuery records the latency of a call.
+func (me *Memory) RecordQuery(operation string, model string, duration time.Duration) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	if me.queries == nil {
+		me.queries = map[Key]*Histogram{}
+	}
+	key := Key{Operation: operation, Model: model}
+	histogram, ok := me.queries[key]
+	if !ok {
+		buckets := me.Buckets
+		if len(buckets) == 0 {
+			buckets = DefaultBuckets
+		}
+		histogram = &Histogram{Buckets: buckets, Counts: make([]uint64, len(buckets))}
+		me.queries[key] = histogram
+	}
+	histogram.observe(duration.Seconds())
+}
+
+// RecordError records a failed call.
+func (me *Memory) RecordError(operation string, class string) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	if me.errors == nil {
+		me.errors = map[ErrorKey]uint64{}
+	}
+	me.errors[ErrorKey{Operation: operation, Class: class}]++
+}
+
+// RecordRows records scanned rows.
+func (me *Memory) RecordRows(model string, n int64) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	if me.rows == nil {
+		me.rows = map[string]uint64{}
+	}
+	me.rows[model] += uint64(n)
+}
+
+// RecordTransaction records a transaction outcome.
+func (me *Memory) RecordTransaction(outcome string) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	if me.transactions == nil {
+		me.transactions = map[string]uint64{}
+	}
+	me.transactions[outcome]++
+}
+
+// Snapshot returns a copy of the recorded metrics.
+func (me *Memory) Snapshot() Snapshot {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	rv := Snapshot{
+		Queries:      make(map[Key]Histogram, len(me.queries)),
+		Errors:       make(map[ErrorKey]uint64, len(me.errors)),
+		Rows:         make(map[string]uint64, len(me.rows)),
+		Transactions: make(map[string]uint64, len(me.transactions)),
+	}
+	for key, histogram := range me.queries {
+		copied := *histogram
+		copied.Counts = append([]uint64(nil), histogram.Counts...)
+		rv.Queries[key] = copied
+	}
+	for key, n := range me.errors {
+		rv.Errors[key] = n
+	}
+	for model, n := range me.rows {
+		rv.Rows[model] = n
+	}
+	for outcome, n := range me.transactions {
+		rv.Transactions[outcome] = n
+	}
+	return rv
+}
+
+// Reset discards the recorded metrics.
+func (me *Memory) Reset() {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	me.queries, me.errors, me.rows, me.transactions = nil, nil, nil, nil
+}
+
+// Var returns an expvar.Var that exports the metrics as JSON; publish it with expvar.Publish.
+//
+// Queries and errors are keyed by Key.String and ErrorKey.String; the key of rows scanned outside of model.Models is
+// the empty string.
+func (me *Memory) Var() expvar.Var {
+	return expvar.Func(func() interface{} {
+		snapshot := me.Snapshot()
+		queries, errors := map[string]Histogram{}, map[string]uint64{}
+		for key, histogram := range snapshot.Queries {
+			queries[key.String()] = histogram
+		}
+		for key, n := range snapshot.Errors {
+			errors[key.String()] = n
+		}
+		return map[string]interface{}{
+			"queries":      queries,
+			"errors":       errors,
+			"rows":         snapshot.Rows,
+			"transactions": snapshot.Transactions,
+		}
+	})
+}
+
+// Keys returns the keys of the query histograms in snapshot sorted by operation and model.
+func (me Snapshot) Keys() []Key {
+	rv := make([]Key, 0, len(me.Queries))
+	for key := range me.Queries {
+		rv = append(rv, key)
+	}
+	sort.Slice(rv, func(i, j int) bool {
+		if rv[i].Operation != rv[j].Operation {
+			return rv[i].Operation < rv[j].Operation
+		}
+		return rv[i].Model < rv[j].Model
+	})
+	return rv
+}
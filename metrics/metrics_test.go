@@ -0,0 +1,166 @@
+package metrics_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/nofeaturesonlybugs/errors"
+	"github.com/nofeaturesonlybugs/set"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nofeaturesonlybugs/sqlh"
+	"github.com/nofeaturesonlybugs/sqlh/grammar"
+	"github.com/nofeaturesonlybugs/sqlh/metrics"
+	"github.com/nofeaturesonlybugs/sqlh/model/examples"
+)
+
+func TestObserver_Models(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	memory := &metrics.Memory{}
+	mdb := examples.NewModels()
+	mdb.Observer = &metrics.Observer{Recorder: memory}
+	//
+	mock.ExpectBegin()
+	prepare := mock.ExpectPrepare("INSERT INTO upsertable")
+	prepare.ExpectQuery().
+		WillReturnRows(sqlmock.NewRows([]string{"created_tmz", "modified_tmz"}).AddRow(time.Now(), time.Now()))
+	prepare.ExpectQuery().WillReturnError(errors.Errorf("unique violation"))
+	mock.ExpectRollback()
+	mock.ExpectQuery("SELECT person_fk, address_fk FROM relate_people_addresses").
+		WillReturnRows(sqlmock.NewRows([]string{"person_fk", "address_fk"}).AddRow(1, 10).AddRow(1, 11))
+	mock.ExpectQuery("SELECT pk, created_tmz, modified_tmz, street, city, state, zip FROM addresses").
+		WillReturnRows(sqlmock.NewRows([]string{"pk"}).AddRow(10).AddRow(11))
+	chk.Error(mdb.Upsert(db, []examples.Upsertable{{Id: "a"}, {Id: "b"}}))
+	chk.NoError(mdb.Preload(db, &examples.Person{Id: 1}, "Addresses"))
+	chk.NoError(mock.ExpectationsWereMet())
+	//
+	snapshot := memory.Snapshot()
+	chk.Equal([]metrics.Key{
+		{Operation: "BEGIN", Model: "examples.Upsertable"},
+		{Operation: "ROLLBACK", Model: "examples.Upsertable"},
+		{Operation: "SELECT", Model: "examples.Address"},
+		{Operation: "SELECT", Model: "examples.PersonAddress"},
+		{Operation: "UPSERT", Model: "examples.Upsertable"},
+	}, snapshot.Keys())
+	// The prepared statement is counted with its two queries.
+	chk.Equal(uint64(3), snapshot.Queries[metrics.Key{Operation: "UPSERT", Model: "examples.Upsertable"}].Count)
+	chk.Equal(map[metrics.ErrorKey]uint64{{Operation: "UPSERT", Class: "other"}: 1}, snapshot.Errors)
+	chk.Equal(map[string]uint64{"examples.Address": 2, "examples.PersonAddress": 2}, snapshot.Rows)
+	chk.Equal(map[string]uint64{metrics.Rollback: 1}, snapshot.Transactions)
+}
+
+func TestObserver_Transact(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	memory := &metrics.Memory{}
+	Q := sqlh.Observe(db, &metrics.Observer{Recorder: memory})
+	//
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectCommit().WillReturnError(sql.ErrConnDone)
+	chk.NoError(sqlh.Transact(Q, func(Q sqlh.IQueries) error { return nil }))
+	chk.Error(sqlh.Transact(Q, func(Q sqlh.IQueries) error { return nil }))
+	chk.NoError(mock.ExpectationsWereMet())
+	snapshot := memory.Snapshot()
+	chk.Equal(map[string]uint64{metrics.Commit: 1, metrics.Failed: 1}, snapshot.Transactions)
+	chk.Equal(map[metrics.ErrorKey]uint64{{Operation: "COMMIT", Class: "conn_done"}: 1}, snapshot.Errors)
+	//
+	memory.Reset()
+	chk.Empty(memory.Snapshot().Transactions)
+}
+
+func TestObserver_Scanner(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	memory := &metrics.Memory{}
+	scanner := &sqlh.Scanner{
+		Mapper:   &set.Mapper{},
+		Observer: sqlh.Observers{&metrics.Observer{Recorder: memory}},
+	}
+	//
+	mock.ExpectQuery("select").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(1).AddRow(2).AddRow(3))
+	mock.ExpectQuery("select").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(4))
+	var numbers []int
+	chk.NoError(scanner.Select(db, &numbers, "select n from t"))
+	var n int
+	chk.NoError(scanner.Select(db, &n, "select n from t limit 1"))
+	chk.NoError(mock.ExpectationsWereMet())
+	chk.Equal(map[string]uint64{"": 4}, memory.Snapshot().Rows)
+	chk.Equal(uint64(2), memory.Snapshot().Queries[metrics.Key{Operation: "SELECT"}].Count)
+}
+
+func TestMemory(t *testing.T) {
+	chk := assert.New(t)
+	//
+	memory := &metrics.Memory{Buckets: []float64{0.1, 1}}
+	memory.RecordQuery("SELECT", "", 50*time.Millisecond)
+	memory.RecordQuery("SELECT", "", 500*time.Millisecond)
+	memory.RecordQuery("SELECT", "", 5*time.Second)
+	histogram := memory.Snapshot().Queries[metrics.Key{Operation: "SELECT"}]
+	chk.Equal([]uint64{1, 2}, histogram.Counts)
+	chk.Equal(uint64(3), histogram.Count)
+	chk.InDelta(5.55, histogram.Sum, 0.0001)
+	//
+	memory.RecordError("SELECT", "no_rows")
+	memory.RecordRows("T", 3)
+	memory.RecordTransaction(metrics.Commit)
+	var exported map[string]interface{}
+	chk.NoError(json.Unmarshal([]byte(memory.Var().String()), &exported))
+	chk.Contains(exported["queries"], "SELECT")
+	chk.Equal(map[string]interface{}{"SELECT/no_rows": float64(1)}, exported["errors"])
+	chk.Equal(map[string]interface{}{"T": float64(3)}, exported["rows"])
+	chk.Equal(map[string]interface{}{"commit": float64(1)}, exported["transactions"])
+	//
+	chk.Equal("INSERT/examples.Address", metrics.Key{Operation: "INSERT", Model: "examples.Address"}.String())
+}
+
+func TestClassify(t *testing.T) {
+	chk := assert.New(t)
+	//
+	chk.Equal("no_rows", metrics.Classify(errors.Go(sql.ErrNoRows)))
+	chk.Equal("tx_done", metrics.Classify(sql.ErrTxDone))
+	chk.Equal("canceled", metrics.Classify(context.Canceled))
+	chk.Equal("deadline", metrics.Classify(context.DeadlineExceeded))
+	chk.Equal("other", metrics.Classify(errors.Errorf("boom")))
+	chk.Equal("unique", metrics.Classify(errors.Go(&sqlh.DBError{Kind: sqlh.ErrUniqueViolation, Index: -1})))
+	chk.Equal("deadlock", metrics.Classify(&sqlh.DBError{Kind: sqlh.ErrDeadlock, Index: -1}))
+	chk.Equal("no_rows", metrics.Classify(sqlh.ClassifyError(nil, sql.ErrNoRows)))
+	//
+	// Classifier classifies driver errors before Classify.
+	observer := &metrics.Observer{Recorder: &metrics.Memory{}, Classifier: &grammar.PostgresGrammar{}}
+	observer.AfterQuery(context.Background(), "INSERT INTO t", nil, 0, -1, &stateError{state: "23503"})
+	observer.AfterQuery(context.Background(), "INSERT INTO t", nil, 0, -1, &stateError{state: "XX000"})
+	chk.Equal(map[metrics.ErrorKey]uint64{{Operation: "INSERT", Class: "foreign_key"}: 1, {Operation: "INSERT", Class: "other"}: 1},
+		observer.Recorder.(*metrics.Memory).Snapshot().Errors)
+	//
+	observer = &metrics.Observer{Recorder: &metrics.Memory{}, Classify: func(error) string { return "custom" }}
+	observer.AfterQuery(context.Background(), "SELECT 1", nil, 0, -1, errors.Errorf("boom"))
+	chk.Equal(map[metrics.ErrorKey]uint64{{Operation: "SELECT", Class: "custom"}: 1}, observer.Recorder.(*metrics.Memory).Snapshot().Errors)
+	//
+	// Observers without recorders do nothing.
+	(&metrics.Observer{}).AfterQuery(context.Background(), "SELECT 1", nil, 0, -1, nil)
+	(&metrics.Observer{}).ScannedRows(context.Background(), "SELECT 1", 1)
+}
+
+// stateError is a driver error with a SQLSTATE.
+type stateError struct {
+	state string
+}
+
+func (me *stateError) Error() string    { return "state " + me.state }
+func (me *stateError) SQLState() string { return me.state }
@@ -0,0 +1,15 @@
+// Package metrics records query latency, errors, scanned rows, and transaction outcomes of
+// database calls made through sqlh.
+//
+// Observer adapts a Recorder to sqlh.Observer so it can be given to sqlh.Observe,
+// sqlh.Scanner.Observer, or model.Models.Observer; combine it with other observers through
+// sqlh.Observers.  Observer records:
+//   - the latency of each call by operation and model; calls made by model.Models use the model
+//     operation (INSERT, UPDATE, UPSERT, SELECT, DELETE, or COPY) and Go type of the model,
+//   - failed calls by operation and error class; see Observer.Classifier and Classify,
+//   - rows scanned by sqlh.Scanner by model,
+//   - the outcome of transactions started by sqlh.Transact: commit, rollback, or failed.
+//
+// Memory is a Recorder that keeps Prometheus style histograms and counters in memory;
+// Memory.Var exports them with package expvar.
+package metrics
@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	pkgerrors "github.com/nofeaturesonlybugs/errors"
+
+	"github.com/nofeaturesonlybugs/sqlh"
+)
+
+// Transaction outcomes given to Recorder.RecordTransaction.
+const (
+	Commit   = "commit"
+	Rollback = "rollback"
+	Failed   = "failed"
+)
+
+// Recorder records metrics about database calls.  Implementations must be safe for concurrent use.
+type Recorder interface {
+	// RecordQuery records the latency of a call by operation and model; model is empty when
+	// the call is not made by model.Models.
+	RecordQuery(operation string, model string, duration time.Duration)
+	// RecordError records a failed call by operation and error class.
+	RecordError(operation string, class string)
+	// RecordRows records the number of rows scanned by sqlh.Scanner by model.
+	RecordRows(model string, n int64)
+	// RecordTransaction records a transaction outcome: Commit, Rollback, or Failed.
+	RecordTransaction(outcome string)
+}
+
+// Observer is a sqlh.Observer and sqlh.RowsObserver that records metrics to Recorder.
+type Observer struct {
+	// Recorder receives the metrics; if nil nothing is recorded.
+	Recorder Recorder
+	// Classifier classifies driver errors as *sqlh.DBError before they are given to Classify; it is
+	// usually the grammar of the database such as *grammar.PostgresGrammar.  If nil only
+	// sql.ErrNoRows is classified.
+	Classifier sqlh.ErrorClassifier
+	// Classify returns the class of errors; if nil the package function Classify is used.
+	Classify func(err error) string
+}
+
+// BeforeQuery returns ctx.
+func (me *Observer) BeforeQuery(ctx context.Context, query string, args []interface{}) context.Context {
+	return ctx
+}
+
+// AfterQuery records the call.
+func (me *Observer) AfterQuery(ctx context.Context, query string, args []interface{}, duration time.Duration, rowsAffected int64, err error) {
+	if me == nil || me.Recorder == nil {
+		return
+	}
+	operation, op := sqlh.OperationOf(ctx, query)
+	me.Recorder.RecordQuery(operation, op.Model, duration)
+	switch {
+	case err != nil && (operation == "COMMIT" || operation == "ROLLBACK"):
+		me.Recorder.RecordTransaction(Failed)
+	case operation == "COMMIT":
+		me.Recorder.RecordTransaction(Commit)
+	case operation == "ROLLBACK":
+		me.Recorder.RecordTransaction(Rollback)
+	}
+	if err != nil {
+		classify := me.Classify
+		if classify == nil {
+			classify = Classify
+		}
+		me.Recorder.RecordError(operation, classify(sqlh.ClassifyError(me.Classifier, err)))
+	}
+}
+
+// ScannedRows records rows scanned by sqlh.Scanner.
+func (me *Observer) ScannedRows(ctx context.Context, query string, n int64) {
+	if me == nil || me.Recorder == nil {
+		return
+	}
+	op, _ := sqlh.OperationFrom(ctx)
+	me.Recorder.RecordRows(op.Model, n)
+}
+
+// kindClasses maps the kinds of *sqlh.DBError to error classes.
+var kindClasses = map[error]string{
+	sqlh.ErrNotFound:            "no_rows",
+	sqlh.ErrUniqueViolation:     "unique",
+	sqlh.ErrForeignKeyViolation: "foreign_key",
+	sqlh.ErrNotNullViolation:    "not_null",
+	sqlh.ErrCheckViolation:      "check",
+	sqlh.ErrSerialization:       "serialization",
+	sqlh.ErrDeadlock:            "deadlock",
+}
+
+// Classify returns the class of err.  A *sqlh.DBError is classified by its Kind: no_rows, unique,
+// foreign_key, not_null, check, serialization, or deadlock.  Other errors are no_rows, tx_done,
+// conn_done, canceled, deadline, or other.
+func Classify(err error) string {
+	original := err
+	if typed, ok := pkgerrors.Original(err).(error); ok {
+		original = typed
+	}
+	var classified *sqlh.DBError
+	if errors.As(original, &classified) {
+		if class, ok := kindClasses[classified.Kind]; ok {
+			return class
+		}
+	}
+	switch original {
+	case sql.ErrNoRows:
+		return "no_rows"
+	case sql.ErrTxDone:
+		return "tx_done"
+	case sql.ErrConnDone:
+		return "conn_done"
+	case context.Canceled:
+		return "canceled"
+	case context.DeadlineExceeded:
+		return "deadline"
+	}
+	return "other"
+}
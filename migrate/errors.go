@@ -0,0 +1,16 @@
+package migrate
+
+import "errors"
+
+var (
+	// ErrInvalidFileName is returned when a migration file name does not start with an integer version.
+	ErrInvalidFileName error = errors.New("invalid migration file name")
+	// ErrDuplicateVersion is returned when more than one migration has the same version.
+	ErrDuplicateVersion error = errors.New("duplicate migration version")
+	// ErrMissingUp is returned when a migration has a down file but no up file.
+	ErrMissingUp error = errors.New("migration has no up file")
+	// ErrMissingDown is returned when reverting a migration that has no down file.
+	ErrMissingDown error = errors.New("migration has no down file")
+	// ErrUnknownVersion is returned when reverting an applied migration that does not have files.
+	ErrUnknownVersion error = errors.New("applied migration has no files")
+)
@@ -0,0 +1,233 @@
+package migrate_test
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/nofeaturesonlybugs/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nofeaturesonlybugs/sqlh/grammar"
+	"github.com/nofeaturesonlybugs/sqlh/migrate"
+)
+
+// files returns the migration files used by the tests.
+func files() fstest.MapFS {
+	return fstest.MapFS{
+		"sql/0001_create_people.up.sql":   {Data: []byte("CREATE TABLE people ( pk INTEGER PRIMARY KEY, name TEXT );")},
+		"sql/0001_create_people.down.sql": {Data: []byte("DROP TABLE people;")},
+		"sql/0002_add_email.up.sql":       {Data: []byte("ALTER TABLE people ADD COLUMN email TEXT;")},
+		"sql/0002_add_email.down.sql":     {Data: []byte("ALTER TABLE people DROP COLUMN email;")},
+		"sql/README.md":                   {Data: []byte("ignored")},
+	}
+}
+
+// expectSqliteBookkeeping expects the queries that inspect and read the bookkeeping table; if
+// applied is nil the table does not exist.
+func expectSqliteBookkeeping(mock sqlmock.Sqlmock, applied *sqlmock.Rows) {
+	columns := sqlmock.NewRows([]string{"name", "type", "notnull", "dflt_value", "pk"})
+	if applied == nil {
+		mock.ExpectQuery(regexp.QuoteMeta("FROM pragma_table_info( ? )")).WithArgs("schema_migrations").WillReturnRows(columns)
+		return
+	}
+	columns.AddRow("version", "INTEGER", true, "", int64(1)).AddRow("name", "TEXT", true, "", int64(0))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pragma_table_info( ? )")).WithArgs("schema_migrations").WillReturnRows(columns)
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pragma_index_list( ? )")).WithArgs("schema_migrations").WillReturnRows(sqlmock.NewRows([]string{"name", "unique", "origin"}))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pragma_foreign_key_list( ? )")).WithArgs("schema_migrations").WillReturnRows(sqlmock.NewRows([]string{"id", "table", "from", "to", "on_delete"}))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT version, name, checksum FROM schema_migrations ORDER BY version")).WillReturnRows(applied)
+}
+
+func TestLoad(t *testing.T) {
+	chk := assert.New(t)
+	//
+	migrations, err := migrate.Load(files(), "sql")
+	chk.NoError(err)
+	if chk.Len(migrations, 2) {
+		chk.Equal(int64(1), migrations[0].Version)
+		chk.Equal("create_people", migrations[0].Name)
+		chk.Equal("0001_create_people", "000"+migrations[0].String())
+		chk.True(migrations[0].HasDown)
+		chk.Equal("DROP TABLE people;", migrations[0].Down)
+		chk.Equal(int64(2), migrations[1].Version)
+		chk.Len(migrations[1].Checksum(), 64)
+	}
+	//
+	_, err = migrate.Load(fstest.MapFS{"abc_bad.up.sql": {}}, "")
+	chk.Equal(migrate.ErrInvalidFileName, errors.Original(err))
+	_, err = migrate.Load(fstest.MapFS{"1_a.down.sql": {}}, "")
+	chk.Equal(migrate.ErrMissingUp, errors.Original(err))
+	_, err = migrate.Load(fstest.MapFS{"1_a.up.sql": {}, "1_b.up.sql": {}}, "")
+	chk.Equal(migrate.ErrDuplicateVersion, errors.Original(err))
+	_, err = migrate.Load(nil, "")
+	chk.Error(err)
+	_, err = migrate.Load(fstest.MapFS{}, "missing")
+	chk.Error(err)
+}
+
+func TestMigrator_Up(t *testing.T) {
+	chk := assert.New(t)
+	//
+	migrations, err := migrate.Load(files(), "sql")
+	chk.NoError(err)
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	migrator := &migrate.Migrator{FS: files(), Dir: "sql", Grammar: grammar.Sqlite}
+	{
+		// First run creates the bookkeeping table and applies every migration.
+		mock.ExpectBegin()
+		expectSqliteBookkeeping(mock, nil)
+		mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE schema_migrations (\n\tversion INTEGER NOT NULL,\n\tname TEXT NOT NULL,\n\tchecksum TEXT NOT NULL,\n\tapplied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,\n\tPRIMARY KEY ( version )\n)")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		for _, migration := range migrations {
+			mock.ExpectExec(regexp.QuoteMeta(migration.Up)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta("INSERT INTO schema_migrations ( version, name, checksum ) VALUES ( ?, ?, ? )")).
+				WithArgs(migration.Version, migration.Name, migration.Checksum()).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectCommit()
+		report, err := migrator.Up(db)
+		chk.NoError(err)
+		chk.NoError(mock.ExpectationsWereMet())
+		chk.Equal(migrations, report.Migrations)
+		chk.Empty(report.Changed)
+	}
+	{
+		// Applied migrations are skipped and changed checksums are reported.
+		mock.ExpectBegin()
+		expectSqliteBookkeeping(mock, sqlmock.NewRows([]string{"version", "name", "checksum"}).AddRow(int64(1), "create_people", "old"))
+		mock.ExpectExec(regexp.QuoteMeta(migrations[1].Up)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO schema_migrations")).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		report, err := migrator.Up(db)
+		chk.NoError(err)
+		chk.NoError(mock.ExpectationsWereMet())
+		chk.Equal(migrations[1:], report.Migrations)
+		chk.Equal([]migrate.Applied{{Version: 1, Name: "create_people", Checksum: "old"}}, report.Changed)
+	}
+	{
+		// Failed migrations roll back.
+		mock.ExpectBegin()
+		expectSqliteBookkeeping(mock, sqlmock.NewRows([]string{"version", "name", "checksum"}))
+		mock.ExpectExec(regexp.QuoteMeta(migrations[0].Up)).WillReturnError(errors.Errorf("syntax error"))
+		mock.ExpectRollback()
+		report, err := migrator.Up(db)
+		chk.Error(err)
+		chk.Empty(report.Migrations)
+		chk.NoError(mock.ExpectationsWereMet())
+	}
+}
+
+func TestMigrator_UpPostgres(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	migrator := &migrate.Migrator{FS: files(), Dir: "sql", Grammar: grammar.Postgres, Table: "meta.migrations", LockID: 42}
+	//
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_xact_lock( $1 )")).WithArgs(int64(42)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pg_catalog.pg_attribute a")).WithArgs("meta", "migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"attname", "format_type", "nullable", "default"}).AddRow("version", "bigint", false, ""))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pg_catalog.pg_index x")).WithArgs("meta", "migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"relname", "indisprimary", "indisunique", "attname"}))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pg_catalog.pg_constraint c")).WithArgs("meta", "migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"conname", "attname", "relname", "refname", "confdeltype"}))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT version, name, checksum FROM meta.migrations ORDER BY version")).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "name", "checksum"}).AddRow(int64(1), "create_people", "").AddRow(int64(2), "add_email", ""))
+	mock.ExpectCommit()
+	report, err := migrator.Up(db)
+	chk.NoError(err)
+	chk.NoError(mock.ExpectationsWereMet())
+	chk.Empty(report.Migrations)
+	chk.Len(report.Changed, 2)
+}
+
+func TestMigrator_Down(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	migrator := &migrate.Migrator{FS: files(), Dir: "sql", Grammar: grammar.Sqlite}
+	applied := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"version", "name", "checksum"}).AddRow(int64(1), "create_people", "").AddRow(int64(2), "add_email", "")
+	}
+	//
+	mock.ExpectBegin()
+	expectSqliteBookkeeping(mock, applied())
+	mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE people DROP COLUMN email;")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM schema_migrations WHERE version = ?")).WithArgs(int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	report, err := migrator.Down(db, 1)
+	chk.NoError(err)
+	chk.NoError(mock.ExpectationsWereMet())
+	if chk.Len(report.Migrations, 1) {
+		chk.Equal(int64(2), report.Migrations[0].Version)
+	}
+	//
+	// Migrations without down files can not be reverted.
+	fsys := files()
+	delete(fsys, "sql/0002_add_email.down.sql")
+	migrator.FS = fsys
+	mock.ExpectBegin()
+	expectSqliteBookkeeping(mock, applied())
+	mock.ExpectRollback()
+	_, err = migrator.Down(db, 2)
+	chk.Equal(migrate.ErrMissingDown, errors.Original(err))
+	chk.NoError(mock.ExpectationsWereMet())
+	//
+	// Applied migrations must have files.
+	delete(fsys, "sql/0002_add_email.up.sql")
+	mock.ExpectBegin()
+	expectSqliteBookkeeping(mock, applied())
+	mock.ExpectRollback()
+	_, err = migrator.Down(db, 1)
+	chk.Equal(migrate.ErrUnknownVersion, errors.Original(err))
+	chk.NoError(mock.ExpectationsWereMet())
+}
+
+func TestMigrator_DryRun(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	var out bytes.Buffer
+	migrator := &migrate.Migrator{FS: files(), Dir: "sql", Grammar: grammar.Postgres, DryRun: &out}
+	//
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pg_catalog.pg_attribute a")).WithArgs("", "schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"attname", "format_type", "nullable", "default"}))
+	report, err := migrator.Up(db)
+	chk.NoError(err)
+	chk.NoError(mock.ExpectationsWereMet())
+	chk.Len(report.Migrations, 2)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	chk.Equal("CREATE TABLE schema_migrations (", lines[0])
+	chk.Contains(out.String(), "CREATE TABLE people ( pk INTEGER PRIMARY KEY, name TEXT );\n")
+	chk.Contains(out.String(), "INSERT INTO schema_migrations ( version, name, checksum ) VALUES ( $1, $2, $3 ); -- [1 create_people ")
+	chk.Equal("ALTER TABLE people ADD COLUMN email TEXT;", lines[len(lines)-2])
+}
+
+func TestMigrator_Errors(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, _, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	//
+	var nilMigrator *migrate.Migrator
+	_, err = nilMigrator.Up(db)
+	chk.Error(err)
+	_, err = (&migrate.Migrator{}).Up(db)
+	chk.Error(err)
+	_, err = (&migrate.Migrator{FS: files()}).Down(db, 1)
+	chk.Error(err)
+	_, err = (&migrate.Migrator{FS: files(), Dir: "sql", Grammar: grammar.Sqlite}).Up(nil)
+	chk.Error(err)
+}
@@ -0,0 +1,113 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nofeaturesonlybugs/errors"
+)
+
+// Migration is a single versioned migration.
+type Migration struct {
+	// Version orders the migrations.
+	Version int64
+	// Name is the part of the file name between the version and the .up.sql or .down.sql suffix.
+	Name string
+	// Up and Down contain the SQL that applies and reverts the migration; Down is empty if the
+	// migration does not have a down file.
+	Up, Down string
+	// HasDown is true if the migration has a down file.
+	HasDown bool
+}
+
+// Checksum returns the hex encoded SHA-256 checksum of the Up SQL.
+func (me Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(me.Up))
+	return hex.EncodeToString(sum[:])
+}
+
+// String returns the migration as VERSION_NAME.
+func (me Migration) String() string {
+	if me.Name == "" {
+		return strconv.FormatInt(me.Version, 10)
+	}
+	return strconv.FormatInt(me.Version, 10) + "_" + me.Name
+}
+
+// Load reads the migrations in dir of fsys ordered by version.
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	if fsys == nil {
+		return nil, errors.NilArgument("fsys")
+	}
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, errors.Go(err)
+	}
+	byVersion, hasUp := map[int64]*Migration{}, map[int64]bool{}
+	for _, entry := range entries {
+		var up bool
+		name := entry.Name()
+		switch {
+		case entry.IsDir():
+			continue
+		case strings.HasSuffix(name, ".up.sql"):
+			up, name = true, strings.TrimSuffix(name, ".up.sql")
+		case strings.HasSuffix(name, ".down.sql"):
+			name = strings.TrimSuffix(name, ".down.sql")
+		default:
+			continue
+		}
+		version, label, err := parseName(name)
+		if err != nil {
+			return nil, errors.Go(err).Tag("file", entry.Name())
+		}
+		contents, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, errors.Go(err).Tag("file", entry.Name())
+		}
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: label}
+			byVersion[version] = migration
+		} else if migration.Name != label {
+			return nil, errors.Go(ErrDuplicateVersion).Tag("file", entry.Name()).Tag("version", strconv.FormatInt(version, 10))
+		}
+		if up {
+			migration.Up, hasUp[version] = string(contents), true
+		} else {
+			migration.Down, migration.HasDown = string(contents), true
+		}
+	}
+	rv := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if !hasUp[migration.Version] {
+			return nil, errors.Go(ErrMissingUp).Tag("migration", migration.String())
+		}
+		rv = append(rv, *migration)
+	}
+	sort.Slice(rv, func(a, b int) bool {
+		return rv[a].Version < rv[b].Version
+	})
+	return rv, nil
+}
+
+// parseName splits a file name without its suffix into its version and name.
+func parseName(name string) (int64, string, error) {
+	digits, label := name, ""
+	if n := strings.Index(name, "_"); n >= 0 {
+		digits, label = name[:n], name[n+1:]
+	}
+	version, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || version < 0 {
+		return 0, "", errors.Go(ErrInvalidFileName)
+	}
+	return version, label, nil
+}
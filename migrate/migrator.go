@@ -0,0 +1,292 @@
+package migrate
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nofeaturesonlybugs/errors"
+
+	"github.com/nofeaturesonlybugs/sqlh"
+	"github.com/nofeaturesonlybugs/sqlh/grammar"
+	"github.com/nofeaturesonlybugs/sqlh/schema"
+)
+
+// DefaultTable is the name of the bookkeeping table when Migrator.Table is empty.
+const DefaultTable = "schema_migrations"
+
+// DefaultLockID is the Postgres advisory lock key when Migrator.LockID is zero.
+const DefaultLockID int64 = 7259034160143209
+
+// Migrator applies and reverts migrations.
+type Migrator struct {
+	// FS contains the migration files.
+	FS fs.FS
+	// Dir is the directory within FS containing the migration files; if empty the root of FS is used.
+	Dir string
+	// Grammar determines the SQL used for the bookkeeping table and must implement schema.Inspector.
+	Grammar grammar.Grammar
+	// Table is the name of the bookkeeping table and can be schema qualified; if empty then
+	// DefaultTable is used.
+	Table string
+	// LockID is the key of the Postgres advisory lock; if zero then DefaultLockID is used.
+	LockID int64
+	// DryRun prevents changes to the database when not nil; the statements that would run
+	// are written to DryRun instead.  The bookkeeping table is still read if it exists.
+	DryRun io.Writer
+}
+
+// Applied describes a migration recorded in the bookkeeping table.
+type Applied struct {
+	// Version and Name identify the migration.
+	Version int64
+	Name    string
+	// Checksum is the checksum of the up file when the migration was applied.
+	Checksum string
+}
+
+// Report describes the work done by Migrator.Up or Migrator.Down.
+type Report struct {
+	// Migrations contains the migrations applied by Up or reverted by Down in the order they ran.
+	Migrations []Migration
+	// Changed contains applied migrations whose up file checksum no longer matches the
+	// checksum recorded when the migration was applied.
+	Changed []Applied
+}
+
+// Up applies every migration that has not been applied in order of version.
+//
+// All migrations are applied inside a single call to sqlh.Transact; if any migration fails
+// then no migrations are applied when Q supports transactions.
+func (me *Migrator) Up(Q sqlh.IQueries) (Report, error) {
+	var report Report
+	migrations, err := me.load()
+	if err != nil {
+		return report, errors.Go(err)
+	}
+	err = me.transact(Q, func(Q sqlh.IQueries) error {
+		applied, err := me.prepare(Q)
+		if err != nil {
+			return errors.Go(err)
+		}
+		report.Changed = changed(migrations, applied)
+		for _, migration := range migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err = me.exec(Q, migration.Up); err != nil {
+				return errors.Go(err).Tag("migration", migration.String())
+			}
+			insert := fmt.Sprintf("INSERT INTO %v ( version, name, checksum ) VALUES ( %v, %v, %v )", me.table(), me.param(0), me.param(1), me.param(2))
+			if err = me.exec(Q, insert, migration.Version, migration.Name, migration.Checksum()); err != nil {
+				return errors.Go(err).Tag("migration", migration.String())
+			}
+			report.Migrations = append(report.Migrations, migration)
+		}
+		return nil
+	})
+	if err != nil {
+		return Report{}, errors.Go(err)
+	}
+	return report, nil
+}
+
+// Down reverts the last steps applied migrations in reverse order of version.
+//
+// All migrations are reverted inside a single call to sqlh.Transact.
+func (me *Migrator) Down(Q sqlh.IQueries, steps int) (Report, error) {
+	var report Report
+	migrations, err := me.load()
+	if err != nil {
+		return report, errors.Go(err)
+	}
+	byVersion := map[int64]Migration{}
+	for _, migration := range migrations {
+		byVersion[migration.Version] = migration
+	}
+	err = me.transact(Q, func(Q sqlh.IQueries) error {
+		applied, err := me.prepare(Q)
+		if err != nil {
+			return errors.Go(err)
+		}
+		report.Changed = changed(migrations, applied)
+		versions := sortedVersions(applied)
+		for k := len(versions) - 1; k >= 0 && steps > 0; k, steps = k-1, steps-1 {
+			migration, ok := byVersion[versions[k]]
+			if !ok {
+				return errors.Go(ErrUnknownVersion).Tag("version", fmt.Sprint(versions[k]))
+			} else if !migration.HasDown {
+				return errors.Go(ErrMissingDown).Tag("migration", migration.String())
+			}
+			if err = me.exec(Q, migration.Down); err != nil {
+				return errors.Go(err).Tag("migration", migration.String())
+			}
+			remove := fmt.Sprintf("DELETE FROM %v WHERE version = %v", me.table(), me.param(0))
+			if err = me.exec(Q, remove, migration.Version); err != nil {
+				return errors.Go(err).Tag("migration", migration.String())
+			}
+			report.Migrations = append(report.Migrations, migration)
+		}
+		return nil
+	})
+	if err != nil {
+		return Report{}, errors.Go(err)
+	}
+	return report, nil
+}
+
+// load checks the Migrator and loads the migration files.
+func (me *Migrator) load() ([]Migration, error) {
+	if me == nil {
+		return nil, errors.NilReceiver()
+	} else if me.FS == nil {
+		return nil, errors.NilMember("FS").Type(me.FS)
+	} else if me.Grammar == nil {
+		return nil, errors.NilMember("Grammar").Type(me.Grammar)
+	}
+	rv, err := Load(me.FS, me.Dir)
+	if err != nil {
+		return nil, errors.Go(err)
+	}
+	return rv, nil
+}
+
+// transact calls fn inside sqlh.Transact unless DryRun is set.
+func (me *Migrator) transact(Q sqlh.IQueries, fn func(Q sqlh.IQueries) error) error {
+	if Q == nil {
+		return errors.NilArgument("Q")
+	} else if me.DryRun != nil {
+		return fn(Q)
+	}
+	return sqlh.Transact(Q, fn)
+}
+
+// prepare takes the advisory lock, creates the bookkeeping table if it does not exist, and
+// returns the applied migrations.
+func (me *Migrator) prepare(Q sqlh.IQueries) (map[int64]Applied, error) {
+	if _, ok := me.Grammar.(*grammar.PostgresGrammar); ok && me.DryRun == nil {
+		lockID := me.LockID
+		if lockID == 0 {
+			lockID = DefaultLockID
+		}
+		// A transaction level lock is released when sqlh.Transact commits or rolls back.
+		if err := me.exec(Q, "SELECT pg_advisory_xact_lock( $1 )", lockID); err != nil {
+			return nil, errors.Go(err)
+		}
+	}
+	//
+	bookkeeping := me.bookkeeping()
+	if _, err := schema.Inspect(Q, me.Grammar, bookkeeping.QualifiedName()); errors.Original(err) == schema.ErrTableNotFound {
+		create, err := me.Grammar.CreateTable(bookkeeping)
+		if err != nil {
+			return nil, errors.Go(err)
+		} else if err = me.exec(Q, create); err != nil {
+			return nil, errors.Go(err)
+		}
+		return map[int64]Applied{}, nil
+	} else if err != nil {
+		return nil, errors.Go(err)
+	}
+	//
+	rows, err := Q.Query("SELECT version, name, checksum FROM " + me.table() + " ORDER BY version")
+	if err != nil {
+		return nil, errors.Go(err)
+	}
+	defer rows.Close()
+	rv := map[int64]Applied{}
+	for rows.Next() {
+		var applied Applied
+		if err = rows.Scan(&applied.Version, &applied.Name, &applied.Checksum); err != nil {
+			return nil, errors.Go(err)
+		}
+		rv[applied.Version] = applied
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Go(err)
+	}
+	return rv, nil
+}
+
+// exec runs the query or writes it to DryRun.
+func (me *Migrator) exec(Q sqlh.IQueries, query string, args ...interface{}) error {
+	if me.DryRun != nil {
+		query = strings.TrimRight(strings.TrimSpace(query), ";")
+		if len(args) > 0 {
+			query = query + fmt.Sprintf("; -- %v", args)
+		} else {
+			query = query + ";"
+		}
+		if _, err := fmt.Fprintln(me.DryRun, query); err != nil {
+			return errors.Go(err)
+		}
+		return nil
+	}
+	if _, err := Q.Exec(query, args...); err != nil {
+		return errors.Go(err)
+	}
+	return nil
+}
+
+// bookkeeping returns the definition of the bookkeeping table.
+func (me *Migrator) bookkeeping() schema.Table {
+	name := me.Table
+	if name == "" {
+		name = DefaultTable
+	}
+	rv := schema.Table{
+		Name: name,
+		PrimaryKey: schema.Index{
+			Columns:   []schema.Column{{Name: "version", GoType: int64(0)}},
+			IsPrimary: true,
+			IsUnique:  true,
+		},
+		Columns: []schema.Column{
+			{Name: "name", GoType: ""},
+			{Name: "checksum", GoType: ""},
+			{Name: "applied_at", GoType: time.Time{}, Auto: true},
+		},
+	}
+	if n := strings.LastIndex(name, "."); n > 0 {
+		rv.Schema, rv.Name = name[:n], name[n+1:]
+	}
+	return rv
+}
+
+// table returns the quoted name of the bookkeeping table.
+func (me *Migrator) table() string {
+	return me.Grammar.Quote(me.bookkeeping().QualifiedName())
+}
+
+// param returns the placeholder for parameter n where n is zero based.
+func (me *Migrator) param(n int) string {
+	if paramer, ok := me.Grammar.(interface{ ParamN(int) string }); ok {
+		return paramer.ParamN(n)
+	}
+	return "?"
+}
+
+// changed returns the applied migrations whose checksums differ from the migration files.
+func changed(migrations []Migration, applied map[int64]Applied) []Applied {
+	var rv []Applied
+	for _, migration := range migrations {
+		if prior, ok := applied[migration.Version]; ok && prior.Checksum != migration.Checksum() {
+			rv = append(rv, prior)
+		}
+	}
+	return rv
+}
+
+// sortedVersions returns the versions of the applied migrations in ascending order.
+func sortedVersions(applied map[int64]Applied) []int64 {
+	rv := make([]int64, 0, len(applied))
+	for version := range applied {
+		rv = append(rv, version)
+	}
+	sort.Slice(rv, func(a, b int) bool {
+		return rv[a] < rv[b]
+	})
+	return rv
+}
@@ -0,0 +1,18 @@
+// Package migrate applies versioned SQL migration files.
+//
+// Migration files are read from an fs.FS so migrations can be embedded in the application
+// binary with package embed.  Files are named VERSION_NAME.up.sql and VERSION_NAME.down.sql
+// where VERSION is an integer; migrations are applied in order of VERSION.  Other files
+// are ignored.
+//
+//	0001_create_people.up.sql
+//	0001_create_people.down.sql
+//	0002_add_email.up.sql
+//
+// A Migrator runs the migrations inside sqlh.Transact and records applied versions in a
+// bookkeeping table.  On Postgres a transaction level advisory lock prevents concurrent
+// Migrators from applying the same migrations.
+//
+// The checksum of each up file is recorded when it is applied; Migrator.Up reports applied
+// migrations whose files have since changed.
+package migrate
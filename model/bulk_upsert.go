@@ -0,0 +1,238 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/nofeaturesonlybugs/errors"
+
+	"github.com/nofeaturesonlybugs/sqlh"
+	"github.com/nofeaturesonlybugs/sqlh/grammar"
+)
+
+const (
+	// bulkParameters limits the number of parameters in each INSERT that loads the staging table;
+	// it is the default limit of older SQLite versions.
+	bulkParameters = 999
+	// bulkParametersPostgres is the Postgres limit on the number of parameters in a statement.
+	bulkParametersPostgres = 65535
+)
+
+// stagingTable returns the name of the temporary table used by BulkUpsert for table.
+func stagingTable(table string) string {
+	return "sqlh_bulk_" + strings.ReplaceAll(table, ".", "_")
+}
+
+// BulkUpsert upserts values with a single INSERT ... SELECT ... ON CONFLICT DO UPDATE statement.
+// values is a slice []T or []*T, or a pointer to one, of a registered model that supports Upsert;
+// otherwise ErrUnsupported is returned.
+//
+// Inside a single call to sqlh.Transact BulkUpsert:
+//   - creates a temporary staging table with the model's columns,
+//   - loads values into the staging table with COPY if Q implements sqlh.ICopies and Grammar is
+//     a *grammar.PostgresGrammar, otherwise with multi-row INSERT statements,
+//   - upserts the staging table into the model's table and scans the RETURNING rows back into the
+//     values with matching keys,
+//   - drops the staging table, also when a previous step fails.
+//
+// As with Upsert the rows that are unchanged by the query are not returned so their values are not
+// updated.  values must not contain duplicate keys; databases refuse to update a row twice in one
+// statement.  A RETURNING row whose key matches none of values returns ErrUnmatchedRow.
+//
+// Temporary tables belong to a connection so Q should be a database or transaction and not a pool
+// of independent connections that can not begin a transaction.
+func (me *Models) BulkUpsert(Q sqlh.IQueries, values interface{}) error {
+	if me == nil {
+		return errors.NilReceiver()
+	} else if Q == nil {
+		return errors.NilArgument("Q")
+	} else if values == nil {
+		return errors.NilArgument("values")
+	}
+	v := reflect.ValueOf(values)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice {
+		return errors.Go(ErrUnsupported).Tag("BULK UPSERT", fmt.Sprintf("%T is not a slice", values))
+	}
+	model, err := me.Lookup(v.Interface())
+	if err != nil {
+		return errors.Go(err)
+	} else if model.Statements.BulkUpsert == nil || model.Statements.Upsert == nil {
+		return errors.Go(ErrUnsupported).Tag("BULK UPSERT", fmt.Sprintf("%T", values))
+	}
+	elems, _, err := preloadParents(v.Interface())
+	if err != nil {
+		return errors.Go(err)
+	} else if len(elems) != v.Len() {
+		return errors.Go(ErrUnsupported).Tag("BULK UPSERT", "values contains nil pointers")
+	} else if len(elems) == 0 {
+		return nil
+	} else if err = me.populate(model, v.Interface(), "UPSERT"); err != nil {
+		return errors.Go(err)
+	}
+	return sqlh.Transact(me.queries(Q, "UPSERT", model, v.Type()), func(Q sqlh.IQueries) error {
+		return me.bulkUpsert(Q, model, elems)
+	})
+}
+
+// bulkUpsert performs BulkUpsert for elems of model.  The staging table is also dropped when the
+// upsert fails so it is not left behind when Q is a transaction of the caller.
+func (me *Models) bulkUpsert(Q sqlh.IQueries, model *Model, elems []reflect.Value) error {
+	g := me.grammar()
+	table, staging := model.Table.QualifiedName(), stagingTable(model.Table.QualifiedName())
+	columns := model.Statements.Upsert.Arguments
+	create := "CREATE TEMPORARY TABLE " + g.Quote(staging) + " AS SELECT " + strings.Join(quoteAll(g.Quote, columns), ", ") + " FROM " + g.Quote(table) + " WHERE 1 = 0"
+	if _, err := Q.Exec(create); err != nil {
+		return errors.Go(err).Tag("SQL", create)
+	}
+	drop := "DROP TABLE " + g.Quote(staging)
+	if err := me.upsertStaging(Q, model, staging, elems); err != nil {
+		err = errors.Go(err)
+		if _, dropErr := Q.Exec(drop); dropErr != nil {
+			err.(errors.Error).Tag("staging-drop", dropErr.Error())
+		}
+		return err
+	} else if _, err = Q.Exec(drop); err != nil {
+		return errors.Go(err).Tag("SQL", drop)
+	}
+	return nil
+}
+
+// upsertStaging loads elems into the staging table and upserts the staging table into the table of
+// model.
+func (me *Models) upsertStaging(Q sqlh.IQueries, model *Model, staging string, elems []reflect.Value) error {
+	columns := model.Statements.Upsert.Arguments
+	query := model.Statements.BulkUpsert
+	keys := query.Scan[:len(query.Scan)-len(model.Statements.Upsert.Scan)]
+	if err := me.loadStaging(Q, staging, columns, elems); err != nil {
+		return errors.Go(err)
+	}
+	//
+	// Returned rows are matched to elems by their keys.
+	byKey := map[string][]reflect.Value{}
+	for _, elem := range elems {
+		key := bulkKey(model, keys, elem)
+		byKey[key] = append(byKey[key], elem)
+	}
+	typ := elems[0].Type()
+	prepared, err := me.Mapper.Prepare(reflect.New(typ))
+	if err != nil {
+		return errors.Go(err)
+	} else if err = prepared.Plan(query.Scan...); err != nil {
+		return errors.Go(err)
+	}
+	rows, err := Q.Query(query.SQL)
+	if err != nil {
+		return errors.Go(sqlh.ClassifyError(me.classifier(), err)).Tag("SQL", query.SQL)
+	}
+	defer rows.Close()
+	var dest []interface{}
+	for rows.Next() {
+		row := reflect.New(typ)
+		prepared.Rebind(row)
+		if dest, err = prepared.Assignables(dest); err != nil {
+			return errors.Go(err)
+		} else if err = rows.Scan(dest...); err != nil {
+			return errors.Go(err).Tag("SQL", query.SQL)
+		}
+		key := bulkKey(model, keys, row.Elem())
+		if len(byKey[key]) == 0 {
+			return errors.Go(ErrUnmatchedRow).Tag("key", strings.ReplaceAll(key, "\x00", ", ")).Tag("SQL", query.SQL)
+		}
+		for _, elem := range byKey[key] {
+			for _, column := range query.Scan[len(keys):] {
+				path := model.Mapping.ReflectPaths[column]
+				path.Value(elem).Set(path.Value(row.Elem()))
+			}
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return errors.Go(sqlh.ClassifyError(me.classifier(), err)).Tag("SQL", query.SQL)
+	}
+	return nil
+}
+
+// parameterLimit returns the number of parameters allowed in a statement for the grammar.
+func (me *Models) parameterLimit() int {
+	if _, postgres := me.Grammar.(*grammar.PostgresGrammar); postgres {
+		return bulkParametersPostgres
+	}
+	return bulkParameters
+}
+
+// loadStaging loads the columns of elems into the staging table.
+func (me *Models) loadStaging(Q sqlh.IQueries, staging string, columns []string, elems []reflect.Value) error {
+	prepared, err := me.Mapper.Prepare(reflect.New(elems[0].Type()))
+	if err != nil {
+		return errors.Go(err)
+	} else if err = prepared.Plan(columns...); err != nil {
+		return errors.Go(err)
+	}
+	_, postgres := me.Grammar.(*grammar.PostgresGrammar)
+	if copier, ok := Q.(sqlh.ICopies); ok && postgres {
+		ptrs := reflect.MakeSlice(reflect.SliceOf(reflect.PtrTo(elems[0].Type())), len(elems), len(elems))
+		for k, elem := range elems {
+			ptrs.Index(k).Set(elem.Addr())
+		}
+		if _, err = copier.CopyFrom(staging, columns, &copySource{values: ptrs, prepared: prepared, row: -1}); err != nil {
+			return errors.Go(err).Tag("table", staging)
+		}
+		return nil
+	}
+	//
+	// Without COPY the rows are loaded with multi-row INSERTs limited by the number of parameters.
+	g := me.grammar()
+	batch := me.parameterLimit() / len(columns)
+	if batch < 1 {
+		batch = 1
+	}
+	insert := "INSERT INTO " + g.Quote(staging) + " ( " + strings.Join(quoteAll(g.Quote, columns), ", ") + " ) VALUES "
+	for start := 0; start < len(elems); start += batch {
+		end := start + batch
+		if end > len(elems) {
+			end = len(elems)
+		}
+		rows := make([]string, 0, end-start)
+		args := make([]interface{}, 0, (end-start)*len(columns))
+		for _, elem := range elems[start:end] {
+			params := make([]string, len(columns))
+			for k := range params {
+				params[k] = param(g, len(args)+k)
+			}
+			rows = append(rows, "( "+strings.Join(params, ", ")+" )")
+			prepared.Rebind(elem.Addr())
+			fields, err := prepared.Fields(nil)
+			if err != nil {
+				return errors.Go(err)
+			}
+			args = append(args, fields...)
+		}
+		query := insert + strings.Join(rows, ", ")
+		if _, err = Q.Exec(query, args...); err != nil {
+			return errors.Go(err).Tag("SQL", query)
+		}
+	}
+	return nil
+}
+
+// bulkKey returns a string that identifies the row in value by the columns in keys.  Times are
+// compared in UTC without their monotonic clock reading since they do not survive the database.
+func bulkKey(model *Model, keys []string, value reflect.Value) string {
+	parts := make([]string, len(keys))
+	for k, column := range keys {
+		key := model.Mapping.ReflectPaths[column].Value(value)
+		for key.Kind() == reflect.Ptr && !key.IsNil() {
+			key = key.Elem()
+		}
+		if t, ok := key.Interface().(time.Time); ok {
+			parts[k] = t.UTC().Format(time.RFC3339Nano)
+			continue
+		}
+		parts[k] = fmt.Sprint(key.Interface())
+	}
+	return strings.Join(parts, "\x00")
+}
@@ -0,0 +1,181 @@
+package model_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/nofeaturesonlybugs/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nofeaturesonlybugs/sqlh"
+	"github.com/nofeaturesonlybugs/sqlh/model"
+	"github.com/nofeaturesonlybugs/sqlh/model/examples"
+)
+
+// connCopier is a connection that implements sqlh.ICopies but can not begin transactions.
+type connCopier struct {
+	sqlh.IQueries
+	copier fakeCopier
+}
+
+func (me *connCopier) CopyFrom(table string, columns []string, source sqlh.ICopySource) (int64, error) {
+	return me.copier.CopyFrom(table, columns, source)
+}
+
+func TestModels_BulkUpsert(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	mdb := examples.NewModels()
+	//
+	created, modified := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC), time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TEMPORARY TABLE sqlh_bulk_upsertable AS SELECT pk, string, number FROM upsertable WHERE 1 = 0")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO sqlh_bulk_upsertable ( pk, string, number ) VALUES ( $1, $2, $3 ), ( $4, $5, $6 )")).
+		WithArgs("a", "Hello", 1, "b", "World", 2).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT pk, string, number FROM sqlh_bulk_upsertable")).
+		WillReturnRows(sqlmock.NewRows([]string{"pk", "created_tmz", "modified_tmz"}).AddRow("b", created, modified))
+	mock.ExpectExec(regexp.QuoteMeta("DROP TABLE sqlh_bulk_upsertable")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	values := []examples.Upsertable{{Id: "a", String: "Hello", Number: 1}, {Id: "b", String: "World", Number: 2}}
+	err = mdb.BulkUpsert(db, values)
+	chk.NoError(err)
+	chk.NoError(mock.ExpectationsWereMet())
+	// Rows that are not returned are unchanged.
+	chk.True(values[0].ModifiedTime.IsZero())
+	chk.Equal(created, values[1].CreatedTime)
+	chk.Equal(modified, values[1].ModifiedTime)
+	//
+	// Errors roll back the transaction.
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TEMPORARY TABLE")).WillReturnError(errors.Errorf("permission denied"))
+	mock.ExpectRollback()
+	err = mdb.BulkUpsert(db, &values)
+	chk.Error(err)
+	chk.NoError(mock.ExpectationsWereMet())
+	//
+	// The staging table is dropped when the upsert fails within a transaction of the caller.
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TEMPORARY TABLE sqlh_bulk_upsertable")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO sqlh_bulk_upsertable")).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT pk, string, number FROM sqlh_bulk_upsertable")).
+		WillReturnError(errors.Errorf("deadlock detected"))
+	mock.ExpectExec(regexp.QuoteMeta("DROP TABLE sqlh_bulk_upsertable")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	tx, err := db.Begin()
+	chk.NoError(err)
+	chk.Error(mdb.BulkUpsert(tx, values))
+	chk.NoError(mock.ExpectationsWereMet())
+	//
+	// A failed DROP is reported with the error of the upsert.
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TEMPORARY TABLE sqlh_bulk_upsertable")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO sqlh_bulk_upsertable")).
+		WillReturnError(errors.Errorf("disk full"))
+	mock.ExpectExec(regexp.QuoteMeta("DROP TABLE sqlh_bulk_upsertable")).
+		WillReturnError(errors.Errorf("transaction aborted"))
+	err = mdb.BulkUpsert(tx, values)
+	chk.Error(err)
+	chk.Contains(err.Error(), "transaction aborted")
+	chk.NoError(mock.ExpectationsWereMet())
+}
+
+// Reading is keyed by a time.
+type Reading struct {
+	model.TableName `model:"readings"`
+	At              time.Time `json:"at" model:"key"`
+	Value           int       `json:"value"`
+	Modified        time.Time `json:"modified" model:"inserted,updated"`
+}
+
+func TestModels_BulkUpsertMatch(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	mdb := examples.NewModels()
+	chk.NoError(mdb.Register(Reading{}))
+	modified := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	//
+	// Time keys match in any location and without the monotonic clock reading.
+	at := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TEMPORARY TABLE sqlh_bulk_readings")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO sqlh_bulk_readings")).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM sqlh_bulk_readings")).
+		WillReturnRows(sqlmock.NewRows([]string{"at", "modified"}).AddRow(at.Round(0).In(time.FixedZone("X", 3600)), modified))
+	mock.ExpectExec(regexp.QuoteMeta("DROP TABLE sqlh_bulk_readings")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	readings := []Reading{{At: at, Value: 1}}
+	chk.NoError(mdb.BulkUpsert(db, readings))
+	chk.Equal(modified, readings[0].Modified)
+	chk.NoError(mock.ExpectationsWereMet())
+	//
+	// Returned rows that match no value are errors.
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TEMPORARY TABLE sqlh_bulk_readings")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO sqlh_bulk_readings")).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM sqlh_bulk_readings")).
+		WillReturnRows(sqlmock.NewRows([]string{"at", "modified"}).AddRow(at.Add(time.Second), modified))
+	mock.ExpectExec(regexp.QuoteMeta("DROP TABLE sqlh_bulk_readings")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	err = mdb.BulkUpsert(db, readings)
+	chk.True(errors.Is(err, model.ErrUnmatchedRow))
+	chk.NoError(mock.ExpectationsWereMet())
+}
+
+func TestModels_BulkUpsertCopy(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	mdb := examples.NewModels()
+	//
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TEMPORARY TABLE sqlh_bulk_upsertable")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT pk, string, number FROM sqlh_bulk_upsertable")).
+		WillReturnRows(sqlmock.NewRows([]string{"pk", "created_tmz", "modified_tmz"}))
+	mock.ExpectExec(regexp.QuoteMeta("DROP TABLE sqlh_bulk_upsertable")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	conn := &connCopier{IQueries: db}
+	values := []*examples.Upsertable{{Id: "a", Number: 1}}
+	err = mdb.BulkUpsert(conn, values)
+	chk.NoError(err)
+	chk.NoError(mock.ExpectationsWereMet())
+	chk.Equal("sqlh_bulk_upsertable", conn.copier.table)
+	chk.Equal([]string{"pk", "string", "number"}, conn.copier.columns)
+	chk.Equal([][]interface{}{{"a", "", 1}}, conn.copier.rows)
+}
+
+func TestModels_BulkUpsertUnsupported(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, _, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	mdb := examples.NewModels()
+	//
+	err = mdb.BulkUpsert(db, []examples.Address{{}})
+	chk.Equal(model.ErrUnsupported, errors.Original(err))
+	err = mdb.BulkUpsert(db, examples.Upsertable{})
+	chk.Equal(model.ErrUnsupported, errors.Original(err))
+	err = mdb.BulkUpsert(db, []*examples.Upsertable{{}, nil})
+	chk.Equal(model.ErrUnsupported, errors.Original(err))
+	chk.NoError(mdb.BulkUpsert(db, []examples.Upsertable{}))
+	//
+	chk.Error(mdb.BulkUpsert(nil, []examples.Upsertable{}))
+	chk.Error(mdb.BulkUpsert(db, nil))
+	var nilModels *model.Models
+	chk.Error(nilModels.BulkUpsert(db, []examples.Upsertable{}))
+}
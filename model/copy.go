@@ -0,0 +1,112 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/nofeaturesonlybugs/errors"
+	"github.com/nofeaturesonlybugs/set"
+
+	"github.com/nofeaturesonlybugs/sqlh"
+	"github.com/nofeaturesonlybugs/sqlh/grammar"
+)
+
+// CopyIn bulk loads values with Postgres COPY table ( columns ) FROM STDIN and returns the number
+// of rows copied.  values is a slice []T or []*T, or a pointer to one, of a registered model.
+//
+// The columns are the model's Statements.Insert.Arguments so auto, inserted, and updated columns
+// are populated by the database; unlike Insert their values are not scanned back into values.
+//
+// Q must implement sqlh.ICopies and Grammar must be a *grammar.PostgresGrammar; otherwise
+// ErrUnsupported is returned.  sqlh.ICopies is small enough to adapt pgx or lib/pq connections.
+func (me *Models) CopyIn(Q sqlh.IQueries, values interface{}) (int64, error) {
+	if me == nil {
+		return 0, errors.NilReceiver()
+	} else if Q == nil {
+		return 0, errors.NilArgument("Q")
+	} else if values == nil {
+		return 0, errors.NilArgument("values")
+	}
+	if _, ok := me.Grammar.(*grammar.PostgresGrammar); !ok {
+		return 0, errors.Go(ErrUnsupported).Tag("COPY", fmt.Sprintf("grammar %T", me.Grammar))
+	}
+	copier, ok := Q.(sqlh.ICopies)
+	if !ok {
+		return 0, errors.Go(ErrUnsupported).Tag("COPY", fmt.Sprintf("%T does not implement sqlh.ICopies", Q))
+	}
+	v := reflect.ValueOf(values)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice {
+		return 0, errors.Go(ErrUnsupported).Tag("COPY", fmt.Sprintf("%T is not a slice", values))
+	}
+	model, err := me.Lookup(v.Interface())
+	if err != nil {
+		return 0, errors.Go(err)
+	} else if model.Statements.Insert == nil {
+		return 0, errors.Go(ErrUnsupported).Tag("COPY", fmt.Sprintf("%T", values))
+	} else if v.Len() == 0 {
+		return 0, nil
+	} else if err = me.populate(model, v.Interface(), "INSERT"); err != nil {
+		return 0, errors.Go(err)
+	}
+	copier = me.queries(Q, "COPY", model, v.Type()).(sqlh.ICopies)
+	//
+	// If the call to Plan succeeds then further calls to Fields will not error.
+	columns := model.Statements.Insert.Arguments
+	prepared, err := me.Mapper.Prepare(reflect.New(derefType(v.Type().Elem())))
+	if err != nil {
+		return 0, errors.Go(err)
+	} else if err = prepared.Plan(columns...); err != nil {
+		return 0, errors.Go(err)
+	}
+	source := &copySource{values: v, prepared: prepared, row: -1}
+	n, err := copier.CopyFrom(model.Table.QualifiedName(), columns, source)
+	if err != nil {
+		return n, errors.Go(sqlh.ClassifyError(me.classifier(), err)).Tag("table", model.Table.QualifiedName())
+	}
+	return n, nil
+}
+
+// copySource implements sqlh.ICopySource for a slice of models.
+type copySource struct {
+	values   reflect.Value
+	prepared set.PreparedMapping
+	row      int
+	err      error
+}
+
+// Next advances to the next element of the slice.
+func (me *copySource) Next() bool {
+	if me.err != nil || me.row+1 >= me.values.Len() {
+		return false
+	}
+	me.row++
+	return true
+}
+
+// Values returns the column values of the current element.
+func (me *copySource) Values() ([]interface{}, error) {
+	elem := me.values.Index(me.row)
+	for elem.Kind() == reflect.Ptr {
+		if elem.IsNil() {
+			me.err = errors.Go(ErrUnsupported).Tag("nil pointer", fmt.Sprintf("index %v", me.row))
+			return nil, me.err
+		}
+		elem = elem.Elem()
+	}
+	me.prepared.Rebind(elem.Addr())
+	// Each row gets a new slice since drivers can retain the values.
+	rv, err := me.prepared.Fields(nil)
+	if err != nil {
+		me.err = errors.Go(err)
+		return nil, me.err
+	}
+	return rv, nil
+}
+
+// Err returns the error that stopped iteration.
+func (me *copySource) Err() error {
+	return me.err
+}
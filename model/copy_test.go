@@ -0,0 +1,105 @@
+package model_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/nofeaturesonlybugs/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nofeaturesonlybugs/sqlh"
+	"github.com/nofeaturesonlybugs/sqlh/grammar"
+	"github.com/nofeaturesonlybugs/sqlh/model"
+	"github.com/nofeaturesonlybugs/sqlh/model/examples"
+)
+
+// fakeCopier implements sqlh.ICopies by collecting the copied rows.
+type fakeCopier struct {
+	*sql.DB
+	table   string
+	columns []string
+	rows    [][]interface{}
+	err     error
+}
+
+func (me *fakeCopier) CopyFrom(table string, columns []string, source sqlh.ICopySource) (int64, error) {
+	me.table, me.columns = table, columns
+	for source.Next() {
+		values, err := source.Values()
+		if err != nil {
+			return int64(len(me.rows)), err
+		}
+		me.rows = append(me.rows, values)
+	}
+	if err := source.Err(); err != nil {
+		return int64(len(me.rows)), err
+	}
+	return int64(len(me.rows)), me.err
+}
+
+func TestModels_CopyIn(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, _, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	mdb := examples.NewModels()
+	//
+	copier := &fakeCopier{DB: db}
+	addresses := []*examples.Address{{Street: "Main", City: "Springfield"}, {Street: "Elm", Zip: "12345"}}
+	n, err := mdb.CopyIn(copier, &addresses)
+	chk.NoError(err)
+	chk.Equal(int64(2), n)
+	chk.Equal("addresses", copier.table)
+	chk.Equal([]string{"street", "city", "state", "zip"}, copier.columns)
+	chk.Equal([][]interface{}{{"Main", "Springfield", "", ""}, {"Elm", "", "", "12345"}}, copier.rows)
+	//
+	// Empty slices do not copy.
+	copier = &fakeCopier{DB: db}
+	n, err = mdb.CopyIn(copier, []examples.Address{})
+	chk.NoError(err)
+	chk.Equal(int64(0), n)
+	chk.Equal("", copier.table)
+	//
+	// Nil elements stop the copy.
+	copier = &fakeCopier{DB: db}
+	n, err = mdb.CopyIn(copier, []*examples.Address{{}, nil})
+	chk.Equal(model.ErrUnsupported, errors.Original(err))
+	chk.Equal(int64(1), n)
+	//
+	// Copy errors are returned.
+	copier = &fakeCopier{DB: db, err: errors.Errorf("copy failed")}
+	_, err = mdb.CopyIn(copier, []examples.Address{{}})
+	chk.Error(err)
+}
+
+func TestModels_CopyInUnsupported(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, _, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	mdb := examples.NewModels()
+	copier := &fakeCopier{DB: db}
+	//
+	_, err = mdb.CopyIn(db, []examples.Address{{}})
+	chk.Equal(model.ErrUnsupported, errors.Original(err))
+	_, err = mdb.CopyIn(copier, examples.Address{})
+	chk.Equal(model.ErrUnsupported, errors.Original(err))
+	_, err = mdb.CopyIn(copier, []int{1})
+	chk.Error(err)
+	//
+	sqlite := &model.Models{Mapper: mdb.Mapper, Grammar: grammar.Sqlite}
+	sqlite.MustRegister(examples.Address{})
+	_, err = sqlite.CopyIn(copier, []examples.Address{{}})
+	chk.Equal(model.ErrUnsupported, errors.Original(err))
+	//
+	_, err = mdb.CopyIn(nil, []examples.Address{})
+	chk.Error(err)
+	_, err = mdb.CopyIn(copier, nil)
+	chk.Error(err)
+	var nilModels *model.Models
+	_, err = nilModels.CopyIn(copier, []examples.Address{})
+	chk.Error(err)
+}
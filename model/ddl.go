@@ -0,0 +1,68 @@
+package model
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/nofeaturesonlybugs/errors"
+
+	"github.com/nofeaturesonlybugs/sqlh/schema"
+)
+
+// DDL returns the statements to create the tables and indexes of every registered model.  Each
+// table's CREATE TABLE statement is followed by the CREATE INDEX statements for its indexes
+// that are not unique.  Tables are created in the order returned by Tables.
+//
+// DDL is intended for bootstrapping test databases; see grammar.Grammar.CreateTable for how
+// Go types are mapped to SQL types.
+func (me *Models) DDL() ([]string, error) {
+	if me == nil {
+		return nil, errors.NilReceiver()
+	} else if me.Grammar == nil {
+		return nil, errors.NilMember("Grammar").Type(me.Grammar)
+	}
+	g := me.grammar()
+	var rv []string
+	for _, table := range me.Tables() {
+		statement, err := g.CreateTable(table)
+		if err != nil {
+			return nil, errors.Go(err)
+		}
+		rv = append(rv, statement)
+		for _, index := range table.Indexes {
+			if statement, err = g.CreateIndex(index); err != nil {
+				return nil, errors.Go(err)
+			}
+			rv = append(rv, statement)
+		}
+	}
+	return rv, nil
+}
+
+// Tables returns the tables of the registered models ordered by the names of the registered types
+// except tables referenced by foreign keys come before the tables referencing them.  Tables shared
+// by more than one model are only returned once.
+//
+// Tables can be compared to the tables returned by schema.Inspect with schema.Diff.
+func (me *Models) Tables() []schema.Table {
+	if me == nil {
+		return nil
+	}
+	var rv []schema.Table
+	seen := map[string]bool{}
+	registered := me.models()
+	for _, typ := range me.registeredTypes() {
+		table := registered[typ].Table
+		if name := table.QualifiedName(); !seen[name] {
+			seen[name] = true
+			rv = append(rv, table)
+		}
+	}
+	return dependencyOrder(rv)
+}
+
+// nullableType returns true if fields of typ can hold NULL values: pointers and the
+// database/sql Null types.
+func nullableType(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Ptr || (typ.PkgPath() == "database/sql" && strings.HasPrefix(typ.Name(), "Null"))
+}
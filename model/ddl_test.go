@@ -0,0 +1,76 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/nofeaturesonlybugs/errors"
+	"github.com/nofeaturesonlybugs/set"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nofeaturesonlybugs/sqlh/grammar"
+	"github.com/nofeaturesonlybugs/sqlh/model"
+	"github.com/nofeaturesonlybugs/sqlh/model/examples"
+	"github.com/nofeaturesonlybugs/sqlh/schema"
+)
+
+func TestModels_Tables(t *testing.T) {
+	chk := assert.New(t)
+	//
+	type Summary struct {
+		model.TableName `model:"people"`
+		Id              int `db:"pk" model:"key,auto"`
+	}
+	mdb := examples.NewModels()
+	mdb.MustRegister(Summary{})
+	//
+	names := []string{}
+	for _, table := range mdb.Tables() {
+		names = append(names, table.QualifiedName())
+	}
+	chk.Equal([]string{"addresses", "log", "people", "relate_people_addresses", "relationship", "upsertable"}, names)
+	//
+	// Registered tables diffed against themselves have no changes.
+	chk.Empty(schema.Diff(mdb.Tables(), mdb.Tables()))
+	//
+	// Columns have the SQL types of the grammar so type changes are found against introspected tables.
+	from := mdb.Tables()[0]
+	chk.Equal("TEXT", from.Columns[2].SqlType)
+	from.Columns = append([]schema.Column{}, from.Columns...)
+	from.Columns[2].SqlType = "integer"
+	if changes := schema.Diff([]schema.Table{from}, mdb.Tables()[:1]); chk.Len(changes, 1) {
+		chk.Equal(schema.AlterColumn, changes[0].Kind)
+		chk.Equal("street", changes[0].Column.Name)
+	}
+	//
+	var nilModels *model.Models
+	chk.Nil(nilModels.Tables())
+}
+
+func TestModels_DDLErrors(t *testing.T) {
+	chk := assert.New(t)
+	//
+	type Point struct {
+		X, Y int
+	}
+	type Unknown struct {
+		model.TableName `model:"unknowns"`
+		Id              int   `db:"pk" model:"key,auto"`
+		Value           Point `db:"value"`
+	}
+	mdb := &model.Models{
+		Mapper: &set.Mapper{
+			Tags:          []string{"db"},
+			TreatAsScalar: set.NewTypeList(Point{}),
+		},
+		Grammar: grammar.Postgres,
+	}
+	mdb.MustRegister(Unknown{})
+	_, err := mdb.DDL()
+	chk.Equal(grammar.ErrUnknownType, errors.Original(err))
+	//
+	var nilModels *model.Models
+	_, err = nilModels.DDL()
+	chk.Error(err)
+	_, err = (&model.Models{}).DDL()
+	chk.Error(err)
+}
@@ -1,5 +1,89 @@
 package model
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
 
-var ErrUnsupported error = errors.New("unsupported")
+	pkgerrors "github.com/nofeaturesonlybugs/errors"
+)
+
+var (
+	ErrUnsupported error = errors.New("unsupported")
+
+	// ErrTableNameRequired is returned when a model is registered without a table name.
+	ErrTableNameRequired error = errors.New("table name is required")
+	// ErrTagConflict is returned when a field's struct tag contains options that can not be combined.
+	ErrTagConflict error = errors.New("conflicting tag options")
+	// ErrUnmappedKey is returned when a key field is not mapped to a column by the Mapper.
+	ErrUnmappedKey error = errors.New("key field is not mapped")
+	// ErrSchemaDrift is returned from DriftReport.Err when models differ from the database.
+	ErrSchemaDrift error = errors.New("schema drift")
+	// ErrUnknownRelation is returned from Models.Preload when a relation can not be resolved from
+	// the foreign keys of registered models.
+	ErrUnknownRelation error = errors.New("unknown relation")
+	// ErrUnknownGenerator is returned from Models.Register when a field is tagged with a generator
+	// that is not in Models.Generators or DefaultGenerators.
+	ErrUnknownGenerator error = errors.New("unknown generator")
+	// ErrUnknownColumn is returned from RepoQuery.All when a condition names a column that is not a
+	// column of the model.
+	ErrUnknownColumn error = errors.New("unknown column")
+	// ErrUnmatchedRow is returned from Models.BulkUpsert when a RETURNING row does not have the key
+	// of any of the values.
+	ErrUnmatchedRow error = errors.New("returned row matches no value")
+)
+
+// Errors is a collection of errors and is returned when more than one problem is found
+// during model registration or validation.
+type Errors []error
+
+// Error returns the collection as a string with one error per line.
+func (me Errors) Error() string {
+	messages := make([]string, len(me))
+	for k, err := range me {
+		messages[k] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Is returns true if any error in the collection matches target.
+func (me Errors) Is(target error) bool {
+	for _, err := range me {
+		if pkgerrors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactedValue replaces the values of columns tagged redact in ElementError.Args.
+const RedactedValue = "[REDACTED]"
+
+// ElementError describes the failure of one element of a slice given to QueryBinding.QuerySlice and
+// the Models methods that call it.
+type ElementError struct {
+	// Index is the index of the element in the slice.
+	Index int
+	// Model is the type of the element.
+	Model string
+	// SQL is the query and Args are its arguments; values of redacted columns are RedactedValue.
+	SQL  string
+	Args []interface{}
+	// Err is the error returned for the element.
+	Err error
+}
+
+// Error returns the error with the index, model, SQL, and arguments.
+func (me *ElementError) Error() string {
+	return fmt.Sprintf("%v at index %v of %v; SQL %v; args %v", me.Err, me.Index, me.Model, me.SQL, me.Args)
+}
+
+// Is returns true if Err matches target.
+func (me *ElementError) Is(target error) bool {
+	return pkgerrors.Is(me.Err, target)
+}
+
+// Unwrap returns Err.
+func (me *ElementError) Unwrap() error {
+	return me.Err
+}
@@ -36,23 +36,23 @@ func NewModels() *model.Models {
 		},
 		Grammar: grammar.Postgres,
 	}
-	rv.Register(Address{})
-	rv.Register(LogEntry{})
-	rv.Register(Person{})
-	rv.Register(PersonAddress{})
-	rv.Register(Relationship{})
-	rv.Register(Upsertable{})
+	rv.MustRegister(Address{})
+	rv.MustRegister(LogEntry{})
+	rv.MustRegister(Person{})
+	rv.MustRegister(PersonAddress{})
+	rv.MustRegister(Relationship{})
+	rv.MustRegister(Upsertable{})
 	return rv
 }
 
 func init() {
 	// Somewhere in your application you need to register all types to be used as models.
-	Models.Register(Address{})
-	Models.Register(LogEntry{})
-	Models.Register(Person{})
-	Models.Register(PersonAddress{})
-	Models.Register(Relationship{})
-	Models.Register(Upsertable{})
+	Models.MustRegister(Address{})
+	Models.MustRegister(LogEntry{})
+	Models.MustRegister(Person{})
+	Models.MustRegister(PersonAddress{})
+	Models.MustRegister(Relationship{})
+	Models.MustRegister(Upsertable{})
 }
 
 // Address is a simple model representing an address.
@@ -85,14 +85,16 @@ type Person struct {
 	Last         string    `json:"last"`
 	Age          int       `json:"age"`
 	SSN          string    `json:"ssn" model:"unique"`
+	// Addresses is loaded by Models.Preload through PersonAddress.
+	Addresses []Address `json:"addresses,omitempty"`
 }
 
 // PersonAddress links a person to an address.
 type PersonAddress struct {
 	model.TableName `json:"-" model:"relate_people_addresses"`
 	//
-	PersonId  int `json:"person_id" db:"person_fk" model:"key"`
-	AddressId int `json:"address_id" db:"address_fk" model:"key"`
+	PersonId  int `json:"person_id" db:"person_fk" model:"key,fk(people.pk)"`
+	AddressId int `json:"address_id" db:"address_fk" model:"key,fk(addresses.pk)"`
 }
 
 // Upsertable is a model that can use UPSERT style queries because it only
@@ -6,9 +6,9 @@
 // Model registration is performed via an init() function:
 //	func init() {
 // 		// Somewhere in your application you need to register all types to be used as models.
-// 		Models.Register(&Address{})
-// 		Models.Register(&Person{})
-// 		Models.Register(&PersonAddress{})
+// 		Models.MustRegister(&Address{})
+// 		Models.MustRegister(&Person{})
+// 		Models.MustRegister(&PersonAddress{})
 // 	}
 //
 // Also important is the struct definition for the type Address; the struct definition combined with the Models global
@@ -92,15 +92,66 @@ func ExampleModels_Register() {
 	//
 	// Model registration
 	//  + Models that embed model.TableName do not need to specify the tablename during registration.
-	Models.Register(StringModel{})
-	Models.Register(NumberModel{}, model.TableName("numbers"))
-	Models.Register(CompanyModel{}, model.TableName("companies"))
+	//  + Register returns an error describing every problem with the model; use MustRegister
+	//    to panic instead, which is convenient inside init().
+	if err := Models.Register(StringModel{}); err != nil {
+		fmt.Println(err)
+	}
+	if err := Models.Register(NumberModel{}, model.TableName("numbers")); err != nil {
+		fmt.Println(err)
+	}
+	Models.MustRegister(CompanyModel{}, model.TableName("companies"))
+
+	// Validate checks every registered model and is useful at application startup.
+	if err := Models.Validate(); err != nil {
+		fmt.Println(err)
+	}
 
 	fmt.Println("all done")
 
 	// Output: all done
 }
 
+func ExampleModels_DDL() {
+	// This example demonstrates creating tables for registered models; this is useful for
+	// bootstrapping test databases.
+
+	var Models *model.Models = &model.Models{
+		Mapper: &set.Mapper{
+			Join: "_",
+			Tags: []string{"db", "json"},
+		},
+		Grammar: grammar.Sqlite,
+	}
+
+	type Person struct {
+		model.TableName `json:"-" model:"people"`
+
+		Id       int       `json:"id" db:"pk" model:"key,auto"`
+		Email    string    `json:"email" model:"unique"`
+		Nickname *string   `json:"nickname"`
+		Created  time.Time `json:"created" model:"inserted"`
+	}
+	Models.MustRegister(Person{})
+
+	statements, err := Models.DDL()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, statement := range statements {
+		fmt.Println(statement)
+	}
+
+	// Output: CREATE TABLE people (
+	// 	pk INTEGER PRIMARY KEY AUTOINCREMENT,
+	// 	email TEXT NOT NULL,
+	// 	nickname TEXT,
+	// 	created DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	// 	UNIQUE ( email )
+	// )
+}
+
 func ExampleModels_Insert() {
 	var zero time.Time
 	//
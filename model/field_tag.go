@@ -0,0 +1,155 @@
+package model
+
+import (
+	"reflect"
+	"strings"
+)
+
+// fieldTag is the parsed value of a model struct tag on a struct field.
+//
+// A tag of "-" excludes the field from the model entirely; otherwise the tag is a comma
+// separated list of the following options:
+//
+//	key        The field is the primary key or part of a composite primary key.
+//	auto       The key field is populated by the database (i.e. auto incrementing).
+//	inserted   The field is populated by the database during INSERT.
+//	updated    The field is populated by the database during UPDATE.
+//	unique     The field is part of a unique index.
+//	readonly   The field is scanned but never written; the database computes its value.
+//	insertonly The field is written during INSERT but never during UPDATE.
+//	fk(t.c)    The field is a foreign key referencing column c of table t; t can be schema qualified.
+//	ondelete=x The ON DELETE action of the foreign key: cascade, setnull, setdefault, or restrict.
+//	redact     The field's value is sensitive and redacted from errors.
+//	client     The inserted or updated field is a timestamp set by Models instead of the database.
+//	generate=x The field is set by generator x of Models before INSERT if it is the zero value.
+type fieldTag struct {
+	Skip       bool
+	Key        bool
+	Auto       bool
+	Inserted   bool
+	Updated    bool
+	Unique     bool
+	ReadOnly   bool
+	InsertOnly bool
+	Redact     bool
+	Client     bool
+	// Generate is the name of the generator of the field.
+	Generate string
+	// RefTable and RefColumn are the table and column referenced by a foreign key.
+	RefTable  string
+	RefColumn string
+	// OnDelete is the ON DELETE action of the foreign key as SQL, i.e. SET NULL.
+	OnDelete string
+	// invalid describes an option that could not be parsed.
+	invalid string
+	// hasOnDelete is true if the tag has the ondelete option.
+	hasOnDelete bool
+}
+
+// onDeleteActions maps the ondelete tag values to SQL.
+var onDeleteActions = map[string]string{
+	"cascade":    "CASCADE",
+	"setnull":    "SET NULL",
+	"set_null":   "SET NULL",
+	"setdefault": "SET DEFAULT",
+	"restrict":   "RESTRICT",
+	"noaction":   "",
+}
+
+// parseFieldTag parses the struct tag value into a fieldTag.
+func parseFieldTag(tag string) fieldTag {
+	var rv fieldTag
+	if tag == "-" {
+		rv.Skip = true
+		return rv
+	}
+	for _, option := range strings.Split(tag, ",") {
+		option = strings.TrimSpace(option)
+		if strings.HasPrefix(option, "fk(") && strings.HasSuffix(option, ")") {
+			reference := option[len("fk(") : len(option)-1]
+			if n := strings.LastIndex(reference, "."); n > 0 && n < len(reference)-1 {
+				rv.RefTable, rv.RefColumn = reference[:n], reference[n+1:]
+			} else {
+				rv.invalid = option + " is not fk(table.column)"
+			}
+			continue
+		} else if strings.HasPrefix(option, "ondelete=") {
+			action, ok := onDeleteActions[strings.ToLower(strings.TrimPrefix(option, "ondelete="))]
+			if !ok {
+				rv.invalid = option + " is not cascade, setnull, setdefault, restrict, or noaction"
+			}
+			rv.OnDelete, rv.hasOnDelete = action, true
+			continue
+		} else if strings.HasPrefix(option, "generate=") {
+			if rv.Generate = strings.TrimPrefix(option, "generate="); rv.Generate == "" {
+				rv.invalid = option + " requires a generator name"
+			}
+			continue
+		}
+		switch option {
+		case "key":
+			rv.Key = true
+		case "auto":
+			rv.Auto = true
+		case "inserted":
+			rv.Inserted = true
+		case "updated":
+			rv.Updated = true
+		case "unique":
+			rv.Unique = true
+		case "readonly":
+			rv.ReadOnly = true
+		case "insertonly":
+			rv.InsertOnly = true
+		case "redact":
+			rv.Redact = true
+		case "client":
+			rv.Client = true
+		}
+	}
+	return rv
+}
+
+// Conflict returns a description of conflicting options in the tag or an empty string
+// if the options can be combined.
+func (me fieldTag) Conflict() string {
+	switch {
+	case me.invalid != "":
+		return me.invalid
+	case me.hasOnDelete && me.RefTable == "":
+		return "ondelete requires fk"
+	case me.Auto && !me.Key:
+		return "auto requires key"
+	case me.Key && (me.Inserted || me.Updated):
+		return "key can not be combined with inserted or updated"
+	case me.Key && me.ReadOnly:
+		return "key can not be combined with readonly"
+	case me.Key && me.InsertOnly:
+		return "key can not be combined with insertonly"
+	case me.ReadOnly && me.InsertOnly:
+		return "readonly can not be combined with insertonly"
+	case me.InsertOnly && (me.Inserted || me.Updated):
+		return "insertonly can not be combined with inserted or updated"
+	case me.Client && !me.Inserted && !me.Updated:
+		return "client requires inserted or updated"
+	case me.Generate != "" && (me.Auto || me.Inserted || me.Updated || me.ReadOnly):
+		return "generate can not be combined with auto, inserted, updated, or readonly"
+	}
+	return ""
+}
+
+// skippedParent returns true if a struct field containing the field at index in typ is tagged "-";
+// fields nested in excluded structs, such as related models, are excluded with their parent.
+func skippedParent(typ reflect.Type, index []int, tagName string) bool {
+	for k := 0; k < len(index)-1; k++ {
+		for typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		field := typ.Field(index[k])
+		if parseFieldTag(field.Tag.Get(tagName)).Skip {
+			return true
+		}
+		typ = field.Type
+	}
+	return false
+}
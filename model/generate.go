@@ -0,0 +1,142 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/nofeaturesonlybugs/errors"
+	"github.com/nofeaturesonlybugs/set/path"
+)
+
+// Generator returns a new value for a field tagged model:"generate=name"; now is the time of the
+// write as returned by Models.Clock.  The value must be assignable or convertible to the field.
+type Generator func(now time.Time) (interface{}, error)
+
+// DefaultGenerators are the generators available to every Models in addition to Models.Generators:
+//
+//	uuid     random UUID (version 4) as a string
+//	uuidv7   time ordered UUID (version 7) as a string
+var DefaultGenerators = map[string]Generator{
+	"uuid":   UUIDv4,
+	"uuidv7": UUIDv7,
+}
+
+// UUIDv4 is a Generator returning a random UUID as a string.
+func UUIDv4(now time.Time) (interface{}, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, errors.Go(err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return formatUUID(b), nil
+}
+
+// UUIDv7 is a Generator returning a UUID starting with the milliseconds of now as a string; UUIDs
+// generated at increasing times sort in the same order.
+func UUIDv7(now time.Time) (interface{}, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[6:]); err != nil {
+		return nil, errors.Go(err)
+	}
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(now.UnixNano()/int64(time.Millisecond)))
+	copy(b[:6], ms[2:])
+	b[6] = b[6]&0x0f | 0x70
+	b[8] = b[8]&0x3f | 0x80
+	return formatUUID(b), nil
+}
+
+// formatUUID returns b in the canonical 8-4-4-4-12 form.
+func formatUUID(b [16]byte) string {
+	s := hex.EncodeToString(b[:])
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
+
+// ClientField is a field Models sets before writing a model: a timestamp tagged client or a field
+// tagged generate=name.
+type ClientField struct {
+	Column string
+	Path   path.ReflectPath
+	// Insert and Update are true if the field is set before INSERT or UPDATE; both apply to UPSERT.
+	// UPSERT only sets insert only timestamps that are the zero value because an UPSERT updating an
+	// existing row does not write them.
+	Insert bool
+	Update bool
+	// Generator is the name of the generator; it is empty for timestamps.  Generated fields are only
+	// set when they are the zero value.
+	Generator string
+}
+
+// generator returns the generator called name from Generators or DefaultGenerators.
+func (me *Models) generator(name string) Generator {
+	if generator, ok := me.Generators[name]; ok {
+		return generator
+	}
+	return DefaultGenerators[name]
+}
+
+// now returns the time from Clock or time.Now.
+func (me *Models) now() time.Time {
+	if me.Clock != nil {
+		return me.Clock()
+	}
+	return time.Now()
+}
+
+// populate sets the ClientFields of model in value, a model or slice of models, before op.
+func (me *Models) populate(model *Model, value interface{}, op string) error {
+	if len(model.ClientFields) == 0 {
+		return nil
+	}
+	insert, update := op == "INSERT" || op == "UPSERT", op == "UPDATE" || op == "UPSERT"
+	elems, _, err := preloadParents(value)
+	if err != nil {
+		return errors.Go(err)
+	}
+	now := me.now()
+	for _, elem := range elems {
+		if !elem.CanSet() {
+			return errors.Go(ErrUnsupported).Tag(op, fmt.Sprintf("%T is not addressable; pass a pointer", value))
+		}
+		for _, field := range model.ClientFields {
+			if !(insert && field.Insert) && !(update && field.Update) {
+				continue
+			}
+			v := field.Path.Value(elem)
+			for ; v.Kind() == reflect.Ptr; v = v.Elem() {
+				if v.IsNil() {
+					v.Set(reflect.New(v.Type().Elem()))
+				}
+			}
+			if field.Generator == "" {
+				// An UPSERT updating an existing row does not write insert only timestamps so they
+				// are only set when zero.
+				if op != "UPSERT" || field.Update || v.IsZero() {
+					v.Set(reflect.ValueOf(now))
+				}
+				continue
+			} else if !v.IsZero() {
+				continue
+			}
+			generated, err := me.generator(field.Generator)(now)
+			if err != nil {
+				return errors.Go(err).Tag("generate", field.Generator).Tag("column", field.Column)
+			}
+			g := reflect.ValueOf(generated)
+			switch {
+			case g.IsValid() && g.Type().AssignableTo(v.Type()):
+				v.Set(g)
+			case g.IsValid() && g.Type().ConvertibleTo(v.Type()):
+				v.Set(g.Convert(v.Type()))
+			default:
+				return errors.Go(ErrUnsupported).Tag("generate", field.Generator).Tag("column", field.Column).Tag("value", fmt.Sprintf("%T", generated))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package model_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/nofeaturesonlybugs/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nofeaturesonlybugs/sqlh/model"
+	"github.com/nofeaturesonlybugs/sqlh/model/examples"
+)
+
+// Event has a generated key and client timestamps.
+type Event struct {
+	model.TableName `model:"events"`
+	Id              string     `json:"id" model:"key,generate=uuidv7"`
+	Name            string     `json:"name"`
+	Created         time.Time  `json:"created" model:"inserted,client"`
+	Modified        *time.Time `json:"modified" model:"inserted,updated,client"`
+}
+
+func TestModels_ClientFields(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mdb := examples.NewModels()
+	mdb.Clock = func() time.Time { return now }
+	chk.NoError(mdb.Register(Event{}))
+	m, err := mdb.Lookup(Event{})
+	chk.NoError(err)
+	chk.Len(m.ClientFields, 3)
+	chk.NotContains(m.Statements.Update.Arguments, "created")
+	chk.Contains(m.Statements.Update.Arguments, "modified")
+	//
+	// INSERT sets the key and both timestamps.
+	mock.ExpectExec("INSERT INTO events").WithArgs(sqlmock.AnyArg(), "a", now, now).WillReturnResult(sqlmock.NewResult(0, 1))
+	event := &Event{Name: "a"}
+	chk.NoError(mdb.Insert(db, event))
+	chk.Regexp(regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), event.Id)
+	chk.Equal(now, event.Created)
+	chk.Equal(now, *event.Modified)
+	id := event.Id
+	//
+	// UPDATE only sets the updated timestamp; keys are not generated again.
+	later := now.Add(time.Hour)
+	mdb.Clock = func() time.Time { return later }
+	mock.ExpectExec("UPDATE events").WithArgs("b", later, id).WillReturnResult(sqlmock.NewResult(0, 1))
+	event.Name = "b"
+	chk.NoError(mdb.Update(db, event))
+	chk.Equal(id, event.Id)
+	chk.Equal(now, event.Created)
+	chk.Equal(later, *event.Modified)
+	chk.NoError(mock.ExpectationsWereMet())
+	//
+	// UPSERT does not write inserted only timestamps of existing rows so they are only set when zero.
+	latest := later.Add(time.Hour)
+	mdb.Clock = func() time.Time { return latest }
+	mock.ExpectExec("INSERT INTO events").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO events").WillReturnResult(sqlmock.NewResult(0, 1))
+	chk.NoError(mdb.Upsert(db, event))
+	chk.Equal(now, event.Created)
+	chk.Equal(latest, *event.Modified)
+	upserted := &Event{Id: "c"}
+	chk.NoError(mdb.Upsert(db, upserted))
+	chk.Equal(latest, upserted.Created)
+	chk.NoError(mock.ExpectationsWereMet())
+	mdb.Clock = func() time.Time { return later }
+	//
+	// Fields tagged updated,client without inserted are also set by INSERT.
+	type Note struct {
+		model.TableName `model:"notes"`
+		Id              int       `json:"id" model:"key,auto"`
+		Modified        time.Time `json:"modified" model:"updated,client"`
+	}
+	chk.NoError(mdb.Register(Note{}))
+	mock.ExpectQuery("INSERT INTO notes").WithArgs(later).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	note := &Note{}
+	chk.NoError(mdb.Insert(db, note))
+	chk.Equal(later, note.Modified)
+	chk.NoError(mock.ExpectationsWereMet())
+	//
+	// Values must be addressable.
+	err = mdb.Insert(db, Event{})
+	chk.True(errors.Is(err, model.ErrUnsupported))
+}
+
+func TestModels_Generators(t *testing.T) {
+	chk := assert.New(t)
+	//
+	type Sequenced struct {
+		model.TableName `model:"sequenced"`
+		Id              int64  `json:"id" model:"key,generate=seq"`
+		Code            string `json:"code" model:"generate=uuid"`
+	}
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	mdb := examples.NewModels()
+	chk.True(errors.Is(mdb.Register(Sequenced{}), model.ErrUnknownGenerator))
+	//
+	next := 0
+	mdb.Generators = map[string]model.Generator{
+		"seq": func(time.Time) (interface{}, error) {
+			next++
+			return next, nil
+		},
+	}
+	chk.NoError(mdb.Register(Sequenced{}))
+	mock.ExpectBegin()
+	prepare := mock.ExpectPrepare("INSERT INTO sequenced")
+	prepare.ExpectExec().WithArgs(int64(1), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	prepare.ExpectExec().WithArgs(int64(10), "given").WillReturnResult(sqlmock.NewResult(0, 1))
+	prepare.ExpectExec().WithArgs(int64(2), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	values := []*Sequenced{{}, {Id: 10, Code: "given"}, {}}
+	chk.NoError(mdb.Insert(db, values))
+	chk.Equal(int64(2), values[2].Id)
+	chk.Len(values[0].Code, 36)
+	chk.NoError(mock.ExpectationsWereMet())
+	//
+	// Generators returning values that can not be assigned fail.
+	mdb.Generators["seq"] = func(time.Time) (interface{}, error) { return []byte("x"), nil }
+	chk.True(errors.Is(mdb.Insert(db, &Sequenced{}), model.ErrUnsupported))
+	mdb.Generators["seq"] = func(time.Time) (interface{}, error) { return nil, errors.Errorf("exhausted") }
+	chk.Error(mdb.Insert(db, &Sequenced{}))
+}
+
+func TestModels_ClientFieldsRegister(t *testing.T) {
+	chk := assert.New(t)
+	//
+	type NotTime struct {
+		model.TableName `model:"not_time"`
+		Id              int    `json:"id" model:"key,auto"`
+		Created         string `json:"created" model:"inserted,client"`
+	}
+	type NotTimestamp struct {
+		model.TableName `model:"not_timestamp"`
+		Id              int       `json:"id" model:"key,auto"`
+		Created         time.Time `json:"created" model:"client"`
+	}
+	type GeneratedAuto struct {
+		model.TableName `model:"generated_auto"`
+		Id              int `json:"id" model:"key,auto,generate=uuid"`
+	}
+	mdb := examples.NewModels()
+	for _, value := range []interface{}{NotTime{}, NotTimestamp{}, GeneratedAuto{}} {
+		chk.True(errors.Is(mdb.Register(value), model.ErrTagConflict), "%T", value)
+	}
+}
+
+func TestUUIDv7(t *testing.T) {
+	chk := assert.New(t)
+	//
+	now := time.Now()
+	first, err := model.UUIDv7(now)
+	chk.NoError(err)
+	second, err := model.UUIDv7(now.Add(time.Millisecond))
+	chk.NoError(err)
+	chk.Less(first.(string), second.(string))
+	v4, err := model.UUIDv4(now)
+	chk.NoError(err)
+	chk.Equal("4", v4.(string)[14:15])
+}
@@ -0,0 +1,394 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/nofeaturesonlybugs/errors"
+
+	"github.com/nofeaturesonlybugs/sqlh"
+	"github.com/nofeaturesonlybugs/sqlh/schema"
+)
+
+// GraphOption configures Models.SaveGraph.
+type GraphOption int
+
+const (
+	// DeleteOrphans deletes the rows of has-many relations and the join rows of many-to-many
+	// relations that reference a saved parent but are no longer among its related models.
+	DeleteOrphans GraphOption = iota + 1
+)
+
+// graphField is a struct field holding related models.
+type graphField struct {
+	index     []int
+	child     *Model
+	childType reflect.Type
+	rel       relation
+}
+
+// graphKey identifies a struct value saved by SaveGraph.
+type graphKey struct {
+	typ  reflect.Type
+	addr uintptr
+}
+
+// graphSaver saves model graphs for SaveGraph.
+type graphSaver struct {
+	models        *Models
+	deleteOrphans bool
+	saved         map[graphKey]bool
+}
+
+// SaveGraph saves value and the related models in its fields inside a single call to
+// sqlh.Transact.  value is a registered model or a slice of them as *T, []T, *[]T, or *[]*T.
+//
+// Relations are the fields that Preload can load: fields of type []R or []*R and fields of type
+// R or *R tagged model:"-", where R is a registered model related to T by foreign keys.  Related
+// models are saved in dependency order:
+//   - belongs-to models are saved first and their keys are copied into the parent's foreign key,
+//   - the parent is saved with Models.Save so generated keys are scanned via RETURNING,
+//   - has-many and has-one models receive the parent's key in their foreign key and are saved,
+//   - many-to-many models are saved and missing join rows are inserted.
+//
+// Related models are saved recursively; each struct value is saved once so relations pointing
+// back to a parent do not cause loops.  With DeleteOrphans the rows of has-many relations and
+// join rows of many-to-many relations that are no longer related to a parent are deleted; this
+// requires related models with a single column primary key.
+func (me *Models) SaveGraph(Q sqlh.IQueries, value interface{}, opts ...GraphOption) error {
+	if me == nil {
+		return errors.NilReceiver()
+	} else if Q == nil {
+		return errors.NilArgument("Q")
+	} else if value == nil {
+		return errors.NilArgument("value")
+	}
+	values, typ, err := preloadParents(value)
+	if err != nil {
+		return errors.Go(err)
+	}
+	saver := &graphSaver{models: me, saved: map[graphKey]bool{}}
+	for _, opt := range opts {
+		saver.deleteOrphans = saver.deleteOrphans || opt == DeleteOrphans
+	}
+	return sqlh.Transact(me.queries(Q, "", nil, nil), func(Q sqlh.IQueries) error {
+		return saver.save(Q, values, typ)
+	})
+}
+
+// save saves values of type typ and their related models.
+func (me *graphSaver) save(Q sqlh.IQueries, values []reflect.Value, typ reflect.Type) error {
+	model, err := me.models.lookupStruct(typ)
+	if err != nil {
+		return errors.Go(err)
+	}
+	fields, err := me.fields(model, typ)
+	if err != nil {
+		return errors.Go(err)
+	}
+	var pending []reflect.Value
+	for _, value := range values {
+		key := graphKey{typ: typ, addr: value.Addr().Pointer()}
+		if !me.saved[key] {
+			me.saved[key] = true
+			pending = append(pending, value)
+		}
+	}
+	//
+	// Belongs-to models are saved before the models referencing them.
+	for _, field := range fields {
+		if field.rel.kind != belongsTo {
+			continue
+		}
+		var related []reflect.Value
+		for _, value := range pending {
+			related = append(related, relatedValues(value.FieldByIndex(field.index))...)
+		}
+		if err = me.save(Q, related, field.childType); err != nil {
+			return errors.Go(err)
+		}
+		for _, value := range pending {
+			for _, parent := range relatedValues(value.FieldByIndex(field.index)) {
+				if err = copyColumn(field.child, field.rel.childColumn, parent, model, field.rel.parentColumn, value); err != nil {
+					return errors.Go(err)
+				}
+			}
+		}
+	}
+	for _, value := range pending {
+		if err = me.models.Save(Q, value.Addr().Interface()); err != nil {
+			return errors.Go(err)
+		}
+	}
+	//
+	// Has-many and many-to-many models are saved after the models they reference.
+	for _, field := range fields {
+		if field.rel.kind == belongsTo {
+			continue
+		}
+		for _, value := range pending {
+			children := relatedValues(value.FieldByIndex(field.index))
+			if field.rel.kind == hasMany {
+				for _, child := range children {
+					if err = copyColumn(model, field.rel.parentColumn, value, field.child, field.rel.childColumn, child); err != nil {
+						return errors.Go(err)
+					}
+				}
+			}
+			if err = me.save(Q, children, field.childType); err != nil {
+				return errors.Go(err)
+			}
+			if field.rel.kind == manyToMany {
+				err = me.saveJoins(Q, model, value, field, children)
+			} else if me.deleteOrphans {
+				err = me.deleteOrphanRows(Q, field.child, field.rel.childColumn, model, field.rel.parentColumn, value, children)
+			}
+			if err != nil {
+				return errors.Go(err)
+			}
+		}
+	}
+	return nil
+}
+
+// fields returns the fields of typ holding related models.
+func (me *graphSaver) fields(model *Model, typ reflect.Type) ([]graphField, error) {
+	tagName := me.models.StructTag
+	if tagName == "" {
+		tagName = "model"
+	}
+	registered := me.models.models()
+	var rv []graphField
+	for k, size := 0, typ.NumField(); k < size; k++ {
+		field := typ.Field(k)
+		if field.PkgPath != "" || field.Anonymous {
+			continue
+		}
+		childType, many := relatedType(field.Type)
+		child, ok := registered[childType]
+		if !ok {
+			child, ok = registered[reflect.PtrTo(childType)]
+		}
+		if !ok || (!many && !parseFieldTag(field.Tag.Get(tagName)).Skip) {
+			// Single structs not tagged model:"-" are columns of the parent.
+			continue
+		}
+		rel, err := me.models.relation(model, child, many)
+		if err != nil {
+			return nil, errors.Go(err).Tag("type", typ.String()).Tag("relation", field.Name)
+		}
+		rv = append(rv, graphField{index: field.Index, child: child, childType: childType, rel: rel})
+	}
+	return rv, nil
+}
+
+// saveJoins inserts the missing join rows between parent and children and deletes join rows to
+// other models when deleting orphans.
+func (me *graphSaver) saveJoins(Q sqlh.IQueries, model *Model, parent reflect.Value, field graphField, children []reflect.Value) error {
+	rel := field.rel
+	key, err := columnValue(model, rel.parentColumn, parent)
+	if err != nil {
+		return errors.Go(err)
+	}
+	existing, err := me.models.preloadQuery(Q, rel.join, rel.joinType, rel.joinParent, []interface{}{key.Interface()})
+	if err != nil {
+		return errors.Go(err)
+	}
+	joinValues := make([]reflect.Value, existing.Len())
+	for k := range joinValues {
+		joinValues[k] = existing.Index(k)
+	}
+	joined, _, err := columnKeys(rel.join, rel.joinChild, joinValues)
+	if err != nil {
+		return errors.Go(err)
+	}
+	found := map[string]bool{}
+	for _, childKey := range joined {
+		found[childKey] = true
+	}
+	childKeys, childArgs, err := columnKeys(field.child, rel.childColumn, children)
+	if err != nil {
+		return errors.Go(err)
+	}
+	for k, child := range children {
+		if childKeys[k] == "" || found[childKeys[k]] {
+			continue
+		}
+		found[childKeys[k]] = true
+		join := reflect.New(rel.joinType).Elem()
+		if err = copyColumn(model, rel.parentColumn, parent, rel.join, rel.joinParent, join); err != nil {
+			return errors.Go(err)
+		} else if err = copyColumn(field.child, rel.childColumn, child, rel.join, rel.joinChild, join); err != nil {
+			return errors.Go(err)
+		} else if err = me.models.Insert(Q, join.Addr().Interface()); err != nil {
+			return errors.Go(err)
+		}
+	}
+	if !me.deleteOrphans {
+		return nil
+	}
+	return me.deleteRows(Q, rel.join, rel.joinParent, key.Interface(), rel.joinChild, childArgs)
+}
+
+// deleteOrphanRows deletes the rows of child's table that reference parent but are not children.
+func (me *graphSaver) deleteOrphanRows(Q sqlh.IQueries, child *Model, childColumn string, model *Model, parentColumn string, parent reflect.Value, children []reflect.Value) error {
+	if len(child.Table.PrimaryKey.Columns) != 1 {
+		return errors.Go(ErrUnsupported).Tag("table", child.Table.QualifiedName()).Tag("reason", "deleting orphans requires a single column primary key")
+	}
+	key, err := columnValue(model, parentColumn, parent)
+	if err != nil {
+		return errors.Go(err)
+	}
+	_, keep, err := columnKeys(child, child.Table.PrimaryKey.Columns[0].Name, children)
+	if err != nil {
+		return errors.Go(err)
+	}
+	return me.deleteRows(Q, child, childColumn, key.Interface(), child.Table.PrimaryKey.Columns[0].Name, keep)
+}
+
+// deleteRows deletes the rows of model's table where column equals key and keepColumn is not
+// one of keep.  If keep exceeds the parameter limit of the grammar the rows to delete are selected
+// first and deleted in batches.
+func (me *graphSaver) deleteRows(Q sqlh.IQueries, model *Model, column string, key interface{}, keepColumn string, keep []interface{}) error {
+	g, limit := me.models.grammar(), me.models.parameterLimit()
+	query := "DELETE FROM " + g.Quote(model.Table.QualifiedName()) + " WHERE " + g.Quote(column) + " = " + param(g, 0)
+	if len(keep) < limit {
+		if len(keep) > 0 {
+			params := make([]string, len(keep))
+			for k := range keep {
+				params[k] = param(g, k+1)
+			}
+			query = query + " AND " + g.Quote(keepColumn) + " NOT IN ( " + strings.Join(params, ", ") + " )"
+		}
+		if _, err := me.models.queries(Q, "DELETE", model, nil).Exec(query, append([]interface{}{key}, keep...)...); err != nil {
+			return errors.Go(err).Tag("SQL", query)
+		}
+		return nil
+	}
+	remove, err := me.removedKeys(Q, model, column, key, keepColumn, keep)
+	if err != nil {
+		return errors.Go(err)
+	}
+	Q = me.models.queries(Q, "DELETE", model, nil)
+	for start, batch := 0, limit-1; start < len(remove); start += batch {
+		end := start + batch
+		if end > len(remove) {
+			end = len(remove)
+		}
+		params := make([]string, end-start)
+		for k := range params {
+			params[k] = param(g, k+1)
+		}
+		in := query + " AND " + g.Quote(keepColumn) + " IN ( " + strings.Join(params, ", ") + " )"
+		if _, err = Q.Exec(in, append([]interface{}{key}, remove[start:end]...)...); err != nil {
+			return errors.Go(err).Tag("SQL", in)
+		}
+	}
+	return nil
+}
+
+// removedKeys returns the values of keepColumn in the rows of model's table where column equals key
+// and keepColumn is not one of keep.
+func (me *graphSaver) removedKeys(Q sqlh.IQueries, model *Model, column string, key interface{}, keepColumn string, keep []interface{}) ([]interface{}, error) {
+	var typ reflect.Type
+	for _, c := range append(append([]schema.Column{}, model.Table.PrimaryKey.Columns...), model.Table.Columns...) {
+		if c.Name == keepColumn {
+			typ = reflect.TypeOf(c.GoType)
+		}
+	}
+	if typ == nil {
+		return nil, errors.Go(ErrUnknownRelation).Tag("reason", "column "+keepColumn+" is not a column of "+model.Table.QualifiedName())
+	}
+	kept := make(map[string]bool, len(keep))
+	for _, value := range keep {
+		kept[fmt.Sprint(value)] = true
+	}
+	g := me.models.grammar()
+	query := "SELECT " + g.Quote(keepColumn) + " FROM " + g.Quote(model.Table.QualifiedName()) + " WHERE " + g.Quote(column) + " = " + param(g, 0)
+	rows, err := me.models.queries(Q, "SELECT", model, nil).Query(query, key)
+	if err != nil {
+		return nil, errors.Go(err).Tag("SQL", query)
+	}
+	defer rows.Close()
+	var rv []interface{}
+	for rows.Next() {
+		dest := reflect.New(typ)
+		if err = rows.Scan(dest.Interface()); err != nil {
+			return nil, errors.Go(err).Tag("SQL", query)
+		}
+		value := dest.Elem()
+		for value.Kind() == reflect.Ptr && !value.IsNil() {
+			value = value.Elem()
+		}
+		if value.Kind() != reflect.Ptr && !kept[fmt.Sprint(value.Interface())] {
+			rv = append(rv, value.Interface())
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Go(err).Tag("SQL", query)
+	}
+	return rv, nil
+}
+
+// relatedValues returns the addressable struct values held by a relation field; nil pointers and
+// zero structs are skipped.
+func relatedValues(field reflect.Value) []reflect.Value {
+	var rv []reflect.Value
+	add := func(v reflect.Value) {
+		for v.Kind() == reflect.Ptr && !v.IsNil() {
+			v = v.Elem()
+		}
+		if v.Kind() == reflect.Struct && !v.IsZero() {
+			rv = append(rv, v)
+		}
+	}
+	if field.Kind() == reflect.Slice {
+		for k, size := 0, field.Len(); k < size; k++ {
+			add(field.Index(k))
+		}
+	} else {
+		add(field)
+	}
+	return rv
+}
+
+// columnValue returns the non-zero value of column in value.
+func columnValue(model *Model, column string, value reflect.Value) (reflect.Value, error) {
+	path, ok := model.Mapping.ReflectPaths[column]
+	if !ok {
+		return reflect.Value{}, errors.Go(ErrUnknownRelation).Tag("reason", "column "+column+" is not mapped")
+	}
+	rv := path.Value(value)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() == reflect.Ptr || rv.IsZero() {
+		return reflect.Value{}, errors.Go(ErrUnsupported).Tag("table", model.Table.QualifiedName()).Tag("column", column).Tag("reason", "key is zero after save")
+	}
+	return rv, nil
+}
+
+// copyColumn copies column from of source into column to of target.
+func copyColumn(sourceModel *Model, from string, source reflect.Value, targetModel *Model, to string, target reflect.Value) error {
+	value, err := columnValue(sourceModel, from, source)
+	if err != nil {
+		return errors.Go(err)
+	}
+	path, ok := targetModel.Mapping.ReflectPaths[to]
+	if !ok {
+		return errors.Go(ErrUnknownRelation).Tag("reason", "column "+to+" is not mapped")
+	}
+	field := path.Value(target)
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		field = field.Elem()
+	}
+	if !value.Type().ConvertibleTo(field.Type()) {
+		return errors.Go(ErrUnsupported).Tag("column", to).Tag("reason", fmt.Sprintf("can not assign %v to %v", value.Type(), field.Type()))
+	}
+	field.Set(value.Convert(field.Type()))
+	return nil
+}
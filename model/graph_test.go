@@ -0,0 +1,153 @@
+package model_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/nofeaturesonlybugs/errors"
+	"github.com/nofeaturesonlybugs/set"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nofeaturesonlybugs/sqlh/grammar"
+	"github.com/nofeaturesonlybugs/sqlh/model"
+	"github.com/nofeaturesonlybugs/sqlh/model/examples"
+)
+
+func TestModels_SaveGraph(t *testing.T) {
+	chk := assert.New(t)
+	//
+	type Customer struct {
+		model.TableName `model:"customers"`
+		Id              int    `db:"pk" model:"key,auto"`
+		Name            string `db:"name"`
+	}
+	type Line struct {
+		model.TableName `model:"order_lines"`
+		Id              int    `db:"pk" model:"key,auto"`
+		OrderId         int    `db:"order_fk" model:"fk(orders.pk),ondelete=cascade"`
+		Sku             string `db:"sku"`
+	}
+	type Order struct {
+		model.TableName `model:"orders"`
+		Id              int       `db:"pk" model:"key,auto"`
+		CustomerId      *int      `db:"customer_fk" model:"fk(customers.pk)"`
+		Customer        *Customer `db:"customer" model:"-"`
+		Lines           []*Line   `db:"lines"`
+	}
+	mdb := &model.Models{
+		Mapper:  &set.Mapper{Join: "_", Tags: []string{"db"}},
+		Grammar: grammar.Sqlite,
+	}
+	mdb.MustRegister(Customer{})
+	mdb.MustRegister(Line{})
+	mdb.MustRegister(Order{})
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	{
+		// New parents are inserted first and their keys propagate to children.
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO customers")).WithArgs("Bob").
+			WillReturnRows(sqlmock.NewRows([]string{"pk"}).AddRow(5))
+		mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO orders")).WithArgs(5).
+			WillReturnRows(sqlmock.NewRows([]string{"pk"}).AddRow(10))
+		mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO order_lines")).WithArgs(10, "a").
+			WillReturnRows(sqlmock.NewRows([]string{"pk"}).AddRow(100))
+		mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO order_lines")).WithArgs(10, "b").
+			WillReturnRows(sqlmock.NewRows([]string{"pk"}).AddRow(101))
+		mock.ExpectCommit()
+		order := Order{Customer: &Customer{Name: "Bob"}, Lines: []*Line{{Sku: "a"}, {Sku: "b"}}}
+		chk.NoError(mdb.SaveGraph(db, &order))
+		chk.NoError(mock.ExpectationsWereMet())
+		chk.Equal(10, order.Id)
+		if chk.NotNil(order.CustomerId) {
+			chk.Equal(5, *order.CustomerId)
+		}
+		chk.Equal(100, order.Lines[0].Id)
+		chk.Equal(10, order.Lines[1].OrderId)
+	}
+	{
+		// Existing models are updated and orphans are deleted.
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE orders SET")).WithArgs(nil, 10).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE order_lines SET")).WithArgs(10, "a", 100).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM order_lines WHERE order_fk = ? AND pk NOT IN ( ? )")).WithArgs(10, 100).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		orders := []Order{{Id: 10, Lines: []*Line{{Id: 100, Sku: "a"}}}}
+		chk.NoError(mdb.SaveGraph(db, orders, model.DeleteOrphans))
+		chk.NoError(mock.ExpectationsWereMet())
+	}
+	{
+		// Children beyond the parameter limit select the orphans and delete them in batches.
+		lines := make([]*Line, 1000)
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE orders SET")).WillReturnResult(sqlmock.NewResult(0, 1))
+		for k := range lines {
+			lines[k] = &Line{Id: k + 1, Sku: "a"}
+			mock.ExpectExec(regexp.QuoteMeta("UPDATE order_lines SET")).WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT pk FROM order_lines WHERE order_fk = ?")).WithArgs(10).
+			WillReturnRows(sqlmock.NewRows([]string{"pk"}).AddRow(1).AddRow(1001).AddRow(1002))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM order_lines WHERE order_fk = ? AND pk IN ( ?, ? )")).WithArgs(10, 1001, 1002).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+		chk.NoError(mdb.SaveGraph(db, &Order{Id: 10, Lines: lines}, model.DeleteOrphans))
+		chk.NoError(mock.ExpectationsWereMet())
+	}
+	{
+		// Without children every row referencing the parent is an orphan.
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE orders SET")).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM order_lines WHERE order_fk = ?")).WithArgs(10).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+		chk.NoError(mdb.SaveGraph(db, &Order{Id: 10}, model.DeleteOrphans))
+		chk.NoError(mock.ExpectationsWereMet())
+	}
+	{
+		// Errors roll back the transaction.
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO orders")).WillReturnRows(sqlmock.NewRows([]string{"pk"}).AddRow(11))
+		mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO order_lines")).WillReturnError(errors.Errorf("constraint failed"))
+		mock.ExpectRollback()
+		chk.Error(mdb.SaveGraph(db, &Order{Lines: []*Line{{Sku: "a"}}}))
+		chk.NoError(mock.ExpectationsWereMet())
+	}
+	//
+	chk.Error(mdb.SaveGraph(nil, &Order{}))
+	chk.Error(mdb.SaveGraph(db, nil))
+	chk.Equal(model.ErrUnsupported, errors.Original(mdb.SaveGraph(db, Order{})))
+	var nilModels *model.Models
+	chk.Error(nilModels.SaveGraph(db, &Order{}))
+}
+
+func TestModels_SaveGraphManyToMany(t *testing.T) {
+	chk := assert.New(t)
+	//
+	mdb := examples.NewModels()
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	now := time.Now()
+	//
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO people")).
+		WillReturnRows(sqlmock.NewRows([]string{"pk", "created_tmz", "modified_tmz"}).AddRow(1, now, now))
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE addresses SET")).
+		WillReturnRows(sqlmock.NewRows([]string{"modified_tmz"}).AddRow(now))
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO addresses")).
+		WillReturnRows(sqlmock.NewRows([]string{"pk", "created_tmz", "modified_tmz"}).AddRow(8, now, now))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT person_fk, address_fk FROM relate_people_addresses WHERE person_fk IN ( $1 )")).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"person_fk", "address_fk"}).AddRow(1, 7).AddRow(1, 6))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO relate_people_addresses")).WithArgs(1, 8).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM relate_people_addresses WHERE person_fk = $1 AND address_fk NOT IN ( $2, $3 )")).
+		WithArgs(1, 7, 8).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	person := examples.Person{First: "Bob", Addresses: []examples.Address{{Id: 7, Street: "Main"}, {Street: "Elm"}}}
+	chk.NoError(mdb.SaveGraph(db, &person, model.DeleteOrphans))
+	chk.NoError(mock.ExpectationsWereMet())
+	chk.Equal(1, person.Id)
+	chk.Equal(8, person.Addresses[1].Id)
+}
@@ -25,8 +25,20 @@ type Model struct {
 	SaveMode          SaveMode
 	InsertUpdatePaths []path.ReflectPath
 
+	// SaveStrategy, if not nil, decides how Models.Save writes each element; it is the SaveStrategy
+	// passed to Register or, for types implementing InsertUpdater, their IsNew method.
+	SaveStrategy SaveStrategy
+
 	// Mapping is the column to struct field mapping.
 	Mapping set.Mapping
+
+	// Redacted are the columns tagged redact; their values are replaced with RedactedValue in
+	// ElementError.Args.
+	Redacted map[string]bool
+
+	// ClientFields are the fields set by Models before writes: timestamps tagged client and
+	// fields tagged generate=name.
+	ClientFields []ClientField
 }
 
 // BindQuery returns a QueryBinding that facilitates running queries against
@@ -4,12 +4,16 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/nofeaturesonlybugs/errors"
 	"github.com/nofeaturesonlybugs/set"
 	"github.com/nofeaturesonlybugs/set/path"
 	"github.com/nofeaturesonlybugs/sqlh"
 	"github.com/nofeaturesonlybugs/sqlh/grammar"
+	"github.com/nofeaturesonlybugs/sqlh/internal/types"
 	"github.com/nofeaturesonlybugs/sqlh/model/statements"
 	"github.com/nofeaturesonlybugs/sqlh/schema"
 )
@@ -23,32 +27,141 @@ type Models struct {
 	// Grammar defines the SQL grammar to use for SQL generation.
 	Grammar grammar.Grammar
 	//
-	// Models is a map of Go types to Model instances.  This member is automatically
-	// instantiated during calls to Register().
-	Models map[reflect.Type]*Model
-	//
 	// StructTag specifies the struct tag name to use when inspecting types
 	// during register.  If not set will default to "model".
 	StructTag string
+	//
+	// Quoting overrides the identifier quoting of Grammar when not grammar.QuoteDefault.
+	Quoting grammar.Quoting
+	//
+	// TableNaming is the naming strategy used during Register when a type does not embed TableName,
+	// does not implement TableNamer, and no TableName is passed to Register.  If nil then such types
+	// can not be registered.
+	TableNaming TableNaming
+	//
+	// AutoRegister enables registering types during Lookup.  When true a type that is not
+	// registered is registered on first use if it embeds TableName or implements TableNamer.
+	AutoRegister bool
+	//
+	// Observer, if not nil, is notified of the queries run by methods of Models; see sqlh.Observe.
+	Observer sqlh.Observer
+	//
+	// Clock returns the time written to fields tagged client and passed to generators; if nil
+	// then time.Now is used.
+	Clock func() time.Time
+	//
+	// Generators are the generators available to fields tagged generate=name in addition to
+	// DefaultGenerators; generators are looked up during Register.
+	Generators map[string]Generator
+
+	// mu serializes calls to Register.
+	mu sync.Mutex
+	// registry is a map[reflect.Type]*Model of registered models.  The map is never modified
+	// once stored; Register stores a modified copy so Lookup can read without locking.
+	registry atomic.Value
+}
+
+// models returns the map of registered models; the returned map must not be modified.
+func (me *Models) models() map[reflect.Type]*Model {
+	if rv, ok := me.registry.Load().(map[reflect.Type]*Model); ok {
+		return rv
+	}
+	return nil
+}
+
+// queries returns Q observed by Observer, or by the observer of Q if Observer is nil, with the context
+// of Q describing the operation op on model; typ, if not nil, is the type of the value given to the operation.
+// If model is nil the context does not describe an operation.
+func (me *Models) queries(Q sqlh.IQueries, op string, model *Model, typ reflect.Type) sqlh.IQueries {
+	observer := me.Observer
+	if observer == nil {
+		observer = sqlh.ObserverOf(Q)
+	}
+	if observer == nil {
+		return Q
+	} else if model == nil {
+		return sqlh.Observe(Q, observer)
+	}
+	operation := sqlh.Operation{Name: op, Table: model.Table.QualifiedName()}
+	if typ != nil {
+		if typ = derefType(typ); typ.Kind() == reflect.Slice {
+			typ = derefType(typ.Elem())
+		}
+		operation.Model = typ.String()
+	}
+	ctx := sqlh.WithOperation(sqlh.ContextOf(Q), operation)
+	return sqlh.ObserveContext(ctx, Q, observer)
+}
+
+// classifier returns Grammar if it classifies errors; otherwise nil.
+func (me *Models) classifier() sqlh.ErrorClassifier {
+	classifier, _ := me.Grammar.(sqlh.ErrorClassifier)
+	return classifier
+}
+
+// bind returns the QueryBinding of query for model; op is the operation counted by Result.  UPDATE
+// and DELETE bindings return sqlh.ErrNotFound for rows that do not exist.
+func (me *Models) bind(model *Model, query *statements.Query, op string) QueryBinding {
+	rv := model.BindQuery(me.Mapper, query).WithClassifier(me.classifier())
+	rv.op, rv.checkFound = op, op == "UPDATE" || op == "DELETE"
+	return rv
+}
+
+// grammar returns Grammar with Quoting applied.
+func (me *Models) grammar() grammar.Grammar {
+	if me.Quoting != grammar.QuoteDefault {
+		return me.Grammar.WithQuoting(me.Quoting)
+	}
+	return me.Grammar
 }
 
 // Register adds a Go type to the Models instance.
 //
-// Register is not goroutine safe; implement locking in the store or application level if required.
+// opts may contain a TableName and a SaveStrategy; see Save.
+//
+// The table name is the first of: a TableName passed in opts, the struct tag of an embedded TableName
+// field, the return value of TableName() if the type implements TableNamer, or the name returned from
+// the TableNaming strategy.
+//
+// If the model can not be registered an error is returned describing every problem found with
+// the model; the error is of type Errors when more than one problem is found.
+//
+// Register and Lookup are goroutine safe.
 //
 // When Register is called with a type T the following registrations are made:
 //	T, *T, []T, & []*T
 //
 // As a convenience register can be called with a reflect.Type as the value.
-func (me *Models) Register(value interface{}, opts ...interface{}) {
+//
+// Fields are classified by their struct tag (see StructTag):
+//	key, key,auto       primary key fields
+//	inserted, updated   populated by the database during INSERT and/or UPDATE
+//	unique              part of a unique index
+//	readonly            computed by the database; never written, scanned after writes
+//	insertonly          written during INSERT; never written during UPDATE
+//	fk(table.column)    foreign key; combine with ondelete=cascade|setnull|setdefault|restrict
+//	redact              sensitive; values are redacted from errors
+//	inserted,client     timestamp set from Clock before INSERT; UPSERT sets it only when zero
+//	updated,client      timestamp set from Clock before INSERT and UPDATE
+//	generate=name       set by the generator name before INSERT when zero; see Generators
+//	-                   excluded from the model entirely
+func (me *Models) Register(value interface{}, opts ...interface{}) error {
+	if me == nil {
+		return errors.NilReceiver()
+	} else if me.Mapper == nil {
+		return errors.NilMember("Mapper").Type(me.Mapper)
+	} else if me.Grammar == nil {
+		return errors.NilMember("Grammar").Type(me.Grammar)
+	}
 	tagName := me.StructTag
 	if tagName == "" {
 		tagName = "model"
 	}
+	g := me.grammar()
 	//
-	if me.Models == nil {
-		me.Models = make(map[reflect.Type]*Model)
-	}
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	registered := me.models()
 	//
 	var typ reflect.Type
 	var typInfo set.TypeInfo
@@ -60,17 +173,31 @@ func (me *Models) Register(value interface{}, opts ...interface{}) {
 		typ = reflect.TypeOf(value)
 		typInfo = set.TypeCache.Stat(value) // Consider creating a local type cache to the Models type.
 	}
-	if _, ok := me.Models[typ]; ok {
-		return // Already registered.
+	if typ == nil {
+		return errors.NilArgument("value")
+	} else if _, ok := registered[typ]; ok {
+		return nil // Already registered.
 	}
 	//
+	// problems collects every problem with the model so they can be reported together.
+	var problems Errors
+	//
 	// Get the table name from embedded TableName field.
 	var tableName string
+	var saveStrategy SaveStrategy
 	for _, opt := range opts {
-		if tn, ok := opt.(TableName); ok {
-			tableName = string(tn)
+		switch opt := opt.(type) {
+		case TableName:
+			tableName = string(opt)
+		case SaveStrategy:
+			saveStrategy = opt
+		case func(elem interface{}) SaveMode:
+			saveStrategy = opt
 		}
 	}
+	if saveStrategy == nil && (typ.Implements(typeInsertUpdater) || reflect.PtrTo(typ).Implements(typeInsertUpdater)) {
+		saveStrategy = insertUpdaterStrategy
+	}
 	if tableName == "" {
 		for _, field := range typInfo.StructFields {
 			if field.Type == typeTableName {
@@ -80,16 +207,31 @@ func (me *Models) Register(value interface{}, opts ...interface{}) {
 		}
 	}
 	if tableName == "" {
-		panic("no table name; call Register with a TableName value or embed TableName into your struct")
+		tableName = tableNamerName(typ)
+	}
+	if tableName == "" && me.TableNaming != nil {
+		structType := typ
+		for structType.Kind() == reflect.Ptr {
+			structType = structType.Elem()
+		}
+		tableName = me.TableNaming(structType)
+	}
+	if tableName == "" {
+		problems = append(problems, errors.Go(ErrTableNameRequired).Tag("type", typ.String()).Tag("hint", "call Register with a TableName value, embed TableName, implement TableNamer, or set TableNaming"))
 	}
 	//
 	// Now map the columns.
 	mapping := me.Mapper.Map(value)
+	for _, name := range unmappedKeys(typ, tagName, mapping) {
+		problems = append(problems, errors.Go(ErrUnmappedKey).Tag("type", typ.String()).Tag("field", name))
+	}
 	//
 	// key is the Columns for the table's primary key.
 	// unique is the slice of unique indexes on the table.
 	// columns are the non-primary key columns and includes columns in unique.
+	// foreignKeys are the foreign keys declared with fk(table.column).
 	key, unique, columns := []schema.Column{}, []schema.Index{}, []schema.Column{}
+	var foreignKeys []schema.ForeignKey
 	//
 	// The following slices keep track of column names in the database.
 	//	autoKeyNames, keyNames
@@ -98,50 +240,104 @@ func (me *Models) Register(value interface{}, opts ...interface{}) {
 	//		+ autoKeyNames are columns automatically populated by the database such as auto incrementing integer keys.
 	//	autoInsertNames, autoUpdateNames, autoInsertUpdateNames
 	//		+ Columns automatically populated by the database such as created or modified timestamps.
+	//		+ readonly columns are in all three so they are scanned after every write.
 	//		+ autoInsertUpdateNames is UNIQUE( UNION( autoInsertNames, autoUpdateNames ) ).
 	//	columnNames
 	//		+ All other column names that need to be explicitly set during insert/update operations.
+	//	insertOnlyNames
+	//		+ Column names that are set during insert operations but never during updates.
 	//
 	// NB: auto* columns are not currently limited to any specific type.
 	autoKeyNames, autoInsertNames, autoUpdateNames, autoInsertUpdateNames, keyNames, columnNames := []string{}, []string{}, []string{}, []string{}, []string{}, []string{}
+	insertOnlyNames, redacted := []string{}, map[string]bool{}
+	var clientFields []ClientField
 	for _, name := range mapping.Keys {
 		field := mapping.StructFields[name]
 		if field.Type == typeTableName {
 			// Leave as empty case to ensure embedded TableName is not used for column information.
+		} else if skippedParent(typ, mapping.Indeces[name], tagName) {
+			// Leave as empty case; the field is nested in a struct excluded with tag=-.
 		} else {
+			// Get the struct field tag and then classify the column accordingly.
+			tag := parseFieldTag(field.Tag.Get(tagName))
+			if tag.Skip {
+				// tag=- excludes the field from the model entirely.
+				continue
+			} else if conflict := tag.Conflict(); conflict != "" {
+				problems = append(problems, errors.Go(ErrTagConflict).Tag("type", typ.String()).Tag("field", field.Name).Tag("conflict", conflict))
+				continue
+			}
 			// Create the Column type.
 			column := schema.Column{
-				Name:   name,
-				GoType: reflect.Zero(field.Type).Interface(),
-				// TODO SqlType
+				Name:     name,
+				GoType:   reflect.Zero(field.Type).Interface(),
+				Nullable: nullableType(field.Type),
+				Auto:     tag.Auto || tag.Inserted || tag.Updated,
 			}
-			// Get the struct field tag and then classify the column accordingly.
-			tag := field.Tag.Get(tagName)
-			if tag == "key" || strings.HasPrefix(tag, "key,") {
+			if typer, ok := g.(grammar.SqlTyper); ok {
+				column.SqlType = typer.SqlType(column.GoType)
+			}
+			if tag.Key {
 				// tag=key or tag=key,auto is a primary key field.
 				key = append(key, column)
-				if strings.Contains(tag, ",auto") {
+				if tag.Auto {
 					autoKeyNames = append(autoKeyNames, name)
 				} else {
 					keyNames = append(keyNames, name)
 				}
-			} else if insert, update := strings.Contains(tag, "inserted"), strings.Contains(tag, "updated"); insert || update {
+			} else if tag.Client {
+				// inserted,client and updated,client are timestamps set by Models; inserted only timestamps
+				// are never updated.  Updated timestamps are written by INSERT so they are also set then.
+				if fieldType := derefType(field.Type); fieldType != types.Time {
+					problems = append(problems, errors.Go(ErrTagConflict).Tag("type", typ.String()).Tag("field", field.Name).Tag("conflict", "client requires time.Time"))
+					continue
+				}
+				clientFields = append(clientFields, ClientField{Column: name, Path: mapping.ReflectPaths[name], Insert: tag.Inserted || tag.Updated, Update: tag.Updated})
+				columns = append(columns, column)
+				if tag.Updated {
+					columnNames = append(columnNames, name)
+				} else {
+					insertOnlyNames = append(insertOnlyNames, name)
+				}
+			} else if tag.Inserted || tag.Updated {
 				// inserted or updated signals the column is populated on insert or update statements respectively.
-				if insert {
+				if tag.Inserted {
 					autoInsertNames = append(autoInsertNames, name)
 				}
-				if update {
+				if tag.Updated {
 					autoUpdateNames = append(autoUpdateNames, name)
 				}
-				if insert || update {
-					autoInsertUpdateNames = append(autoInsertUpdateNames, name)
-				}
+				autoInsertUpdateNames = append(autoInsertUpdateNames, name)
+				columns = append(columns, column)
+			} else if tag.ReadOnly {
+				// readonly columns are computed by the database and never written; they are scanned
+				// after every write.
+				autoInsertNames = append(autoInsertNames, name)
+				autoUpdateNames = append(autoUpdateNames, name)
+				autoInsertUpdateNames = append(autoInsertUpdateNames, name)
+				columns = append(columns, column)
+			} else if tag.InsertOnly {
+				// insertonly columns are written during insert but never updated.
+				columns = append(columns, column)
+				insertOnlyNames = append(insertOnlyNames, name)
 			} else {
 				// All other columns are explicitly set during queries.
 				columns = append(columns, column)
 				columnNames = append(columnNames, name)
 			}
-			if strings.Contains(tag, "unique") {
+			if tag.Generate != "" {
+				// generate=name signals the field is set by a generator before insert when zero.
+				if me.generator(tag.Generate) == nil {
+					problems = append(problems, errors.Go(ErrUnknownGenerator).Tag("type", typ.String()).Tag("field", field.Name).Tag("generate", tag.Generate))
+					continue
+				}
+				clientFields = append(clientFields, ClientField{Column: name, Path: mapping.ReflectPaths[name], Insert: true, Generator: tag.Generate})
+			}
+			if tag.Redact {
+				// redact signals the column's values are sensitive.
+				redacted[name] = true
+			}
+			if tag.Unique {
 				// unique signals the column is part of a unique index.
 				// TODO Currently only single column unique indexes are supported; should also support multi-column.
 				// TODO The above comment is a lie -- indexes aren't supported at all yet.
@@ -153,6 +349,15 @@ func (me *Models) Register(value interface{}, opts ...interface{}) {
 				}
 				unique = append(unique, index)
 			}
+			if tag.RefTable != "" {
+				// fk(table.column) signals the column references another table.
+				foreignKeys = append(foreignKeys, schema.ForeignKey{
+					Columns:    []schema.Column{column},
+					RefTable:   tag.RefTable,
+					RefColumns: []string{tag.RefColumn},
+					OnDelete:   tag.OnDelete,
+				})
+			}
 		}
 	}
 	//
@@ -173,7 +378,12 @@ func (me *Models) Register(value interface{}, opts ...interface{}) {
 	//
 	// Merge autoKeyNames into autoInsertNames as those keys are generated during insert statements.
 	autoInsertNames = append(autoKeyNames, autoInsertNames...)
-	// Create table struct.
+	// INSERT statements write keys, columns, and insert only columns.
+	insertNames := []string{}
+	for _, names := range [][]string{keyNames, columnNames, insertOnlyNames} {
+		insertNames = append(insertNames, names...)
+	}
+	// Create table struct; schema qualified table names are split into Schema and Name.
 	table := schema.Table{
 		Name: tableName,
 		PrimaryKey: schema.Index{
@@ -182,8 +392,16 @@ func (me *Models) Register(value interface{}, opts ...interface{}) {
 			IsPrimary: true,
 			IsUnique:  true,
 		},
-		Unique:  unique,
-		Columns: columns,
+		Unique:      unique,
+		Columns:     columns,
+		ForeignKeys: foreignKeys,
+	}
+	if n := strings.LastIndex(tableName, "."); n > 0 {
+		table.Schema, table.Name = tableName[:n], tableName[n+1:]
+	}
+	table.PrimaryKey.Table = tableName
+	for k := range table.Unique {
+		table.Unique[k].Table = tableName
 	}
 	// Create model struct.
 	model := &Model{
@@ -192,23 +410,68 @@ func (me *Models) Register(value interface{}, opts ...interface{}) {
 		SaveMode:          saveMode,
 		InsertUpdatePaths: insertUpdatePaths,
 		Mapping:           mapping,
+		Redacted:          redacted,
+		ClientFields:      clientFields,
+		SaveStrategy:      saveStrategy,
 	}
 	// Fill in query statements.
-	// NB: Ignore errors here as we'll handle when a query is nil for a model in our other functions.
-	model.Statements.Insert, _ = me.Grammar.Insert(tableName, append(keyNames, columnNames...), autoInsertNames)
-	model.Statements.Update, _ = me.Grammar.Update(tableName, columnNames, append(autoKeyNames, keyNames...), autoUpdateNames)
-	model.Statements.Delete, _ = me.Grammar.Delete(tableName, append(autoKeyNames, keyNames...))
-	model.Statements.Upsert, _ = me.Grammar.Upsert(tableName, columnNames, keyNames, autoInsertUpdateNames)
+	//
+	// Grammars return ErrColumnsRequired or ErrKeysRequired when the model does not have the
+	// columns or keys for a statement; such statements are left nil and calls that require them
+	// return ErrUnsupported.  Any other error is a problem with the model.
+	var err error
+	checkStatement := func(name string, err error) {
+		if original := errors.Original(err); original != grammar.ErrColumnsRequired && original != grammar.ErrKeysRequired && original != grammar.ErrTableRequired {
+			problems = append(problems, errors.Go(ErrUnsupported).Tag("type", typ.String()).Tag(name, err.Error()))
+		}
+	}
+	if model.Statements.Insert, err = g.Insert(tableName, insertNames, autoInsertNames); err != nil {
+		checkStatement("INSERT", err)
+	}
+	if model.Statements.Update, err = g.Update(tableName, columnNames, append(autoKeyNames, keyNames...), autoUpdateNames); err != nil {
+		checkStatement("UPDATE", err)
+	}
+	if model.Statements.Delete, err = g.Delete(tableName, append(autoKeyNames, keyNames...)); err != nil {
+		checkStatement("DELETE", err)
+	}
+	if model.Statements.Upsert, err = g.UpsertInsertOnly(tableName, columnNames, insertOnlyNames, keyNames, autoInsertUpdateNames); err != nil {
+		checkStatement("UPSERT", err)
+	}
+	if model.Statements.BulkUpsert, err = g.UpsertSelect(tableName, stagingTable(tableName), columnNames, insertOnlyNames, keyNames, autoInsertUpdateNames); err != nil {
+		checkStatement("BULK UPSERT", err)
+	}
+	if len(problems) == 1 {
+		return problems[0]
+	} else if len(problems) > 0 {
+		return problems
+	}
 	//
 	// We want to be able to look up the model by the original type T passed to this function
 	// as well as []T.
-	me.Models[typ] = model
-	me.Models[reflect.PtrTo(typ)] = model
-	me.Models[reflect.SliceOf(typ)] = model
-	me.Models[reflect.SliceOf(reflect.PtrTo(typ))] = model
+	next := make(map[reflect.Type]*Model, len(registered)+4)
+	for k, v := range registered {
+		next[k] = v
+	}
+	next[typ] = model
+	next[reflect.PtrTo(typ)] = model
+	next[reflect.SliceOf(typ)] = model
+	next[reflect.SliceOf(reflect.PtrTo(typ))] = model
+	me.registry.Store(next)
+	return nil
+}
+
+// MustRegister is the same as Register except it panics if Register returns an error;
+// it is intended for registering models during init().
+func (me *Models) MustRegister(value interface{}, opts ...interface{}) {
+	if err := me.Register(value, opts...); err != nil {
+		panic(err)
+	}
 }
 
 // Lookup returns the model associated with the value.
+//
+// If AutoRegister is true and value's type is not registered then it is registered if it
+// embeds TableName or implements TableNamer.
 func (me *Models) Lookup(value interface{}) (m *Model, err error) {
 	if me == nil {
 		err = errors.NilReceiver()
@@ -216,15 +479,27 @@ func (me *Models) Lookup(value interface{}) (m *Model, err error) {
 	}
 	var ok bool
 	t := reflect.TypeOf(value)
-	if m, ok = me.Models[t]; ok {
+	if m, ok = me.models()[t]; ok {
 		return
+	} else if t != nil && me.AutoRegister {
+		if typ, ok := autoRegisterType(t); ok {
+			if err = me.Register(typ); err != nil {
+				err = errors.Go(err)
+				return
+			} else if m, ok = me.models()[t]; ok {
+				return
+			}
+		}
 	}
 	err = errors.Errorf("%T not registered", value)
 	return
 }
 
 // Insert attempts to persist values via INSERTs.
-func (me *Models) Insert(Q sqlh.IQueries, value interface{}) error {
+//
+// If value is a slice the error for a failed element is an *ElementError; see QueryBinding.QuerySlice
+// and ContinueOnError.
+func (me *Models) Insert(Q sqlh.IQueries, value interface{}, opts ...QueryOption) error {
 	var model *Model
 	var query *statements.Query
 	var binding QueryBinding
@@ -235,8 +510,11 @@ func (me *Models) Insert(Q sqlh.IQueries, value interface{}) error {
 		return errors.Go(ErrUnsupported).Tag("INSERT", fmt.Sprintf("%T", value))
 	}
 	//
-	binding = model.BindQuery(me.Mapper, query)
-	if err = binding.Query(Q, value); err != nil {
+	if err = me.populate(model, value, "INSERT"); err != nil {
+		return errors.Go(err)
+	}
+	binding = me.bind(model, query, "INSERT")
+	if err = binding.Query(me.queries(Q, "INSERT", model, reflect.TypeOf(value)), value, opts...); err != nil {
 		return errors.Go(err)
 	}
 	//
@@ -244,7 +522,10 @@ func (me *Models) Insert(Q sqlh.IQueries, value interface{}) error {
 }
 
 // Update attempts to persist values via UPDATESs.
-func (me *Models) Update(Q sqlh.IQueries, value interface{}) error {
+//
+// If a row does not exist the error is sqlh.ErrNotFound unless opts has IgnoreNotFound; pass a *Result
+// in opts to count the updated and unchanged rows.
+func (me *Models) Update(Q sqlh.IQueries, value interface{}, opts ...QueryOption) error {
 	var model *Model
 	var query *statements.Query
 	var binding QueryBinding
@@ -255,8 +536,34 @@ func (me *Models) Update(Q sqlh.IQueries, value interface{}) error {
 		return errors.Go(ErrUnsupported).Tag("UPDATE", fmt.Sprintf("%T", value))
 	}
 	//
-	binding = model.BindQuery(me.Mapper, query)
-	if err = binding.Query(Q, value); err != nil {
+	if err = me.populate(model, value, "UPDATE"); err != nil {
+		return errors.Go(err)
+	}
+	binding = me.bind(model, query, "UPDATE")
+	if err = binding.Query(me.queries(Q, "UPDATE", model, reflect.TypeOf(value)), value, opts...); err != nil {
+		return errors.Go(err)
+	}
+	//
+	return nil
+}
+
+// Delete deletes values by their primary keys with DELETEs.
+//
+// If a row does not exist the error is sqlh.ErrNotFound unless opts has IgnoreNotFound; pass a *Result
+// in opts to count the deleted and unchanged rows.
+func (me *Models) Delete(Q sqlh.IQueries, value interface{}, opts ...QueryOption) error {
+	var model *Model
+	var query *statements.Query
+	var binding QueryBinding
+	var err error
+	if model, err = me.Lookup(value); err != nil {
+		return errors.Go(err)
+	} else if query = model.Statements.Delete; query == nil {
+		return errors.Go(ErrUnsupported).Tag("DELETE", fmt.Sprintf("%T", value))
+	}
+	//
+	binding = me.bind(model, query, "DELETE")
+	if err = binding.Query(me.queries(Q, "DELETE", model, reflect.TypeOf(value)), value, opts...); err != nil {
 		return errors.Go(err)
 	}
 	//
@@ -273,48 +580,40 @@ func (me *Models) Update(Q sqlh.IQueries, value interface{}) error {
 // Otherwise the model has only "key,auto" fields and will use Update if any such field
 // is a non-zero value and Insert otherwise.
 //
-// If value is a slice []M then the first element is inspected to determine which of
-// Insert, Update, or Upsert is applied to the entire slice.
-func (me *Models) Save(Q sqlh.IQueries, value interface{}) error {
+// Models implementing InsertUpdater use Insert if IsNew returns true and Update otherwise; a SaveStrategy
+// passed to Register decides for every element instead.
+//
+// If value is a slice []M of an InsertOrUpdate model, or of a model with a SaveStrategy or implementing
+// InsertUpdater, then each element is inspected and the groups are saved within one transaction.
+func (me *Models) Save(Q sqlh.IQueries, value interface{}, opts ...QueryOption) error {
 	model, err := me.Lookup(value)
 	if err != nil {
 		return errors.Go(err)
 	}
-	switch model.SaveMode {
-	case Insert:
-		return me.Insert(Q, value)
-	case Upsert:
-		return me.Upsert(Q, value)
-	case InsertOrUpdate:
-		v := reflect.ValueOf(value)
-		switch v.Kind() {
-		case reflect.Slice:
-			if v.Len() == 0 {
-				return nil
-			}
-			for v = v.Index(0); v.Kind() == reflect.Ptr; v = v.Elem() {
-				if v.IsNil() {
-					return errors.Go(ErrUnsupported).Tag("nil pointer", fmt.Sprintf("%v %v", v.Type(), v.Interface()))
-				}
-			}
-		case reflect.Ptr:
-			for ; v.Kind() == reflect.Ptr; v = v.Elem() {
-				if v.IsNil() {
-					return errors.Go(ErrUnsupported).Tag("nil pointer", fmt.Sprintf("%v %v", v.Type(), v.Interface()))
-				}
-			}
+	if model.SaveStrategy == nil {
+		switch model.SaveMode {
+		case Insert:
+			return me.Insert(Q, value, opts...)
+		case Upsert:
+			return me.Upsert(Q, value, opts...)
 		}
-		// TODO Possibly add support for an InsertUpdater interface
-		//
-		var keyValue reflect.Value
-		for _, path := range model.InsertUpdatePaths {
-			keyValue = path.Value(v)
-			if !keyValue.IsZero() {
-				// A non-zero field value means update.
-				return me.Update(Q, value)
-			}
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Slice {
+		return me.saveSlice(Q, model, v, opts)
+	}
+	for ; v.Kind() == reflect.Ptr; v = v.Elem() {
+		if v.IsNil() {
+			return errors.Go(ErrUnsupported).Tag("nil pointer", fmt.Sprintf("%v %v", v.Type(), v.Interface()))
 		}
-		return me.Insert(Q, value)
+	}
+	switch model.saveMode(v) {
+	case Insert:
+		return me.Insert(Q, value, opts...)
+	case Update:
+		return me.Update(Q, value, opts...)
+	case Upsert:
+		return me.Upsert(Q, value, opts...)
 	}
 	// Currently it _should_be_ impossible for this to occur.  The first thing this method
 	// does is find the associated model and -- if not found -- returns error.  Any model that
@@ -330,7 +629,7 @@ func (me *Models) Save(Q sqlh.IQueries, value interface{}) error {
 //
 // Upsert only supports primary keys; currently there is no support for upsert on UNIQUE indexes that are
 // not primary keys.
-func (me *Models) Upsert(Q sqlh.IQueries, value interface{}) error {
+func (me *Models) Upsert(Q sqlh.IQueries, value interface{}, opts ...QueryOption) error {
 	var model *Model
 	var query *statements.Query
 	var binding QueryBinding
@@ -341,8 +640,11 @@ func (me *Models) Upsert(Q sqlh.IQueries, value interface{}) error {
 		return errors.Go(ErrUnsupported).Tag("UPSERT", fmt.Sprintf("%T", value))
 	}
 	//
-	binding = model.BindQuery(me.Mapper, query)
-	if err = binding.Query(Q, value); err != nil {
+	if err = me.populate(model, value, "UPSERT"); err != nil {
+		return errors.Go(err)
+	}
+	binding = me.bind(model, query, "UPSERT")
+	if err = binding.Query(me.queries(Q, "UPSERT", model, reflect.TypeOf(value)), value, opts...); err != nil {
 		return errors.Go(err)
 	}
 	//
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -28,7 +29,7 @@ type ModelQueryTest struct {
 	Name        string
 	DBWrapper   hobbled.Wrapper
 	MockFn      func(mock sqlmock.Sqlmock)
-	ModelsFn    func(Q sqlh.IQueries, Data interface{}) error
+	ModelsFn    func(Q sqlh.IQueries, Data interface{}, opts ...model.QueryOption) error
 	Data        interface{}
 	ExpectError bool
 }
@@ -73,11 +74,23 @@ func TestModels_Register(t *testing.T) {
 		mdb.Register(&examples.Address{})
 
 	})
-	t.Run("no tablename panics", func(t *testing.T) {
+	t.Run("no tablename", func(t *testing.T) {
 		// Models must have a table name when registering.
 		chk := assert.New(t)
 		//
 		type T struct{}
+		mdb := examples.NewModels()
+		err := mdb.Register(&T{})
+		chk.Error(err)
+		chk.True(errors.Is(err, model.ErrTableNameRequired))
+		_, err = mdb.Lookup(&T{})
+		chk.Error(err)
+	})
+	t.Run("no tablename must register panics", func(t *testing.T) {
+		// MustRegister panics when Register returns an error.
+		chk := assert.New(t)
+		//
+		type T struct{}
 		recovered := false
 		mdb := examples.NewModels()
 		func() {
@@ -86,10 +99,55 @@ func TestModels_Register(t *testing.T) {
 					recovered = true
 				}
 			}()
-			mdb.Register(&T{})
+			mdb.MustRegister(&T{})
 		}()
 		chk.True(recovered)
 	})
+	t.Run("every problem reported", func(t *testing.T) {
+		// Register reports every problem with the model.
+		chk := assert.New(t)
+		//
+		type T struct {
+			Id      int    `db:"pk" model:"key,updated"`
+			Name    string `db:"name" model:"readonly,insertonly"`
+			Missing int    `model:"key"`
+		}
+		mdb := &model.Models{
+			Mapper: &set.Mapper{
+				Tags:             []string{"db"},
+				TaggedFieldsOnly: true,
+			},
+			Grammar: grammar.Postgres,
+		}
+		err := mdb.Register(T{})
+		chk.Error(err)
+		problems, ok := err.(model.Errors)
+		chk.True(ok)
+		chk.Len(problems, 4)
+		chk.True(errors.Is(err, model.ErrTableNameRequired))
+		chk.True(errors.Is(err, model.ErrTagConflict))
+		chk.True(errors.Is(err, model.ErrUnmappedKey))
+		chk.Contains(err.Error(), "T.Missing")
+		_, err = mdb.Lookup(T{})
+		chk.Error(err)
+	})
+	t.Run("nil members", func(t *testing.T) {
+		chk := assert.New(t)
+		//
+		type T struct {
+			model.TableName `model:"t"`
+			Name            string
+		}
+		var mdb *model.Models
+		chk.Error(mdb.Register(T{}))
+		mdb = &model.Models{Grammar: grammar.Postgres}
+		chk.Error(mdb.Register(T{}))
+		mdb = &model.Models{Mapper: &set.Mapper{}}
+		chk.Error(mdb.Register(T{}))
+		mdb = &model.Models{Mapper: &set.Mapper{}, Grammar: grammar.Postgres}
+		chk.Error(mdb.Register(nil))
+		chk.NoError(mdb.Register(T{}))
+	})
 	t.Run("tablename option", func(t *testing.T) {
 		// We can provide table name either by embedding in struct or passing as argument to Register()
 		chk := assert.New(t)
@@ -135,6 +193,225 @@ func TestModels_Register(t *testing.T) {
 	})
 }
 
+func TestModels_RegisterColumnTags(t *testing.T) {
+	chk := assert.New(t)
+	//
+	type T struct {
+		model.TableName `model:"things"`
+		Id              int    `db:"pk" model:"key"`
+		CreatedBy       string `db:"created_by" model:"insertonly"`
+		Computed        int    `db:"computed" model:"readonly"`
+		Ignored         string `db:"ignored" model:"-"`
+		Name            string `db:"name"`
+	}
+	mdb := &model.Models{
+		Mapper: &set.Mapper{
+			Tags: []string{"db"},
+		},
+		Grammar: grammar.Postgres,
+	}
+	mdb.Register(T{})
+	mdl, err := mdb.Lookup(T{})
+	chk.NoError(err)
+	chk.NotNil(mdl)
+	//
+	columns := []string{}
+	for _, column := range mdl.Table.Columns {
+		columns = append(columns, column.Name)
+	}
+	chk.Equal([]string{"created_by", "computed", "name"}, columns)
+	//
+	chk.Equal([]string{"pk", "name", "created_by"}, mdl.Statements.Insert.Arguments)
+	chk.Equal([]string{"name", "pk"}, mdl.Statements.Update.Arguments)
+	chk.Equal([]string{"pk", "name", "created_by"}, mdl.Statements.Upsert.Arguments)
+	chk.Equal(strings.Join([]string{
+		"INSERT INTO things AS dest",
+		"\t\t( pk, name, created_by )",
+		"\tVALUES",
+		"\t\t( $1, $2, $3 )",
+		"\tON CONFLICT( pk ) DO UPDATE SET",
+		"\t\tname = EXCLUDED.name",
+		"\t\tWHERE (",
+		"\t\t\tdest.name <> EXCLUDED.name",
+		"\t\t)",
+		"\tRETURNING computed",
+	}, "\n"), mdl.Statements.Upsert.SQL)
+	//
+	// readonly columns are scanned after every write.
+	chk.Equal([]string{"computed"}, mdl.Statements.Insert.Scan)
+	chk.Equal([]string{"computed"}, mdl.Statements.Update.Scan)
+	chk.Equal([]string{"computed"}, mdl.Statements.Upsert.Scan)
+	chk.Contains(mdl.Statements.Insert.SQL, "RETURNING computed")
+	chk.Contains(mdl.Statements.Update.SQL, "RETURNING computed")
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	mock.ExpectQuery("INSERT INTO things").WithArgs(1, "a", "bob").
+		WillReturnRows(sqlmock.NewRows([]string{"computed"}).AddRow(42))
+	mock.ExpectQuery("UPDATE things").WithArgs("b", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"computed"}).AddRow(43))
+	value := T{Id: 1, Name: "a", CreatedBy: "bob"}
+	chk.NoError(mdb.Insert(db, &value))
+	chk.Equal(42, value.Computed)
+	value.Name = "b"
+	chk.NoError(mdb.Update(db, &value))
+	chk.Equal(43, value.Computed)
+	chk.NoError(mock.ExpectationsWereMet())
+}
+
+// namedModel implements model.TableNamer.
+type namedModel struct {
+	Id   int `db:"pk" model:"key,auto"`
+	Name string
+}
+
+func (namedModel) TableName() string {
+	return "named_models"
+}
+
+func TestModels_AutoRegister(t *testing.T) {
+	chk := assert.New(t)
+	//
+	type Embeds struct {
+		model.TableName `model:"embeds"`
+		Id              int `db:"pk" model:"key,auto"`
+		Name            string
+	}
+	type NoTable struct {
+		Name string
+	}
+	mdb := &model.Models{
+		Mapper:  &set.Mapper{Tags: []string{"db"}},
+		Grammar: grammar.Postgres,
+	}
+	// Not registered without AutoRegister.
+	_, err := mdb.Lookup(&Embeds{})
+	chk.Error(err)
+	//
+	mdb.AutoRegister = true
+	mdl, err := mdb.Lookup([]*Embeds{})
+	chk.NoError(err)
+	chk.Equal("embeds", mdl.Table.Name)
+	mdl2, err := mdb.Lookup(Embeds{})
+	chk.NoError(err)
+	chk.True(mdl == mdl2)
+	//
+	mdl, err = mdb.Lookup(&namedModel{})
+	chk.NoError(err)
+	chk.Equal("named_models", mdl.Table.Name)
+	//
+	_, err = mdb.Lookup(&NoTable{})
+	chk.Error(err)
+	_, err = mdb.Lookup(42)
+	chk.Error(err)
+}
+
+func TestModels_Concurrent(t *testing.T) {
+	chk := assert.New(t)
+	//
+	type A struct {
+		model.TableName `model:"a"`
+		Id              int `db:"pk" model:"key,auto"`
+		Name            string
+	}
+	type B struct {
+		model.TableName `model:"b"`
+		Id              int `db:"pk" model:"key,auto"`
+		Name            string
+	}
+	mdb := &model.Models{
+		Mapper:       &set.Mapper{Tags: []string{"db"}},
+		Grammar:      grammar.Postgres,
+		AutoRegister: true,
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, 400)
+	for k := 0; k < 100; k++ {
+		wg.Add(4)
+		go func() {
+			defer wg.Done()
+			errs <- mdb.Register(A{})
+		}()
+		go func() {
+			defer wg.Done()
+			errs <- mdb.Register(namedModel{})
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := mdb.Lookup(&B{})
+			errs <- err
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := mdb.Lookup([]A{})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		chk.NoError(err)
+	}
+	chk.NoError(mdb.Validate())
+}
+
+func TestModels_Quoting(t *testing.T) {
+	chk := assert.New(t)
+	//
+	type Order struct {
+		model.TableName `model:"shop.order"`
+		Id              int    `db:"pk" model:"key,auto"`
+		User            string `db:"user"`
+	}
+	mdb := &model.Models{
+		Mapper:  &set.Mapper{Tags: []string{"db"}},
+		Grammar: grammar.Postgres,
+		Quoting: grammar.QuoteRequired,
+	}
+	chk.NoError(mdb.Register(Order{}))
+	mdl, err := mdb.Lookup(Order{})
+	chk.NoError(err)
+	chk.Equal("shop", mdl.Table.Schema)
+	chk.Equal("order", mdl.Table.Name)
+	chk.Equal("shop.order", mdl.Table.QualifiedName())
+	chk.Equal("INSERT INTO shop.\"order\"\n\t\t( \"user\" )\n\tVALUES\n\t\t( $1 )\n\tRETURNING pk", mdl.Statements.Insert.SQL)
+	chk.Equal([]string{"user"}, mdl.Statements.Insert.Arguments)
+	chk.Equal([]string{"pk"}, mdl.Statements.Insert.Scan)
+	//
+	// The shared grammar is not modified.
+	chk.Equal(grammar.QuoteDefault, grammar.Postgres.(*grammar.PostgresGrammar).Quoting)
+}
+
+func TestModels_Validate(t *testing.T) {
+	chk := assert.New(t)
+	//
+	mdb := examples.NewModels()
+	chk.NoError(mdb.Validate())
+	chk.NoError(examples.Models.Validate())
+	//
+	var nilModels *model.Models
+	chk.Error(nilModels.Validate())
+	//
+	// A model whose statements reference columns the Mapper can no longer find.
+	type T struct {
+		model.TableName `model:"things"`
+		Id              int `db:"pk" model:"key,auto"`
+		Name            string
+	}
+	mdb = &model.Models{
+		Mapper:  &set.Mapper{Tags: []string{"db"}},
+		Grammar: grammar.Postgres,
+	}
+	chk.NoError(mdb.Register(T{}))
+	chk.NoError(mdb.Validate())
+	mdb.Mapper = &set.Mapper{}
+	err := mdb.Validate()
+	chk.Error(err)
+	problems, ok := err.(model.Errors)
+	chk.True(ok)
+	chk.NotEmpty(problems)
+}
+
 func TestModels_Save(t *testing.T) {
 	mdb := examples.NewModels()
 
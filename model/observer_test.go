@@ -0,0 +1,56 @@
+package model_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nofeaturesonlybugs/sqlh/model/examples"
+)
+
+// queryRecorder is a sqlh.Observer that records queries.
+type queryRecorder struct {
+	queries []string
+}
+
+func (me *queryRecorder) BeforeQuery(ctx context.Context, query string, args []interface{}) context.Context {
+	return ctx
+}
+
+func (me *queryRecorder) AfterQuery(ctx context.Context, query string, args []interface{}, duration time.Duration, rowsAffected int64, err error) {
+	me.queries = append(me.queries, query)
+}
+
+func TestModels_Observer(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	rec := &queryRecorder{}
+	mdb := examples.NewModels()
+	mdb.Observer = rec
+	//
+	// Observed slices are prepared and the prepare and each row are observed.
+	mock.ExpectBegin()
+	prepare := mock.ExpectPrepare("INSERT INTO addresses")
+	for k := 0; k < 2; k++ {
+		prepare.ExpectQuery().
+			WillReturnRows(sqlmock.NewRows([]string{"pk", "created_tmz", "modified_tmz"}).AddRow(k+1, time.Now(), time.Now()))
+	}
+	mock.ExpectCommit()
+	addresses := []examples.Address{{Street: "Main"}, {Street: "Elm"}}
+	chk.NoError(mdb.Insert(db, addresses))
+	chk.NoError(mock.ExpectationsWereMet())
+	if chk.Len(rec.queries, 5) {
+		chk.Equal("BEGIN", rec.queries[0])
+		chk.Contains(rec.queries[1], "INSERT INTO addresses")
+		chk.Contains(rec.queries[2], "INSERT INTO addresses")
+		chk.Contains(rec.queries[3], "INSERT INTO addresses")
+		chk.Equal("COMMIT", rec.queries[4])
+	}
+	chk.Equal(2, addresses[1].Id)
+}
@@ -0,0 +1,391 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/nofeaturesonlybugs/errors"
+
+	"github.com/nofeaturesonlybugs/sqlh"
+	"github.com/nofeaturesonlybugs/sqlh/grammar"
+	"github.com/nofeaturesonlybugs/sqlh/schema"
+)
+
+// relationKind describes how a related model is found from its parent.
+type relationKind int
+
+const (
+	// belongsTo means the parent's foreign key references the related model.
+	belongsTo relationKind = iota + 1
+	// hasMany means the related model's foreign key references the parent; when the field
+	// holds a single model it is a has-one relationship.
+	hasMany
+	// manyToMany means a registered join model references both the parent and the related model.
+	manyToMany
+)
+
+// relation describes how to load a related model into a field of its parent.
+type relation struct {
+	kind relationKind
+	// parentColumn is the parent column holding the key that is matched against childColumn.
+	parentColumn, childColumn string
+	// join is the join model of a manyToMany relation; joinParent references parentColumn and
+	// joinChild references childColumn.
+	join                  *Model
+	joinType              reflect.Type
+	joinParent, joinChild string
+}
+
+// Preload loads the related models named by relations into the fields of dest.  dest is a
+// registered model or a slice of them as *T, []T, *[]T, or *[]*T and each relation is the name
+// of a struct field holding the related model as T, *T, []T, or []*T.
+//
+// Relations are found from the foreign keys of registered models (see model:"fk(table.column)"):
+//   - belongs-to: the parent has a foreign key referencing the related model's table,
+//   - has-many or has-one: the related model has a foreign key referencing the parent's table,
+//   - many-to-many: a registered join model, such as examples.PersonAddress, has foreign keys
+//     referencing both tables.
+//
+// Fields holding a single model prefer belongs-to and slices prefer has-many.  Each relation is
+// loaded with one SELECT ... WHERE fk IN (...) query through sqlh.Scanner; many-to-many relations
+// run one query for the join model and one for the related model.  Parents with zero or nil key
+// values are skipped and their relation fields are left zero.
+//
+// Fields holding a single struct are mapped by the Mapper like any other nested struct; tag such
+// fields with model:"-" to exclude them from the parent model.  Slice fields are never mapped.
+//
+// ErrUnknownRelation is returned when a relation does not name a field of a registered model or
+// its foreign keys are missing or ambiguous.
+func (me *Models) Preload(Q sqlh.IQueries, dest interface{}, relations ...string) error {
+	if me == nil {
+		return errors.NilReceiver()
+	} else if Q == nil {
+		return errors.NilArgument("Q")
+	} else if dest == nil {
+		return errors.NilArgument("dest")
+	}
+	Q = me.queries(Q, "", nil, nil)
+	parents, typ, err := preloadParents(dest)
+	if err != nil {
+		return errors.Go(err)
+	}
+	parent, err := me.lookupStruct(typ)
+	if err != nil {
+		return errors.Go(err)
+	}
+	for _, name := range relations {
+		field, ok := typ.FieldByName(name)
+		if !ok {
+			return errors.Go(ErrUnknownRelation).Tag("type", typ.String()).Tag("relation", name).Tag("reason", "no such field")
+		}
+		childType, many := relatedType(field.Type)
+		child, err := me.lookupStruct(childType)
+		if err != nil {
+			return errors.Go(ErrUnknownRelation).Tag("type", typ.String()).Tag("relation", name).Tag("reason", err.Error())
+		}
+		rel, err := me.relation(parent, child, many)
+		if err != nil {
+			return errors.Go(err).Tag("type", typ.String()).Tag("relation", name)
+		}
+		if err = me.preload(Q, parents, field.Index, parent, child, childType, rel); err != nil {
+			return errors.Go(err).Tag("type", typ.String()).Tag("relation", name)
+		}
+	}
+	return nil
+}
+
+// preloadParents returns the addressable struct values in dest and their type.
+func preloadParents(dest interface{}) ([]reflect.Value, reflect.Type, error) {
+	v := reflect.ValueOf(dest)
+	for v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Kind() != reflect.Struct {
+		v = v.Elem()
+	}
+	var rv []reflect.Value
+	switch {
+	case v.Kind() == reflect.Ptr && !v.IsNil():
+		return []reflect.Value{v.Elem()}, v.Type().Elem(), nil
+	case v.Kind() == reflect.Slice:
+		typ := v.Type().Elem()
+		for typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		if typ.Kind() != reflect.Struct {
+			break
+		}
+		for k, size := 0, v.Len(); k < size; k++ {
+			elem := v.Index(k)
+			for elem.Kind() == reflect.Ptr && !elem.IsNil() {
+				elem = elem.Elem()
+			}
+			if elem.Kind() == reflect.Struct {
+				rv = append(rv, elem)
+			}
+		}
+		return rv, typ, nil
+	}
+	return nil, nil, errors.Go(ErrUnsupported).Tag("dest", fmt.Sprintf("%T", dest))
+}
+
+// lookupStruct returns the model of the struct type typ whether it was registered as T or *T.
+func (me *Models) lookupStruct(typ reflect.Type) (*Model, error) {
+	registered := me.models()
+	if model, ok := registered[typ]; ok {
+		return model, nil
+	} else if model, ok = registered[reflect.PtrTo(typ)]; ok {
+		return model, nil
+	}
+	return me.Lookup(reflect.New(typ).Elem().Interface())
+}
+
+// derefType returns the type typ points to after following every pointer.
+func derefType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+// relatedType returns the struct type of a relation field of type T, *T, []T, or []*T; many is
+// true for slices.
+func relatedType(typ reflect.Type) (rv reflect.Type, many bool) {
+	if typ.Kind() == reflect.Slice {
+		typ, many = typ.Elem(), true
+	}
+	return derefType(typ), many
+}
+
+// param returns the placeholder for parameter n of g where n is zero based.
+func param(g grammar.Grammar, n int) string {
+	if paramer, ok := g.(interface{ ParamN(int) string }); ok {
+		return paramer.ParamN(n)
+	}
+	return "?"
+}
+
+// relation determines how child is loaded into a field of parent; many is true if the field
+// is a slice.
+func (me *Models) relation(parent, child *Model, many bool) (relation, error) {
+	parentTable, childTable := parent.Table.QualifiedName(), child.Table.QualifiedName()
+	owned, referencing := referencesTo(parent.Table, childTable), referencesTo(child.Table, parentTable)
+	unknown := func(reason string) (relation, error) {
+		return relation{}, errors.Go(ErrUnknownRelation).Tag("reason", reason)
+	}
+	switch {
+	case !many && len(owned) == 1:
+		return relation{kind: belongsTo, parentColumn: owned[0].Columns[0].Name, childColumn: owned[0].RefColumns[0]}, nil
+	case !many && len(owned) > 1:
+		return unknown("more than one foreign key from " + parentTable + " to " + childTable)
+	case len(referencing) == 1:
+		return relation{kind: hasMany, parentColumn: referencing[0].RefColumns[0], childColumn: referencing[0].Columns[0].Name}, nil
+	case len(referencing) > 1:
+		return unknown("more than one foreign key from " + childTable + " to " + parentTable)
+	case !many:
+		return unknown("no foreign key between " + parentTable + " and " + childTable)
+	}
+	//
+	// Search the registered models for a join model.
+	var rv relation
+	registered := me.models()
+	for _, typ := range me.registeredTypes() {
+		join := registered[typ]
+		if join == parent || join == child {
+			continue
+		}
+		toParent, toChild := referencesTo(join.Table, parentTable), referencesTo(join.Table, childTable)
+		if len(toParent) == 0 || len(toChild) == 0 {
+			continue
+		} else if rv.join != nil || len(toParent) > 1 || len(toChild) > 1 {
+			return unknown("more than one join model between " + parentTable + " and " + childTable)
+		}
+		rv = relation{
+			kind:         manyToMany,
+			parentColumn: toParent[0].RefColumns[0],
+			childColumn:  toChild[0].RefColumns[0],
+			join:         join,
+			joinType:     derefType(typ),
+			joinParent:   toParent[0].Columns[0].Name,
+			joinChild:    toChild[0].Columns[0].Name,
+		}
+	}
+	if rv.join == nil {
+		return unknown("no foreign key or join model between " + parentTable + " and " + childTable)
+	}
+	return rv, nil
+}
+
+// referencesTo returns the single column foreign keys of table that reference refTable.
+func referencesTo(table schema.Table, refTable string) []schema.ForeignKey {
+	var rv []schema.ForeignKey
+	for _, foreignKey := range table.ForeignKeys {
+		if foreignKey.RefTable == refTable && len(foreignKey.Columns) == 1 && len(foreignKey.RefColumns) == 1 {
+			rv = append(rv, foreignKey)
+		}
+	}
+	return rv
+}
+
+// preload loads child models described by rel into the field at index of every parent.
+func (me *Models) preload(Q sqlh.IQueries, parents []reflect.Value, index []int, parent, child *Model, childType reflect.Type, rel relation) error {
+	for _, value := range parents {
+		field := value.FieldByIndex(index)
+		field.Set(reflect.Zero(field.Type()))
+	}
+	keys, args, err := columnKeys(parent, rel.parentColumn, parents)
+	if err != nil {
+		return errors.Go(err)
+	} else if len(args) == 0 {
+		return nil
+	}
+	//
+	// childKeys maps each parent key to the keys of its children in childColumn.
+	childKeys := map[string][]string{}
+	if rel.kind == manyToMany {
+		joins, err := me.preloadQuery(Q, rel.join, rel.joinType, rel.joinParent, args)
+		if err != nil {
+			return errors.Go(err)
+		}
+		joinValues := make([]reflect.Value, joins.Len())
+		for k := range joinValues {
+			joinValues[k] = joins.Index(k)
+		}
+		joinParents, _, err := columnKeys(rel.join, rel.joinParent, joinValues)
+		if err != nil {
+			return errors.Go(err)
+		}
+		joinChildren, childArgs, err := columnKeys(rel.join, rel.joinChild, joinValues)
+		if err != nil {
+			return errors.Go(err)
+		}
+		for k := range joinValues {
+			if joinParents[k] != "" && joinChildren[k] != "" {
+				childKeys[joinParents[k]] = append(childKeys[joinParents[k]], joinChildren[k])
+			}
+		}
+		if args = childArgs; len(args) == 0 {
+			return nil
+		}
+	}
+	children, err := me.preloadQuery(Q, child, childType, rel.childColumn, args)
+	if err != nil {
+		return errors.Go(err)
+	}
+	childValues := make([]reflect.Value, children.Len())
+	for k := range childValues {
+		childValues[k] = children.Index(k)
+	}
+	found, _, err := columnKeys(child, rel.childColumn, childValues)
+	if err != nil {
+		return errors.Go(err)
+	}
+	byKey := map[string][]reflect.Value{}
+	for k, key := range found {
+		byKey[key] = append(byKey[key], childValues[k])
+	}
+	//
+	for k, value := range parents {
+		if keys[k] == "" {
+			continue
+		}
+		field := value.FieldByIndex(index)
+		var related []reflect.Value
+		if rel.kind == manyToMany {
+			for _, key := range childKeys[keys[k]] {
+				related = append(related, byKey[key]...)
+			}
+		} else {
+			related = byKey[keys[k]]
+		}
+		for _, childValue := range related {
+			if !assignRelated(field, childValue) {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// preloadQuery selects the rows of model's table where column is one of args into a slice of typ.
+// args are split into batches so the IN list of each query is within the parameter limit of the
+// grammar.
+func (me *Models) preloadQuery(Q sqlh.IQueries, model *Model, typ reflect.Type, column string, args []interface{}) (reflect.Value, error) {
+	g := me.grammar()
+	columns := append(append([]string{}, schema.ColumnNames(model.Table.PrimaryKey.Columns)...), schema.ColumnNames(model.Table.Columns)...)
+	sel := "SELECT " + strings.Join(quoteAll(g.Quote, columns), ", ") + " FROM " + g.Quote(model.Table.QualifiedName()) +
+		" WHERE " + g.Quote(column) + " IN ( "
+	scanner := &sqlh.Scanner{Mapper: me.Mapper}
+	rv, batch := reflect.MakeSlice(reflect.SliceOf(typ), 0, len(args)), me.parameterLimit()
+	for start := 0; start < len(args); start += batch {
+		end := start + batch
+		if end > len(args) {
+			end = len(args)
+		}
+		params := make([]string, end-start)
+		for k := range params {
+			params[k] = param(g, k)
+		}
+		query := sel + strings.Join(params, ", ") + " )"
+		dest := reflect.New(reflect.SliceOf(typ))
+		if err := scanner.Select(me.queries(Q, "SELECT", model, typ), dest.Interface(), query, args[start:end]...); err != nil {
+			return reflect.Value{}, errors.Go(err).Tag("SQL", query)
+		}
+		rv = reflect.AppendSlice(rv, dest.Elem())
+	}
+	return rv, nil
+}
+
+// columnKeys returns the value of column in each of values as a string key and the distinct
+// non-zero values as query arguments.  Keys of zero or nil values are empty.
+func columnKeys(model *Model, column string, values []reflect.Value) ([]string, []interface{}, error) {
+	path, ok := model.Mapping.ReflectPaths[column]
+	if !ok {
+		return nil, nil, errors.Go(ErrUnknownRelation).Tag("reason", "column "+column+" is not mapped")
+	}
+	keys, args, seen := make([]string, len(values)), []interface{}(nil), map[string]bool{}
+	for k, value := range values {
+		key := path.Value(value)
+		for key.Kind() == reflect.Ptr && !key.IsNil() {
+			key = key.Elem()
+		}
+		if key.Kind() == reflect.Ptr || key.IsZero() {
+			continue
+		}
+		keys[k] = fmt.Sprint(key.Interface())
+		if !seen[keys[k]] {
+			seen[keys[k]] = true
+			args = append(args, key.Interface())
+		}
+	}
+	return keys, args, nil
+}
+
+// assignRelated stores child into field; it appends to slices and sets single values.  It returns
+// false when field can not hold more children.
+func assignRelated(field reflect.Value, child reflect.Value) bool {
+	target := field.Type()
+	if target.Kind() == reflect.Slice {
+		field.Set(reflect.Append(field, relatedValue(target.Elem(), child)))
+		return true
+	}
+	field.Set(relatedValue(target, child))
+	return false
+}
+
+// relatedValue returns child as typ where typ is the struct type of child or a pointer to it.
+func relatedValue(typ reflect.Type, child reflect.Value) reflect.Value {
+	if typ.Kind() != reflect.Ptr {
+		return child
+	}
+	rv := reflect.New(typ.Elem())
+	rv.Elem().Set(relatedValue(typ.Elem(), child))
+	return rv
+}
+
+// quoteAll quotes each name with quote.
+func quoteAll(quote func(string) string, names []string) []string {
+	rv := make([]string, len(names))
+	for k, name := range names {
+		rv[k] = quote(name)
+	}
+	return rv
+}
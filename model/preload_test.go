@@ -0,0 +1,174 @@
+package model_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/nofeaturesonlybugs/errors"
+	"github.com/nofeaturesonlybugs/set"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nofeaturesonlybugs/sqlh/grammar"
+	"github.com/nofeaturesonlybugs/sqlh/model"
+	"github.com/nofeaturesonlybugs/sqlh/model/examples"
+)
+
+func TestModels_PreloadManyToMany(t *testing.T) {
+	chk := assert.New(t)
+	//
+	mdb := examples.NewModels()
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	//
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT person_fk, address_fk FROM relate_people_addresses WHERE person_fk IN ( $1, $2 )")).
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"person_fk", "address_fk"}).AddRow(1, 10).AddRow(1, 11).AddRow(2, 10))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT pk, created_tmz, modified_tmz, street, city, state, zip FROM addresses WHERE pk IN ( $1, $2 )")).
+		WithArgs(10, 11).
+		WillReturnRows(sqlmock.NewRows([]string{"pk", "street"}).AddRow(10, "Main").AddRow(11, "Elm"))
+	//
+	people := []*examples.Person{{Id: 1}, {Id: 2}, {Id: 0}, nil}
+	err = mdb.Preload(db, &people, "Addresses")
+	chk.NoError(err)
+	chk.NoError(mock.ExpectationsWereMet())
+	if chk.Len(people[0].Addresses, 2) {
+		chk.Equal("Main", people[0].Addresses[0].Street)
+		chk.Equal("Elm", people[0].Addresses[1].Street)
+	}
+	if chk.Len(people[1].Addresses, 1) {
+		chk.Equal(10, people[1].Addresses[0].Id)
+	}
+	chk.Nil(people[2].Addresses)
+}
+
+func TestModels_Preload(t *testing.T) {
+	chk := assert.New(t)
+	//
+	type Person struct {
+		model.TableName `model:"people"`
+		Id              int             `db:"pk" model:"key,auto"`
+		Name            string          `db:"name"`
+		Orders          []*preloadOrder `db:"orders"`
+	}
+	type Account struct {
+		model.TableName `model:"accounts"`
+		Id              int     `db:"pk" model:"key,auto"`
+		PersonId        *int    `db:"person_fk" model:"fk(people.pk)"`
+		Person          *Person `db:"person" model:"-"`
+	}
+	mdb := &model.Models{
+		Mapper:  &set.Mapper{Join: "_", Tags: []string{"db"}},
+		Grammar: grammar.Sqlite,
+	}
+	mdb.MustRegister(Person{})
+	mdb.MustRegister(preloadOrder{})
+	mdb.MustRegister(Account{})
+	//
+	// Fields nested in model:"-" fields are not part of the model.
+	account, err := mdb.Lookup(Account{})
+	chk.NoError(err)
+	chk.Len(account.Table.Columns, 1)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	{
+		// Has-many.
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT pk, person_fk, total FROM orders WHERE person_fk IN ( ?, ? )")).
+			WithArgs(1, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"pk", "person_fk", "total"}).AddRow(100, 2, 5).AddRow(101, 2, 7))
+		people := []Person{{Id: 1, Orders: []*preloadOrder{{Id: 99}}}, {Id: 2}}
+		chk.NoError(mdb.Preload(db, people, "Orders"))
+		chk.NoError(mock.ExpectationsWereMet())
+		chk.Nil(people[0].Orders)
+		if chk.Len(people[1].Orders, 2) {
+			chk.Equal(100, people[1].Orders[0].Id)
+			chk.Equal(7, people[1].Orders[1].Total)
+		}
+	}
+	{
+		// Belongs-to.
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT pk, name FROM people WHERE pk IN ( ? )")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"pk", "name"}).AddRow(1, "Bob"))
+		one := 1
+		accounts := []Account{{Id: 1, PersonId: &one}, {Id: 2}, {Id: 3, PersonId: &one}}
+		chk.NoError(mdb.Preload(db, &accounts, "Person"))
+		chk.NoError(mock.ExpectationsWereMet())
+		if chk.NotNil(accounts[0].Person) && chk.NotNil(accounts[2].Person) {
+			chk.Equal("Bob", accounts[0].Person.Name)
+			chk.Equal("Bob", accounts[2].Person.Name)
+		}
+		chk.Nil(accounts[1].Person)
+	}
+	{
+		// Keys beyond the parameter limit are selected in batches.
+		people := make([]Person, 1000)
+		for k := range people {
+			people[k].Id = k + 1
+		}
+		mock.ExpectQuery(regexp.QuoteMeta("FROM orders WHERE person_fk IN ( ?, ")).
+			WillReturnRows(sqlmock.NewRows([]string{"pk", "person_fk", "total"}).AddRow(100, 1, 5))
+		mock.ExpectQuery(regexp.QuoteMeta("FROM orders WHERE person_fk IN ( ? )")).WithArgs(1000).
+			WillReturnRows(sqlmock.NewRows([]string{"pk", "person_fk", "total"}).AddRow(101, 1000, 7))
+		chk.NoError(mdb.Preload(db, people, "Orders"))
+		chk.NoError(mock.ExpectationsWereMet())
+		chk.Len(people[0].Orders, 1)
+		chk.Len(people[999].Orders, 1)
+	}
+	{
+		// Nothing to load does not query.
+		var person Person
+		chk.NoError(mdb.Preload(db, &person, "Orders"))
+		chk.NoError(mock.ExpectationsWereMet())
+	}
+	{
+		// Query errors are returned.
+		mock.ExpectQuery(regexp.QuoteMeta("FROM orders")).WillReturnError(errors.Errorf("connection lost"))
+		chk.Error(mdb.Preload(db, &Person{Id: 1}, "Orders"))
+		chk.NoError(mock.ExpectationsWereMet())
+	}
+}
+
+// preloadOrder is used by TestModels_Preload; it is declared at package level so Person.Orders can refer to it.
+type preloadOrder struct {
+	model.TableName `model:"orders"`
+	Id              int `db:"pk" model:"key,auto"`
+	PersonId        int `db:"person_fk" model:"fk(people.pk)"`
+	Total           int `db:"total"`
+}
+
+func TestModels_PreloadErrors(t *testing.T) {
+	chk := assert.New(t)
+	//
+	type Widget struct {
+		model.TableName `model:"widgets"`
+		Id              int                `db:"pk" model:"key,auto"`
+		Addresses       []examples.Address `db:"addresses"`
+		Unregistered    []struct{ A int }  `db:"unregistered"`
+	}
+	mdb := examples.NewModels()
+	mdb.MustRegister(Widget{})
+	db, _, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	//
+	widgets := []Widget{{Id: 1}}
+	err = mdb.Preload(db, &widgets, "Missing")
+	chk.Equal(model.ErrUnknownRelation, errors.Original(err))
+	err = mdb.Preload(db, &widgets, "Addresses")
+	chk.Equal(model.ErrUnknownRelation, errors.Original(err))
+	err = mdb.Preload(db, &widgets, "Unregistered")
+	chk.Equal(model.ErrUnknownRelation, errors.Original(err))
+	err = mdb.Preload(db, Widget{}, "Addresses")
+	chk.Equal(model.ErrUnsupported, errors.Original(err))
+	err = mdb.Preload(db, &[]int{1}, "Addresses")
+	chk.Equal(model.ErrUnsupported, errors.Original(err))
+	//
+	chk.Error(mdb.Preload(nil, &widgets))
+	chk.Error(mdb.Preload(db, nil))
+	var nilModels *model.Models
+	chk.Error(nilModels.Preload(db, &widgets))
+}
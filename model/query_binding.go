@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 
+	"github.com/nofeaturesonlybugs/errors"
 	"github.com/nofeaturesonlybugs/set"
 
 	"github.com/nofeaturesonlybugs/sqlh"
@@ -16,25 +17,119 @@ type QueryBinding struct {
 	mapper *set.Mapper
 	model  *Model
 	query  *statements.Query
+	// classifier classifies errors returned by the database; it may be nil.
+	classifier sqlh.ErrorClassifier
+	// op is the operation of the query counted by Result; see Result.add.
+	op string
+	// checkFound is true if queries matching no rows return sqlh.ErrNotFound unless the call has
+	// the IgnoreNotFound option.
+	checkFound bool
+}
+
+// QueryOption configures QueryBinding.Query and the Models methods that call it.  A QueryOption is
+// a QueryFlag or a *Result.
+type QueryOption interface {
+	applyQuery(options *queryOptions)
+}
+
+// QueryFlag is a QueryOption without a value.
+type QueryFlag int
+
+const (
+	// ContinueOnError continues with the remaining elements of a slice when an element fails.  The
+	// errors of the failed elements are returned as Errors of *ElementError after the others are
+	// committed.  Each element runs within a SAVEPOINT so a failed element does not abort the
+	// transaction; Q must be a transaction or able to begin one.
+	ContinueOnError QueryFlag = iota + 1
+	// IgnoreNotFound stops Models.Update and Models.Delete from returning sqlh.ErrNotFound when a
+	// row does not exist; such rows are counted as Result.Unchanged.
+	IgnoreNotFound
+)
+
+// applyQuery sets the flag in options.
+func (me QueryFlag) applyQuery(options *queryOptions) {
+	switch me {
+	case ContinueOnError:
+		options.continueOnError = true
+	case IgnoreNotFound:
+		options.ignoreNotFound = true
+	}
+}
+
+// queryOptions are the QueryOption values given to a call.
+type queryOptions struct {
+	continueOnError bool
+	ignoreNotFound  bool
+	result          *Result
+}
+
+// newQueryOptions returns the queryOptions for opts.
+func newQueryOptions(opts []QueryOption) queryOptions {
+	var rv queryOptions
+	for _, opt := range opts {
+		if opt != nil {
+			opt.applyQuery(&rv)
+		}
+	}
+	return rv
 }
 
 // Query accepts either a single model M or a slice of models []M.  It then
 // runs and returns the result of QueryOne or QuerySlice.
-func (me QueryBinding) Query(q sqlh.IQueries, value interface{}) error {
+func (me QueryBinding) Query(q sqlh.IQueries, value interface{}, opts ...QueryOption) error {
 	if reflect.Slice == reflect.TypeOf(value).Kind() {
-		if err := me.QuerySlice(q, value); err != nil {
+		if err := me.QuerySlice(q, value, opts...); err != nil {
 			return err
 		}
-	} else if err := me.QueryOne(q, value); err != nil {
+	} else if err := me.QueryOne(q, value, opts...); err != nil {
 		return err
 	}
 	return nil
 }
 
+// WithClassifier returns a copy of the binding that classifies database errors with classifier;
+// see sqlh.ClassifyError.
+func (me QueryBinding) WithClassifier(classifier sqlh.ErrorClassifier) QueryBinding {
+	me.classifier = classifier
+	return me
+}
+
+// fail classifies err.  If index is not -1 it is the index of the failed slice element of type typ and
+// the returned error is an *ElementError describing it with a copy of args.
+func (me QueryBinding) fail(err error, index int, typ reflect.Type, args []interface{}) error {
+	err = sqlh.ClassifyError(me.classifier, err)
+	classified, ok := err.(*sqlh.DBError)
+	if ok {
+		classified.Index = index
+	}
+	if index == -1 {
+		return err
+	}
+	rv := &ElementError{Index: index, Model: typ.String(), SQL: me.query.SQL, Args: make([]interface{}, len(args)), Err: err}
+	copy(rv.Args, args)
+	if me.model != nil {
+		for k, column := range me.query.Arguments {
+			if me.model.Redacted[column] && k < len(rv.Args) {
+				rv.Args[k] = RedactedValue
+			}
+		}
+	}
+	return rv
+}
+
 // QueryOne runs the query against a single instance of the model.
 //
 // As a special case value can be an instance of reflect.Value.
-func (me QueryBinding) QueryOne(q sqlh.IQueries, value interface{}) error {
+//
+// Errors returned by the database are classified; see sqlh.ClassifyError.
+func (me QueryBinding) QueryOne(q sqlh.IQueries, value interface{}, opts ...QueryOption) error {
+	options := newQueryOptions(opts)
+	return me.queryOne(q, value, -1, nil, options)
+}
+
+// queryOne runs the query against a single instance of the model; index is the index of value
+// within a slice of typ or -1.
+func (me QueryBinding) queryOne(q sqlh.IQueries, value interface{}, index int, typ reflect.Type, options queryOptions) error {
 	args, scans := make([]interface{}, len(me.query.Arguments)), make([]interface{}, len(me.query.Scan))
 	//
 	// Create our prepared mapping.  Note that if the calls to Plan() succeed then we do
@@ -52,45 +147,78 @@ func (me QueryBinding) QueryOne(q sqlh.IQueries, value interface{}) error {
 	}
 	_, _ = prepared.Assignables(scans)
 	//
-	// If no scans then use Exec().
+	exec := func(args ...interface{}) (sql.Result, error) {
+		return q.Exec(me.query.SQL, args...)
+	}
+	queryRow := func(args ...interface{}) *sql.Row {
+		return q.QueryRow(me.query.SQL, args...)
+	}
+	affected, err := me.run(exec, queryRow, args, scans, options)
+	options.result.add(me.op, affected, err)
+	if err != nil {
+		return me.fail(err, index, typ, args)
+	}
+	return nil
+}
+
+// run runs the query for one element with exec if the query has no Scan columns and queryRow otherwise.
+// affected is false if the query matched no rows; such queries return sql.ErrNoRows if the binding
+// checks for them.
+func (me QueryBinding) run(exec func(args ...interface{}) (sql.Result, error), queryRow func(args ...interface{}) *sql.Row, args []interface{}, scans []interface{}, options queryOptions) (affected bool, err error) {
+	checkFound, ignored := me.checkFound && !options.ignoreNotFound, me.checkFound && options.ignoreNotFound
 	if len(me.query.Scan) == 0 {
-		if _, err := q.Exec(me.query.SQL, args...); err != nil {
-			return err
+		result, err := exec(args...)
+		if err != nil {
+			return false, err
+		} else if !checkFound && options.result == nil {
+			return true, nil
 		}
-		return nil
+		// Drivers that do not report rows affected are assumed to affect the row.
+		if n, err := result.RowsAffected(); err == nil && n == 0 {
+			if checkFound {
+				return false, sql.ErrNoRows
+			}
+			return false, nil
+		}
+		return true, nil
 	}
-	//
-	row := q.QueryRow(me.query.SQL, args...)
 	// NB: The error conditions are separated for code coverage purposes.
-	if err := row.Scan(scans...); err != nil {
+	if err = queryRow(args...).Scan(scans...); err != nil {
 		if err != sql.ErrNoRows {
-			return err
-		} else if err == sql.ErrNoRows && me.query.Expect != statements.ExpectRowOrNone {
-			return err
+			return false, err
+		} else if err == sql.ErrNoRows && ignored {
+			// With IgnoreNotFound a missing row is unchanged even if the query expects a row.
+			return false, nil
+		} else if err == sql.ErrNoRows && (me.query.Expect != statements.ExpectRowOrNone || checkFound) {
+			return false, err
 		}
+		return false, nil
 	}
-	return nil
+	return true, nil
 }
 
 // QuerySlice runs the query against a slice of model instances.
-func (me QueryBinding) QuerySlice(q sqlh.IQueries, values interface{}) error {
+//
+// The error for a failed element is an *ElementError with its index, type, SQL, and arguments;
+// database errors are also classified, see sqlh.DBError.  With ContinueOnError the remaining elements
+// are queried and the errors are returned as Errors.
+func (me QueryBinding) QuerySlice(q sqlh.IQueries, values interface{}, opts ...QueryOption) error {
 	v := reflect.ValueOf(values)
 	if v.Kind() != reflect.Slice {
 		return fmt.Errorf("values expects a slice; got %T", values) // TODO Sentinal error
 	}
+	options := newQueryOptions(opts)
+	typ := v.Type().Elem()
 	// Size of slice will be helpful here.
 	size := v.Len()
 	if size == 0 {
 		return nil
-	} else if size == 1 {
-		return me.QueryOne(q, v.Index(0))
+	} else if size == 1 && !options.continueOnError {
+		// With ContinueOnError a single element still runs within a SAVEPOINT so a failure does not
+		// abort a transaction given as q.
+		return me.queryOne(q, v.Index(0), 0, typ, options)
 	}
 	//
-	var tx *sql.Tx
-	var stmt *sql.Stmt
-	var row *sql.Row
-	var err error
-	//
 	// If the calls to Plan succeed then further calls to Fields or Assignables will not error.
 	preparedArgs, err := me.mapper.Prepare(v.Index(0))
 	if err != nil {
@@ -105,74 +233,91 @@ func (me QueryBinding) QuerySlice(q sqlh.IQueries, values interface{}) error {
 	}
 	args, scans := make([]interface{}, len(me.query.Arguments)), make([]interface{}, len(me.query.Scan))
 	//
-	// If original parameter supports transactions...
-	if txer, ok := q.(sqlh.IBegins); ok {
-		if tx, err = txer.Begin(); err != nil {
-			return err
-		}
-		defer tx.Rollback()
-		q = tx
-	}
-	//
-	// QueryRowFunc normalizes the query row call so the same logic can be used with or without prepared statements.
-	type ExecFunc func(args ...interface{}) (sql.Result, error)
-	type QueryRowFunc func(args ...interface{}) *sql.Row
-	var QueryRow QueryRowFunc
-	var Exec ExecFunc
+	// With ContinueOnError the failed elements are returned after the transaction commits.
+	var failed Errors
 	//
-	// Use prepared statement if possible.
-	if pper, ok := q.(sqlh.IPrepares); ok {
-		if stmt, err = pper.Prepare(me.query.SQL); err != nil {
-			return err
-		}
-		defer stmt.Close()
-		Exec = stmt.Exec
-		QueryRow = stmt.QueryRow
-	} else {
-		Exec = func(args ...interface{}) (sql.Result, error) {
-			return q.Exec(me.query.SQL, args...)
-		}
-		QueryRow = func(args ...interface{}) *sql.Row {
-			return q.QueryRow(me.query.SQL, args...)
-		}
-	}
-	//
-	// There's a little bit of copy+paste between both conditions.  Tread carefully when editing the similar portions.
-	if len(me.query.Scan) == 0 {
-		for k := 0; k < size; k++ {
-			elem := v.Index(k)
-			preparedArgs.Rebind(elem)
-			_, _ = preparedArgs.Fields(args)
-			//
-			if _, err = Exec(args...); err != nil {
+	// If original parameter supports transactions the queries run inside one.
+	err = sqlh.Transact(q, func(q sqlh.IQueries) error {
+		var stmt *sqlh.Stmt
+		var err error
+		//
+		// QueryRowFunc normalizes the query row call so the same logic can be used with or without prepared statements.
+		type ExecFunc func(args ...interface{}) (sql.Result, error)
+		type QueryRowFunc func(args ...interface{}) *sql.Row
+		var QueryRow QueryRowFunc
+		var Exec ExecFunc
+		//
+		// Use prepared statement if possible; the observer of q sees each call of the statement.
+		if pper, ok := q.(sqlh.IPrepares); ok {
+			if stmt, err = sqlh.Prepare(pper, me.query.SQL); err != nil {
 				return err
 			}
+			defer stmt.Close()
+			Exec = stmt.Exec
+			QueryRow = stmt.QueryRow
+		} else {
+			Exec = func(args ...interface{}) (sql.Result, error) {
+				return q.Exec(me.query.SQL, args...)
+			}
+			QueryRow = func(args ...interface{}) *sql.Row {
+				return q.QueryRow(me.query.SQL, args...)
+			}
 		}
-	} else {
+		//
 		for k := 0; k < size; k++ {
 			elem := v.Index(k)
 			preparedArgs.Rebind(elem)
 			_, _ = preparedArgs.Fields(args)
-			preparedScans.Rebind(elem)
-			_, _ = preparedScans.Assignables(scans)
+			if len(me.query.Scan) > 0 {
+				preparedScans.Rebind(elem)
+				_, _ = preparedScans.Assignables(scans)
+			}
 			//
-			row = QueryRow(args...)
-			if err = row.Scan(scans...); err != nil {
-				if err != sql.ErrNoRows {
-					return err
-				} else if err == sql.ErrNoRows && me.query.Expect != statements.ExpectRowOrNone {
-					return err
+			var affected bool
+			query := func() (err error) {
+				affected, err = me.run(Exec, QueryRow, args, scans, options)
+				return err
+			}
+			if !options.continueOnError {
+				err = query()
+				options.result.add(me.op, affected, err)
+				if err != nil {
+					return me.fail(err, k, typ, args)
 				}
+				continue
+			}
+			queryErr, err := savepoint(q, query)
+			if err != nil {
+				return err
+			}
+			options.result.add(me.op, affected, queryErr)
+			if queryErr != nil {
+				failed = append(failed, me.fail(queryErr, k, typ, args))
 			}
 		}
+		return nil
+	})
+	if err != nil {
+		return err
+	} else if len(failed) > 0 {
+		return failed
 	}
+	return nil
+}
 
-	//
-	// If we opened a transaction then attempt to commit.
-	if tx != nil {
-		if err = tx.Commit(); err != nil {
-			return err
+// savepoint runs fn within a savepoint of q and returns the error of fn as failed; the savepoint is rolled
+// back if fn fails.  err is returned if a savepoint statement fails.
+func savepoint(q sqlh.IQueries, fn func() error) (failed error, err error) {
+	const name = "sqlh_element"
+	if _, err = q.Exec("SAVEPOINT " + name); err != nil {
+		return nil, errors.Go(err)
+	} else if failed = fn(); failed != nil {
+		if _, err = q.Exec("ROLLBACK TO SAVEPOINT " + name); err != nil {
+			return failed, errors.Go(err).Tag("element", failed.Error())
 		}
+		return failed, nil
+	} else if _, err = q.Exec("RELEASE SAVEPOINT " + name); err != nil {
+		return nil, errors.Go(err)
 	}
-	return nil
+	return nil, nil
 }
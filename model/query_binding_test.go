@@ -3,12 +3,14 @@ package model_test
 import (
 	"database/sql"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/nofeaturesonlybugs/errors"
 	"github.com/nofeaturesonlybugs/set"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/nofeaturesonlybugs/sqlh"
 	"github.com/nofeaturesonlybugs/sqlh/grammar"
 	"github.com/nofeaturesonlybugs/sqlh/model"
 	"github.com/nofeaturesonlybugs/sqlh/model/examples"
@@ -493,3 +495,148 @@ func TestQueryBinding_PreparedMappingErrors(t *testing.T) {
 		chk.NoError(mock.ExpectationsWereMet())
 	})
 }
+
+// pgError has the methods and fields of a Postgres driver error.
+type pgError struct {
+	Code           string
+	ConstraintName string
+	Detail         string
+}
+
+func (me *pgError) Error() string    { return "ERROR: duplicate key (SQLSTATE " + me.Code + ")" }
+func (me *pgError) SQLState() string { return me.Code }
+
+func TestQueryBinding_ClassifyError(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	mdb := examples.NewModels()
+	duplicate := &pgError{Code: "23505", ConstraintName: "addresses_street_key", Detail: "Key (street)=(Elm) already exists."}
+	//
+	// The second element of the slice fails.
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO addresses")
+	mock.ExpectQuery("INSERT INTO addresses").WillReturnRows(sqlmock.NewRows([]string{"pk", "created_tmz", "modified_tmz"}).AddRow(1, time.Now(), time.Now()))
+	mock.ExpectQuery("INSERT INTO addresses").WillReturnError(duplicate)
+	mock.ExpectRollback()
+	err = mdb.Insert(db, []examples.Address{{Street: "Main"}, {Street: "Elm"}})
+	chk.True(errors.Is(err, sqlh.ErrUniqueViolation))
+	element, ok := errors.Original(err).(*model.ElementError)
+	chk.True(ok)
+	classified, ok := element.Err.(*sqlh.DBError)
+	if chk.True(ok) {
+		chk.Equal(1, classified.Index)
+		chk.Equal("addresses_street_key", classified.Constraint)
+		chk.Equal("street", classified.Column)
+		chk.Equal(duplicate, classified.Err)
+	}
+	chk.NoError(mock.ExpectationsWereMet())
+	//
+	// A single model reports no index; errors that are not classified are still element errors.
+	mock.ExpectQuery("INSERT INTO addresses").WillReturnError(duplicate)
+	err = mdb.Insert(db, &examples.Address{Street: "Elm"})
+	chk.True(errors.Is(err, sqlh.ErrUniqueViolation))
+	chk.Equal(-1, errors.Original(err).(*sqlh.DBError).Index)
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO addresses")
+	mock.ExpectQuery("INSERT INTO addresses").WillReturnError(sql.ErrConnDone)
+	mock.ExpectRollback()
+	err = mdb.Insert(db, []examples.Address{{Street: "Main"}, {Street: "Elm"}})
+	chk.True(errors.Is(err, sql.ErrConnDone))
+	chk.Contains(err.Error(), "at index 0 of examples.Address")
+	chk.NoError(mock.ExpectationsWereMet())
+}
+
+func TestQueryBinding_ElementError(t *testing.T) {
+	chk := assert.New(t)
+	//
+	type Account struct {
+		model.TableName `model:"accounts"`
+		Id              int    `json:"id" model:"key,auto"`
+		Email           string `json:"email"`
+		Password        string `json:"password" model:"redact"`
+	}
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	mdb := examples.NewModels()
+	chk.NoError(mdb.Register(Account{}))
+	m, err := mdb.Lookup(Account{})
+	chk.NoError(err)
+	chk.Equal(map[string]bool{"password": true}, m.Redacted)
+	//
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO accounts")
+	mock.ExpectQuery("INSERT INTO accounts").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("INSERT INTO accounts").WillReturnError(sql.ErrConnDone)
+	mock.ExpectRollback()
+	err = mdb.Insert(db, []*Account{{Email: "a@example.com", Password: "secret"}, {Email: "b@example.com", Password: "hunter2"}})
+	chk.True(errors.Is(err, sql.ErrConnDone))
+	element, ok := errors.Original(err).(*model.ElementError)
+	if chk.True(ok) {
+		chk.Equal(1, element.Index)
+		chk.Equal("*model_test.Account", element.Model)
+		chk.Equal(m.Statements.Insert.SQL, element.SQL)
+		chk.Equal([]interface{}{"b@example.com", model.RedactedValue}, element.Args)
+		chk.NotContains(element.Error(), "hunter2")
+	}
+	chk.NoError(mock.ExpectationsWereMet())
+}
+
+func TestQueryBinding_ContinueOnError(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	mdb := examples.NewModels()
+	duplicate := &pgError{Code: "23505"}
+	//
+	// Elements 0 and 2 fail; element 1 is committed.
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO addresses")
+	mock.ExpectExec("SAVEPOINT sqlh_element").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("INSERT INTO addresses").WillReturnError(duplicate)
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT sqlh_element").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SAVEPOINT sqlh_element").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("INSERT INTO addresses").WillReturnRows(sqlmock.NewRows([]string{"pk", "created_tmz", "modified_tmz"}).AddRow(2, time.Now(), time.Now()))
+	mock.ExpectExec("RELEASE SAVEPOINT sqlh_element").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SAVEPOINT sqlh_element").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("INSERT INTO addresses").WillReturnError(duplicate)
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT sqlh_element").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	addresses := []examples.Address{{Street: "Main"}, {Street: "Elm"}, {Street: "Oak"}}
+	err = mdb.Insert(db, addresses, model.ContinueOnError)
+	chk.True(errors.Is(err, sqlh.ErrUniqueViolation))
+	failed, ok := errors.Original(err).(model.Errors)
+	if chk.True(ok) && chk.Len(failed, 2) {
+		chk.Equal(0, failed[0].(*model.ElementError).Index)
+		chk.Equal(2, failed[1].(*model.ElementError).Index)
+	}
+	chk.Equal(2, addresses[1].Id)
+	chk.NoError(mock.ExpectationsWereMet())
+	//
+	// A failed savepoint aborts the slice.
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO addresses")
+	mock.ExpectExec("SAVEPOINT sqlh_element").WillReturnError(sql.ErrConnDone)
+	mock.ExpectRollback()
+	err = mdb.Insert(db, addresses, model.ContinueOnError)
+	chk.True(errors.Is(err, sql.ErrConnDone))
+	chk.NoError(mock.ExpectationsWereMet())
+	//
+	// A single element runs within a SAVEPOINT and is returned as Errors.
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO addresses")
+	mock.ExpectExec("SAVEPOINT sqlh_element").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("INSERT INTO addresses").WillReturnError(duplicate)
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT sqlh_element").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	err = mdb.Insert(db, addresses[:1], model.ContinueOnError)
+	failed, ok = errors.Original(err).(model.Errors)
+	chk.True(ok)
+	chk.Len(failed, 1)
+	chk.NoError(mock.ExpectationsWereMet())
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/nofeaturesonlybugs/sqlh/schema"
+)
+
+// Relationship is a foreign key from the table of one registered model to another table.
+type Relationship struct {
+	// Type is the registered type that owns the foreign key.
+	Type reflect.Type
+	// Table is the qualified name of the table that owns the foreign key.
+	Table string
+	// ForeignKey is the foreign key as declared with model:"fk(table.column)".
+	ForeignKey schema.ForeignKey
+	// RefType is the registered type whose table is ForeignKey.RefTable; it is nil if no
+	// registered model uses the referenced table.
+	RefType reflect.Type
+}
+
+// Relationships returns the foreign keys of every registered model ordered by the names of the
+// registered types and then the order of the fields declaring them.  Together they form the graph
+// of relationships between registered models, for example for generating documentation or
+// for ordering work between tables.
+func (me *Models) Relationships() []Relationship {
+	if me == nil {
+		return nil
+	}
+	registered := me.models()
+	types := me.registeredTypes()
+	byTable := map[string]reflect.Type{}
+	for _, typ := range types {
+		if name := registered[typ].Table.QualifiedName(); byTable[name] == nil {
+			byTable[name] = typ
+		}
+	}
+	var rv []Relationship
+	for _, typ := range types {
+		table := registered[typ].Table
+		for _, foreignKey := range table.ForeignKeys {
+			rv = append(rv, Relationship{
+				Type:       typ,
+				Table:      table.QualifiedName(),
+				ForeignKey: foreignKey,
+				RefType:    byTable[foreignKey.RefTable],
+			})
+		}
+	}
+	return rv
+}
+
+// dependencyOrder orders tables so referenced tables come before the tables referencing them.
+// The order of tables is otherwise preserved; references to tables not in tables, to the same
+// table, and within cycles are ignored.
+func dependencyOrder(tables []schema.Table) []schema.Table {
+	position := map[string]int{}
+	for k, table := range tables {
+		position[table.QualifiedName()] = k
+	}
+	rv := make([]schema.Table, 0, len(tables))
+	// state is 1 while a table's references are visited and 2 once it is in rv.
+	state := make([]int, len(tables))
+	var visit func(k int)
+	visit = func(k int) {
+		if state[k] != 0 {
+			return
+		}
+		state[k] = 1
+		var references []int
+		for _, foreignKey := range tables[k].ForeignKeys {
+			if n, ok := position[foreignKey.RefTable]; ok && n != k {
+				references = append(references, n)
+			}
+		}
+		sort.Ints(references)
+		for _, n := range references {
+			visit(n)
+		}
+		state[k] = 2
+		rv = append(rv, tables[k])
+	}
+	for k := range tables {
+		visit(k)
+	}
+	return rv
+}
@@ -0,0 +1,128 @@
+package model_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nofeaturesonlybugs/errors"
+	"github.com/nofeaturesonlybugs/set"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nofeaturesonlybugs/sqlh/grammar"
+	"github.com/nofeaturesonlybugs/sqlh/model"
+	"github.com/nofeaturesonlybugs/sqlh/model/examples"
+	"github.com/nofeaturesonlybugs/sqlh/schema"
+)
+
+func TestModels_RegisterForeignKeys(t *testing.T) {
+	chk := assert.New(t)
+	//
+	type Order struct {
+		model.TableName `model:"orders"`
+		Id              int  `db:"pk" model:"key,auto"`
+		PersonId        int  `db:"person_fk" model:"fk(people.pk),ondelete=cascade"`
+		AddressId       *int `db:"address_fk" model:"fk(sales.addresses.pk),ondelete=setnull"`
+	}
+	mdb := &model.Models{
+		Mapper:  &set.Mapper{Tags: []string{"db"}},
+		Grammar: grammar.Postgres,
+	}
+	chk.NoError(mdb.Register(Order{}))
+	mdl, err := mdb.Lookup(Order{})
+	chk.NoError(err)
+	chk.Equal([]schema.ForeignKey{
+		{Columns: []schema.Column{{Name: "person_fk", GoType: 0, SqlType: "BIGINT"}}, RefTable: "people", RefColumns: []string{"pk"}, OnDelete: "CASCADE"},
+		{Columns: []schema.Column{{Name: "address_fk", GoType: (*int)(nil), SqlType: "BIGINT", Nullable: true}}, RefTable: "sales.addresses", RefColumns: []string{"pk"}, OnDelete: "SET NULL"},
+	}, mdl.Table.ForeignKeys)
+	//
+	// Foreign keys are created with the table.
+	ddl, err := mdb.DDL()
+	chk.NoError(err)
+	chk.Contains(ddl[0], "FOREIGN KEY ( person_fk ) REFERENCES people ( pk ) ON DELETE CASCADE")
+	chk.Contains(ddl[0], "FOREIGN KEY ( address_fk ) REFERENCES sales.addresses ( pk ) ON DELETE SET NULL")
+	//
+	// Malformed options are tag conflicts.
+	type Bad struct {
+		model.TableName `model:"bad"`
+		Id              int `db:"pk" model:"key,auto"`
+		A               int `db:"a" model:"fk(people)"`
+		B               int `db:"b" model:"fk(people.pk),ondelete=explode"`
+		C               int `db:"c" model:"ondelete=cascade"`
+	}
+	err = mdb.Register(Bad{})
+	if chk.IsType(model.Errors{}, err) {
+		chk.Len(err.(model.Errors), 3)
+		for _, problem := range err.(model.Errors) {
+			chk.Equal(model.ErrTagConflict, errors.Original(problem))
+		}
+	}
+}
+
+func TestModels_Relationships(t *testing.T) {
+	chk := assert.New(t)
+	//
+	type Aardvark struct {
+		model.TableName `model:"aardvarks"`
+		Id              int `db:"pk" model:"key,auto"`
+		OwnerId         int `db:"owner_fk" model:"fk(people.pk)"`
+		ParentId        int `db:"parent_fk" model:"fk(aardvarks.pk)"`
+		ZooId           int `db:"zoo_fk" model:"fk(zoos.pk)"`
+	}
+	mdb := examples.NewModels()
+	mdb.MustRegister(Aardvark{})
+	//
+	relationships := mdb.Relationships()
+	if chk.Len(relationships, 5) {
+		chk.Equal(reflect.TypeOf(examples.PersonAddress{}), relationships[0].Type)
+		chk.Equal("relate_people_addresses", relationships[0].Table)
+		chk.Equal(reflect.TypeOf(examples.Person{}), relationships[0].RefType)
+		chk.Equal(reflect.TypeOf(examples.Address{}), relationships[1].RefType)
+		chk.Equal(reflect.TypeOf(Aardvark{}), relationships[2].Type)
+		chk.Equal(reflect.TypeOf(examples.Person{}), relationships[2].RefType)
+		chk.Equal(reflect.TypeOf(Aardvark{}), relationships[3].RefType)
+		chk.Equal("zoos", relationships[4].ForeignKey.RefTable)
+		chk.Nil(relationships[4].RefType)
+	}
+	//
+	var nilModels *model.Models
+	chk.Nil(nilModels.Relationships())
+}
+
+func TestModels_TablesDependencyOrder(t *testing.T) {
+	chk := assert.New(t)
+	//
+	// Child sorts before Parent but references it so its table is ordered after parents.
+	type Child struct {
+		model.TableName `model:"children"`
+		Id              int `db:"pk" model:"key,auto"`
+		ParentId        int `db:"parent_fk" model:"fk(parents.pk)"`
+	}
+	type Parent struct {
+		model.TableName `model:"parents"`
+		Id              int `db:"pk" model:"key,auto"`
+		GroupId         int `db:"group_fk" model:"fk(groups.pk)"`
+	}
+	type Group struct {
+		model.TableName `model:"groups"`
+		Id              int `db:"pk" model:"key,auto"`
+		OwnerId         int `db:"owner_fk" model:"fk(children.pk)"`
+	}
+	mdb := &model.Models{
+		Mapper:  &set.Mapper{Tags: []string{"db"}},
+		Grammar: grammar.Sqlite,
+	}
+	mdb.MustRegister(Child{})
+	mdb.MustRegister(Parent{})
+	names := func() []string {
+		rv := []string{}
+		for _, table := range mdb.Tables() {
+			rv = append(rv, table.QualifiedName())
+		}
+		return rv
+	}
+	chk.Equal([]string{"parents", "children"}, names())
+	//
+	// Cycles do not prevent ordering.
+	mdb.MustRegister(Group{})
+	chk.Equal([]string{"groups", "parents", "children"}, names())
+}
@@ -0,0 +1,194 @@
+//go:build go1.21
+// +build go1.21
+
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/nofeaturesonlybugs/errors"
+
+	"github.com/nofeaturesonlybugs/sqlh"
+	"github.com/nofeaturesonlybugs/sqlh/schema"
+)
+
+// Repo is a type safe repository for the model T registered with Models; T is looked up on every
+// call so types registered by AutoRegister can be used without registering them first.
+//
+// ctx is checked before the queries of a call run and is the context given to the observer of the
+// queries; see sqlh.ObserveContext.  sqlh.IQueries does not accept a context so queries are not
+// cancelled once started.
+type Repo[T any] struct {
+	Models *Models
+}
+
+// model returns the model of T.
+func (me *Repo[T]) model() (*Model, error) {
+	if me == nil {
+		return nil, errors.NilReceiver()
+	} else if me.Models == nil {
+		return nil, errors.NilMember("Models")
+	}
+	var zero T
+	return me.Models.Lookup(zero)
+}
+
+// queries returns Q observed with ctx if Models or Q has an observer.
+func (me *Repo[T]) queries(ctx context.Context, Q sqlh.IQueries) (sqlh.IQueries, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, errors.Go(err)
+	}
+	observer := me.Models.Observer
+	if observer == nil {
+		observer = sqlh.ObserverOf(Q)
+	}
+	return sqlh.ObserveContext(ctx, Q, observer), nil
+}
+
+// Insert inserts value; see Models.Insert.
+func (me *Repo[T]) Insert(ctx context.Context, Q sqlh.IQueries, value *T, opts ...QueryOption) error {
+	return me.write(ctx, Q, value, (*Models).Insert, opts)
+}
+
+// InsertMany inserts values within one transaction; see Models.Insert.  Columns returned by the
+// database are scanned into the elements of values.
+func (me *Repo[T]) InsertMany(ctx context.Context, Q sqlh.IQueries, values []T, opts ...QueryOption) error {
+	return me.write(ctx, Q, values, (*Models).Insert, opts)
+}
+
+// Update updates value; see Models.Update.
+func (me *Repo[T]) Update(ctx context.Context, Q sqlh.IQueries, value *T, opts ...QueryOption) error {
+	return me.write(ctx, Q, value, (*Models).Update, opts)
+}
+
+// Save inserts or updates value; see Models.Save.
+func (me *Repo[T]) Save(ctx context.Context, Q sqlh.IQueries, value *T, opts ...QueryOption) error {
+	return me.write(ctx, Q, value, (*Models).Save, opts)
+}
+
+// Delete deletes value by its primary key; see Models.Delete.
+func (me *Repo[T]) Delete(ctx context.Context, Q sqlh.IQueries, value *T, opts ...QueryOption) error {
+	return me.write(ctx, Q, value, (*Models).Delete, opts)
+}
+
+// write calls fn, a method of Models, with value.
+func (me *Repo[T]) write(ctx context.Context, Q sqlh.IQueries, value interface{}, fn func(*Models, sqlh.IQueries, interface{}, ...QueryOption) error, opts []QueryOption) error {
+	if _, err := me.model(); err != nil {
+		return errors.Go(err)
+	} else if Q, err = me.queries(ctx, Q); err != nil {
+		return err
+	} else if err = fn(me.Models, Q, value, opts...); err != nil {
+		return errors.Go(err)
+	}
+	return nil
+}
+
+// Find returns the row with the primary key key; composite keys are given in the order of the
+// primary key columns.  If the row does not exist the error is a *sqlh.DBError with Kind
+// sqlh.ErrNotFound.
+func (me *Repo[T]) Find(ctx context.Context, Q sqlh.IQueries, key ...interface{}) (T, error) {
+	var zero T
+	model, err := me.model()
+	if err != nil {
+		return zero, errors.Go(err)
+	}
+	keys := schema.ColumnNames(model.Table.PrimaryKey.Columns)
+	if len(keys) == 0 {
+		return zero, errors.Go(ErrUnsupported).Tag("FIND", fmt.Sprintf("%T has no primary key", zero))
+	} else if len(keys) != len(key) {
+		return zero, errors.Errorf("%T has %v key columns; got %v values", zero, len(keys), len(key))
+	}
+	query := me.Where(keys[0], key[0])
+	for k := 1; k < len(keys); k++ {
+		query = query.Where(keys[k], key[k])
+	}
+	rows, err := query.All(ctx, Q)
+	if err != nil {
+		return zero, errors.Go(err)
+	} else if len(rows) == 0 {
+		return zero, sqlh.ClassifyError(nil, sql.ErrNoRows)
+	}
+	return rows[0], nil
+}
+
+// Where returns a RepoQuery selecting the rows where column equals value.
+func (me *Repo[T]) Where(column string, value interface{}) *RepoQuery[T] {
+	return (&RepoQuery[T]{repo: me}).Where(column, value)
+}
+
+// RepoQuery selects rows of the model T; it is created with Repo.Where.
+type RepoQuery[T any] struct {
+	repo       *Repo[T]
+	conditions []condition
+}
+
+// condition is a column compared to a value with equals; a nil value is compared with IS NULL.
+type condition struct {
+	column string
+	value  interface{}
+}
+
+// Where returns a copy of the query that also requires column to equal value; a nil value
+// requires column to be NULL.
+func (me *RepoQuery[T]) Where(column string, value interface{}) *RepoQuery[T] {
+	rv := &RepoQuery[T]{repo: me.repo}
+	rv.conditions = append(append(rv.conditions, me.conditions...), condition{column: column, value: value})
+	return rv
+}
+
+// All returns the rows matching every condition of the query.  Columns that are not columns of
+// the model return ErrUnknownColumn.
+func (me *RepoQuery[T]) All(ctx context.Context, Q sqlh.IQueries) ([]T, error) {
+	if me == nil {
+		return nil, errors.NilReceiver()
+	}
+	model, err := me.repo.model()
+	if err != nil {
+		return nil, errors.Go(err)
+	}
+	query, args, err := me.sql(model)
+	if err != nil {
+		return nil, errors.Go(err)
+	} else if Q, err = me.repo.queries(ctx, Q); err != nil {
+		return nil, err
+	}
+	var rv []T
+	scanner := &sqlh.Scanner{Mapper: me.repo.Models.Mapper}
+	if err = scanner.Select(me.repo.Models.queries(Q, "SELECT", model, reflect.TypeOf(rv)), &rv, query, args...); err != nil {
+		return nil, errors.Go(sqlh.ClassifyError(me.repo.Models.classifier(), err)).Tag("SQL", query)
+	}
+	return rv, nil
+}
+
+// sql returns the SELECT statement and arguments of the query.
+func (me *RepoQuery[T]) sql(model *Model) (string, []interface{}, error) {
+	g := me.repo.Models.grammar()
+	columns := append(append([]string{}, schema.ColumnNames(model.Table.PrimaryKey.Columns)...), schema.ColumnNames(model.Table.Columns)...)
+	known := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		known[column] = true
+	}
+	where, args := make([]string, len(me.conditions)), []interface{}{}
+	for k, condition := range me.conditions {
+		if !known[condition.column] {
+			return "", nil, errors.Go(ErrUnknownColumn).Tag("column", condition.column).Tag("table", model.Table.QualifiedName())
+		} else if condition.value == nil {
+			where[k] = g.Quote(condition.column) + " IS NULL"
+			continue
+		}
+		where[k] = g.Quote(condition.column) + " = " + param(g, len(args))
+		args = append(args, condition.value)
+	}
+	query := "SELECT " + strings.Join(quoteAll(g.Quote, columns), ", ") + " FROM " + g.Quote(model.Table.QualifiedName())
+	if len(where) > 0 {
+		query = query + " WHERE " + strings.Join(where, " AND ")
+	}
+	return query, args, nil
+}
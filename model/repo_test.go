@@ -0,0 +1,152 @@
+//go:build go1.21
+// +build go1.21
+
+package model_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/nofeaturesonlybugs/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nofeaturesonlybugs/sqlh"
+	"github.com/nofeaturesonlybugs/sqlh/model"
+	"github.com/nofeaturesonlybugs/sqlh/model/examples"
+)
+
+// contextRecorder is a sqlh.Observer that records the contexts of queries.
+type contextRecorder struct {
+	contexts []context.Context
+}
+
+func (me *contextRecorder) BeforeQuery(ctx context.Context, query string, args []interface{}) context.Context {
+	me.contexts = append(me.contexts, ctx)
+	return ctx
+}
+
+func (me *contextRecorder) AfterQuery(ctx context.Context, query string, args []interface{}, duration time.Duration, rowsAffected int64, err error) {
+}
+
+func TestRepo_Insert(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	repo := &model.Repo[examples.Address]{Models: examples.NewModels()}
+	ctx := context.Background()
+	//
+	mock.ExpectQuery("INSERT INTO addresses").WithArgs("Main", "", "", "").
+		WillReturnRows(sqlmock.NewRows([]string{"pk", "created_tmz", "modified_tmz"}).AddRow(1, time.Now(), time.Now()))
+	address := &examples.Address{Street: "Main"}
+	chk.NoError(repo.Insert(ctx, db, address))
+	chk.Equal(1, address.Id)
+	//
+	mock.ExpectBegin()
+	prepare := mock.ExpectPrepare("INSERT INTO addresses")
+	for k := 0; k < 2; k++ {
+		prepare.ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"pk", "created_tmz", "modified_tmz"}).AddRow(k+2, time.Now(), time.Now()))
+	}
+	mock.ExpectCommit()
+	addresses := []examples.Address{{Street: "Elm"}, {Street: "Oak"}}
+	var result model.Result
+	chk.NoError(repo.InsertMany(ctx, db, addresses, &result))
+	chk.Equal(2, addresses[0].Id)
+	chk.Equal(3, addresses[1].Id)
+	chk.Equal(2, result.Inserted)
+	//
+	mock.ExpectExec("DELETE FROM addresses").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+	chk.True(errors.Is(repo.Delete(ctx, db, address), sqlh.ErrNotFound))
+	chk.NoError(mock.ExpectationsWereMet())
+	//
+	// Canceled contexts do not run queries.
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	chk.True(errors.Is(repo.Insert(canceled, db, address), context.Canceled))
+	_, err = repo.Find(canceled, db, 1)
+	chk.True(errors.Is(err, context.Canceled))
+	//
+	// Unregistered types and nil Models return errors.
+	chk.Error((&model.Repo[struct{ A int }]{Models: examples.NewModels()}).Insert(ctx, db, &struct{ A int }{}))
+	chk.Error((&model.Repo[examples.Address]{}).Insert(ctx, db, address))
+	chk.NoError(mock.ExpectationsWereMet())
+}
+
+func TestRepo_Find(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	repo := &model.Repo[examples.Address]{Models: examples.NewModels()}
+	ctx := context.Background()
+	const query = "SELECT pk, created_tmz, modified_tmz, street, city, state, zip FROM addresses"
+	//
+	mock.ExpectQuery(regexp.QuoteMeta(query + " WHERE pk = $1")).WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"pk", "street"}).AddRow(10, "Main"))
+	address, err := repo.Find(ctx, db, 10)
+	chk.NoError(err)
+	chk.Equal(examples.Address{Id: 10, Street: "Main"}, address)
+	//
+	mock.ExpectQuery(regexp.QuoteMeta(query + " WHERE pk = $1")).WithArgs(11).
+		WillReturnRows(sqlmock.NewRows([]string{"pk", "street"}))
+	address, err = repo.Find(ctx, db, 11)
+	chk.True(errors.Is(err, sqlh.ErrNotFound))
+	chk.Equal(examples.Address{}, address)
+	//
+	_, err = repo.Find(ctx, db, 1, 2)
+	chk.Error(err)
+	chk.NoError(mock.ExpectationsWereMet())
+	//
+	// Composite keys are given in the order of the key columns.
+	related := &model.Repo[examples.PersonAddress]{Models: repo.Models}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT person_fk, address_fk FROM relate_people_addresses WHERE person_fk = $1 AND address_fk = $2")).WithArgs(1, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"person_fk", "address_fk"}).AddRow(1, 10))
+	relation, err := related.Find(ctx, db, 1, 10)
+	chk.NoError(err)
+	chk.Equal(examples.PersonAddress{PersonId: 1, AddressId: 10}, relation)
+	chk.NoError(mock.ExpectationsWereMet())
+}
+
+func TestRepo_Where(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	rec := &contextRecorder{}
+	mdb := examples.NewModels()
+	mdb.Observer = rec
+	repo := &model.Repo[examples.Address]{Models: mdb}
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "request")
+	const query = "SELECT pk, created_tmz, modified_tmz, street, city, state, zip FROM addresses"
+	//
+	mock.ExpectQuery(regexp.QuoteMeta(query+" WHERE city = $1 AND zip IS NULL AND state = $2")).WithArgs("Springfield", "IL").
+		WillReturnRows(sqlmock.NewRows([]string{"pk", "city"}).AddRow(1, "Springfield").AddRow(2, "Springfield"))
+	byCity := repo.Where("city", "Springfield")
+	addresses, err := byCity.Where("zip", nil).Where("state", "IL").All(ctx, db)
+	chk.NoError(err)
+	chk.Equal([]examples.Address{{Id: 1, City: "Springfield"}, {Id: 2, City: "Springfield"}}, addresses)
+	if chk.Len(rec.contexts, 1) {
+		chk.Equal("request", rec.contexts[0].Value(key{}))
+		op, ok := sqlh.OperationFrom(rec.contexts[0])
+		chk.True(ok)
+		chk.Equal(sqlh.Operation{Name: "SELECT", Model: "examples.Address", Table: "addresses"}, op)
+	}
+	//
+	// Where returns a copy so byCity is unchanged.
+	mock.ExpectQuery(regexp.QuoteMeta(query + " WHERE city = $1")).WithArgs("Springfield").
+		WillReturnRows(sqlmock.NewRows([]string{"pk"}))
+	addresses, err = byCity.All(ctx, db)
+	chk.NoError(err)
+	chk.Empty(addresses)
+	chk.NoError(mock.ExpectationsWereMet())
+	//
+	_, err = repo.Where("country", "US").All(ctx, db)
+	chk.True(errors.Is(err, model.ErrUnknownColumn))
+}
@@ -0,0 +1,47 @@
+package model
+
+// Result counts the elements written by the Models methods given it as a QueryOption; pass the
+// same *Result to several calls to total them.
+//
+// Databases do not report if an upsert inserted or updated a row so such elements are counted as
+// Upserted.  Elements matching no rows, such as updates of missing rows with IgnoreNotFound or
+// upserts of insert only models that already exist, are counted as Unchanged.  When an error is
+// returned without ContinueOnError the transaction is rolled back and the counts only describe the
+// statements that ran.
+type Result struct {
+	Inserted  int
+	Updated   int
+	Upserted  int
+	Deleted   int
+	Unchanged int
+	Failed    int
+}
+
+// applyQuery sets the Result of options.
+func (me *Result) applyQuery(options *queryOptions) {
+	options.result = me
+}
+
+// add counts an element of operation op; affected is false if the element matched no rows and err is
+// the error of the element.
+func (me *Result) add(op string, affected bool, err error) {
+	if me == nil {
+		return
+	} else if err != nil {
+		me.Failed++
+		return
+	} else if !affected {
+		me.Unchanged++
+		return
+	}
+	switch op {
+	case "INSERT":
+		me.Inserted++
+	case "UPDATE":
+		me.Updated++
+	case "UPSERT":
+		me.Upserted++
+	case "DELETE":
+		me.Deleted++
+	}
+}
@@ -0,0 +1,136 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/nofeaturesonlybugs/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nofeaturesonlybugs/sqlh"
+	"github.com/nofeaturesonlybugs/sqlh/grammar"
+	"github.com/nofeaturesonlybugs/sqlh/model"
+	"github.com/nofeaturesonlybugs/sqlh/model/examples"
+)
+
+// Tag has no columns populated by the database so UPDATE uses Exec.
+type Tag struct {
+	model.TableName `model:"tags"`
+	Id              int    `json:"id" model:"key,auto"`
+	Name            string `json:"name"`
+}
+
+func TestModels_UpdateNotFound(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	mdb := examples.NewModels()
+	chk.NoError(mdb.Register(Tag{}))
+	//
+	// Exec reports zero rows affected.
+	mock.ExpectExec("UPDATE tags").WillReturnResult(sqlmock.NewResult(0, 0))
+	err = mdb.Update(db, &Tag{Id: 1, Name: "go"})
+	chk.True(errors.Is(err, sqlh.ErrNotFound))
+	mock.ExpectExec("UPDATE tags").WillReturnResult(sqlmock.NewResult(0, 0))
+	chk.NoError(mdb.Update(db, &Tag{Id: 1, Name: "go"}, model.IgnoreNotFound))
+	// RETURNING returns no row.
+	mock.ExpectQuery("UPDATE addresses").WillReturnRows(sqlmock.NewRows([]string{"modified_tmz"}))
+	err = mdb.Update(db, &examples.Address{Id: 10, Street: "Elm"})
+	chk.True(errors.Is(err, sqlh.ErrNotFound))
+	mock.ExpectQuery("UPDATE addresses").WillReturnRows(sqlmock.NewRows([]string{"modified_tmz"}))
+	chk.NoError(mdb.Update(db, &examples.Address{Id: 10, Street: "Elm"}, model.IgnoreNotFound))
+	chk.NoError(mock.ExpectationsWereMet())
+	//
+	// SQLite UPDATE ... RETURNING expects a row; IgnoreNotFound still counts a missing row as unchanged.
+	type Note struct {
+		model.TableName `model:"notes"`
+		Id              int       `json:"id" model:"key,auto"`
+		Text            string    `json:"text"`
+		Modified        time.Time `json:"modified" model:"updated"`
+	}
+	sqlite := &model.Models{Mapper: mdb.Mapper, Grammar: grammar.Sqlite}
+	chk.NoError(sqlite.Register(Note{}))
+	mock.ExpectQuery("UPDATE notes").WillReturnRows(sqlmock.NewRows([]string{"modified"}))
+	err = sqlite.Update(db, &Note{Id: 1, Text: "a"})
+	chk.True(errors.Is(err, sqlh.ErrNotFound))
+	mock.ExpectQuery("UPDATE notes").WillReturnRows(sqlmock.NewRows([]string{"modified"}))
+	chk.NoError(sqlite.Update(db, &Note{Id: 1, Text: "a"}, model.IgnoreNotFound))
+	var result model.Result
+	mock.ExpectBegin()
+	prepare := mock.ExpectPrepare("UPDATE notes")
+	prepare.ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"modified"}).AddRow(time.Now()))
+	prepare.ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"modified"}))
+	mock.ExpectCommit()
+	chk.NoError(sqlite.Update(db, []Note{{Id: 1}, {Id: 2}}, model.IgnoreNotFound, &result))
+	chk.Equal(model.Result{Updated: 1, Unchanged: 1}, result)
+	chk.NoError(mock.ExpectationsWereMet())
+	//
+	// The missing element of a slice is reported by index.
+	mock.ExpectBegin()
+	prepare = mock.ExpectPrepare("UPDATE tags")
+	prepare.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 1))
+	prepare.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	err = mdb.Update(db, []Tag{{Id: 1}, {Id: 2}})
+	chk.True(errors.Is(err, sqlh.ErrNotFound))
+	chk.Equal(1, errors.Original(err).(*model.ElementError).Index)
+	chk.NoError(mock.ExpectationsWereMet())
+}
+
+func TestModels_Delete(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	mdb := examples.NewModels()
+	chk.NoError(mdb.Register(Tag{}))
+	//
+	mock.ExpectExec("DELETE FROM tags").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	chk.NoError(mdb.Delete(db, &Tag{Id: 1}))
+	mock.ExpectExec("DELETE FROM tags").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+	chk.True(errors.Is(mdb.Delete(db, &Tag{Id: 1}), sqlh.ErrNotFound))
+	//
+	var result model.Result
+	mock.ExpectBegin()
+	prepare := mock.ExpectPrepare("DELETE FROM tags")
+	prepare.ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	prepare.ExpectExec().WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 0))
+	prepare.ExpectExec().WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	chk.NoError(mdb.Delete(db, []*Tag{{Id: 1}, {Id: 2}, {Id: 3}}, model.IgnoreNotFound, &result))
+	chk.Equal(model.Result{Deleted: 2, Unchanged: 1}, result)
+	chk.NoError(mock.ExpectationsWereMet())
+	//
+	err = mdb.Delete(db, &struct{}{})
+	chk.Error(err)
+}
+
+func TestModels_Result(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	mdb := examples.NewModels()
+	chk.NoError(mdb.Register(Tag{}))
+	//
+	var result model.Result
+	mock.ExpectBegin()
+	prepare := mock.ExpectPrepare("INSERT INTO tags")
+	prepare.ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	prepare.ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectCommit()
+	chk.NoError(mdb.Insert(db, []Tag{{Name: "a"}, {Name: "b"}}, &result))
+	mock.ExpectExec("UPDATE tags").WillReturnResult(sqlmock.NewResult(0, 1))
+	chk.NoError(mdb.Update(db, &Tag{Id: 1, Name: "c"}, &result))
+	mock.ExpectQuery("UPDATE addresses").WillReturnRows(sqlmock.NewRows([]string{"modified_tmz"}).AddRow(time.Now()))
+	chk.NoError(mdb.Save(db, &examples.Address{Id: 10}, &result))
+	mock.ExpectExec("UPDATE tags").WillReturnError(sqlmock.ErrCancelled)
+	chk.Error(mdb.Update(db, &Tag{Id: 1, Name: "c"}, &result))
+	chk.Equal(model.Result{Inserted: 2, Updated: 2, Failed: 1}, result)
+	chk.NoError(mock.ExpectationsWereMet())
+}
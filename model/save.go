@@ -0,0 +1,174 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/nofeaturesonlybugs/errors"
+
+	"github.com/nofeaturesonlybugs/sqlh"
+)
+
+// isNew returns true if v, a struct of the model, has zero values in every InsertUpdatePaths field and
+// should be inserted.
+func (me *Model) isNew(v reflect.Value) bool {
+	for _, path := range me.InsertUpdatePaths {
+		if !path.Value(v).IsZero() {
+			// A non-zero field value means update.
+			return false
+		}
+	}
+	return true
+}
+
+// saveMode returns how Models.Save writes v, a struct of the model: Insert, Update, or Upsert.
+func (me *Model) saveMode(v reflect.Value) SaveMode {
+	if me.SaveStrategy != nil {
+		elem := v
+		if elem.CanAddr() {
+			elem = elem.Addr()
+		} else {
+			elem = reflect.New(v.Type())
+			elem.Elem().Set(v)
+		}
+		switch mode := me.SaveStrategy(elem.Interface()); mode {
+		case Insert, Update, Upsert:
+			return mode
+		}
+	}
+	switch me.SaveMode {
+	case InsertOrUpdate:
+		if me.isNew(v) {
+			return Insert
+		}
+		return Update
+	}
+	return me.SaveMode
+}
+
+// insertUpdaterStrategy is the SaveStrategy of models implementing InsertUpdater.
+func insertUpdaterStrategy(elem interface{}) SaveMode {
+	if elem.(InsertUpdater).IsNew() {
+		return Insert
+	}
+	return Update
+}
+
+// saveGroup is the elements of a slice saved by one operation; indexes are the indexes of the elements
+// within the original slice.
+type saveGroup struct {
+	values  reflect.Value
+	indexes []int
+}
+
+// add appends the element at index k of the original slice; elem is the address of the element.
+func (me *saveGroup) add(k int, elem reflect.Value) {
+	me.values = reflect.Append(me.values, elem)
+	me.indexes = append(me.indexes, k)
+}
+
+// reindex replaces the indexes of the elements reported by err with the indexes of the original slice.
+func (me *saveGroup) reindex(err error) {
+	var failed Errors
+	switch original := errors.Original(err).(type) {
+	case *ElementError:
+		failed = Errors{original}
+	case Errors:
+		failed = original
+	}
+	for _, err := range failed {
+		if element, ok := err.(*ElementError); ok && element.Index >= 0 && element.Index < len(me.indexes) {
+			element.Index = me.indexes[element.Index]
+			if classified, ok := element.Err.(*sqlh.DBError); ok {
+				classified.Index = element.Index
+			}
+		}
+	}
+}
+
+// saveSlice saves the slice v.  Each element is inserted, updated, or upserted according to
+// model.saveMode; the groups run in one transaction and each group uses a prepared statement.  The
+// groups hold the addresses of the elements so values scanned by the queries are written to v.
+func (me *Models) saveSlice(Q sqlh.IQueries, model *Model, v reflect.Value, opts []QueryOption) error {
+	size := v.Len()
+	if size == 0 {
+		return nil
+	}
+	typ := derefType(v.Type().Elem())
+	groups := map[SaveMode]*saveGroup{}
+	for _, mode := range []SaveMode{Insert, Update, Upsert} {
+		groups[mode] = &saveGroup{values: reflect.MakeSlice(reflect.SliceOf(reflect.PtrTo(typ)), 0, size)}
+	}
+	for k := 0; k < size; k++ {
+		elem := v.Index(k)
+		for ; elem.Kind() == reflect.Ptr; elem = elem.Elem() {
+			if elem.IsNil() {
+				return errors.Go(ErrUnsupported).Tag("nil pointer", fmt.Sprintf("%v at index %v", elem.Type(), k))
+			}
+		}
+		mode := model.saveMode(elem)
+		group, ok := groups[mode]
+		if !ok {
+			return errors.Go(ErrUnsupported).Tag("SAVE", fmt.Sprintf("%v at index %v", typ, k))
+		}
+		group.add(k, elem.Addr())
+	}
+	saves := []struct {
+		*saveGroup
+		save func(Q sqlh.IQueries, value interface{}, opts ...QueryOption) error
+	}{
+		{groups[Insert], me.Insert},
+		{groups[Update], me.Update},
+		{groups[Upsert], me.Upsert},
+	}
+	//
+	// A slice saved by one operation is saved as is.
+	for _, group := range saves {
+		if len(group.indexes) == size {
+			return group.save(Q, v.Interface(), opts...)
+		}
+	}
+	//
+	// With ContinueOnError the failed elements of every group are returned after the transaction commits.
+	options := newQueryOptions(opts)
+	var failed Errors
+	err := sqlh.Transact(me.queries(Q, "", nil, nil), func(Q sqlh.IQueries) error {
+		for _, group := range saves {
+			if len(group.indexes) == 0 {
+				continue
+			}
+			err := group.save(Q, group.values.Interface(), opts...)
+			if err == nil {
+				continue
+			}
+			group.reindex(err)
+			if elements, ok := errors.Original(err).(Errors); ok && options.continueOnError {
+				failed = append(failed, elements...)
+				continue
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Go(err)
+	} else if len(failed) > 0 {
+		sortErrors(failed)
+		return failed
+	}
+	return nil
+}
+
+// sortErrors sorts the *ElementError values of failed by index.
+func sortErrors(failed Errors) {
+	index := func(err error) int {
+		if element, ok := err.(*ElementError); ok {
+			return element.Index
+		}
+		return -1
+	}
+	sort.SliceStable(failed, func(i, j int) bool {
+		return index(failed[i]) < index(failed[j])
+	})
+}
@@ -1,5 +1,7 @@
 package model
 
+import "reflect"
+
 // SaveMode describes how a model should be saved when passed to Models.Save method.
 type SaveMode int
 
@@ -16,4 +18,25 @@ const (
 
 	// Models with at least one key field that is not auto must use upsert.
 	Upsert
+
+	// Update is returned by a SaveStrategy to update an element; models are never
+	// registered with it.
+	Update
 )
+
+// InsertUpdater is implemented by models that know if they are new, such as models with natural
+// keys or a persisted flag.  Models.Save inserts values whose IsNew returns true and updates the
+// others instead of inspecting their key fields.
+type InsertUpdater interface {
+	IsNew() bool
+}
+
+// SaveStrategy decides how Models.Save writes an element of a model and is passed to Models.Register
+// as an option; elem is a pointer to the element.  It returns Insert, Update, or Upsert; any other
+// value uses the decision Models.Save makes without a strategy.
+//
+// A SaveStrategy takes precedence over InsertUpdater.
+type SaveStrategy func(elem interface{}) SaveMode
+
+// typeInsertUpdater is the reflect.Type of InsertUpdater.
+var typeInsertUpdater = reflect.TypeOf((*InsertUpdater)(nil)).Elem()
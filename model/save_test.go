@@ -0,0 +1,160 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/nofeaturesonlybugs/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nofeaturesonlybugs/sqlh"
+	"github.com/nofeaturesonlybugs/sqlh/model"
+	"github.com/nofeaturesonlybugs/sqlh/model/examples"
+)
+
+func TestModels_SaveMixedSlice(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	mdb := examples.NewModels()
+	now := time.Now()
+	//
+	// Elements 0 and 2 are inserted, element 1 is updated; one transaction and groups of several
+	// elements use a prepared statement.
+	mock.ExpectBegin()
+	inserts := mock.ExpectPrepare("INSERT INTO addresses")
+	inserts.ExpectQuery().WithArgs("Main", "", "", "").WillReturnRows(sqlmock.NewRows([]string{"pk", "created_tmz", "modified_tmz"}).AddRow(11, now, now))
+	inserts.ExpectQuery().WithArgs("Oak", "", "", "").WillReturnRows(sqlmock.NewRows([]string{"pk", "created_tmz", "modified_tmz"}).AddRow(12, now, now))
+	mock.ExpectQuery("UPDATE addresses").WithArgs("Elm", "", "", "", 5).WillReturnRows(sqlmock.NewRows([]string{"modified_tmz"}).AddRow(now))
+	mock.ExpectCommit()
+	addresses := []examples.Address{{Street: "Main"}, {Id: 5, Street: "Elm"}, {Street: "Oak"}}
+	var result model.Result
+	chk.NoError(mdb.Save(db, addresses, &result))
+	chk.Equal([]int{11, 5, 12}, []int{addresses[0].Id, addresses[1].Id, addresses[2].Id})
+	chk.Equal(now, addresses[1].ModifiedTime)
+	chk.Equal(model.Result{Inserted: 2, Updated: 1}, result)
+	chk.NoError(mock.ExpectationsWereMet())
+	//
+	// Errors report the index within the original slice and roll back both groups.
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO addresses").WillReturnRows(sqlmock.NewRows([]string{"pk", "created_tmz", "modified_tmz"}).AddRow(21, now, now))
+	mock.ExpectQuery("UPDATE addresses").WithArgs("Elm", "", "", "", 5).WillReturnRows(sqlmock.NewRows([]string{"modified_tmz"}))
+	mock.ExpectRollback()
+	pointers := []*examples.Address{{Street: "Main"}, {Id: 5, Street: "Elm"}}
+	err = mdb.Save(db, pointers)
+	chk.True(errors.Is(err, sqlh.ErrNotFound))
+	if element, ok := errors.Original(err).(*model.ElementError); chk.True(ok) {
+		chk.Equal(1, element.Index)
+		chk.Equal(1, element.Err.(*sqlh.DBError).Index)
+	}
+	chk.NoError(mock.ExpectationsWereMet())
+	//
+	// Nil elements are not supported.
+	chk.True(errors.Is(mdb.Save(db, []*examples.Address{{}, nil}), model.ErrUnsupported))
+}
+
+func TestModels_SaveMixedSliceContinueOnError(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	mdb := examples.NewModels()
+	now := time.Now()
+	//
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO addresses")
+	mock.ExpectExec("SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("INSERT INTO addresses").WillReturnRows(sqlmock.NewRows([]string{"pk", "created_tmz", "modified_tmz"}).AddRow(11, now, now))
+	mock.ExpectExec("RELEASE SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("INSERT INTO addresses").WillReturnError(sqlmock.ErrCancelled)
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+	// The UPDATE group has one element; it also runs within a SAVEPOINT so the transaction can commit.
+	mock.ExpectPrepare("UPDATE addresses")
+	mock.ExpectExec("SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("UPDATE addresses").WillReturnError(sqlmock.ErrCancelled)
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	addresses := []examples.Address{{Id: 5}, {Street: "Main"}, {Street: "Oak"}}
+	err = mdb.Save(db, addresses, model.ContinueOnError)
+	failed, ok := errors.Original(err).(model.Errors)
+	if chk.True(ok) && chk.Len(failed, 2) {
+		chk.Equal(0, failed[0].(*model.ElementError).Index)
+		chk.Equal(2, failed[1].(*model.ElementError).Index)
+	}
+	chk.Equal(11, addresses[1].Id)
+	chk.NoError(mock.ExpectationsWereMet())
+}
+
+// Country has a natural key and a persisted flag.
+type Country struct {
+	model.TableName `model:"countries"`
+	Code            string `json:"code" model:"key"`
+	Name            string `json:"name"`
+	Persisted       bool   `json:"-" db:"-" model:"-"`
+}
+
+func (me *Country) IsNew() bool {
+	return !me.Persisted
+}
+
+func TestModels_SaveInsertUpdater(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	mdb := examples.NewModels()
+	chk.NoError(mdb.Register(Country{}))
+	m, err := mdb.Lookup(Country{})
+	chk.NoError(err)
+	chk.Equal(model.Upsert, m.SaveMode)
+	chk.NotNil(m.SaveStrategy)
+	//
+	// IsNew replaces the upsert of models with natural keys.
+	mock.ExpectExec("INSERT INTO countries").WithArgs("CA", "Canada").WillReturnResult(sqlmock.NewResult(0, 1))
+	chk.NoError(mdb.Save(db, &Country{Code: "CA", Name: "Canada"}))
+	mock.ExpectExec("UPDATE countries").WithArgs("France", "FR").WillReturnResult(sqlmock.NewResult(0, 1))
+	chk.NoError(mdb.Save(db, &Country{Code: "FR", Name: "France", Persisted: true}))
+	//
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO countries").WithArgs("CA", "Canada").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE countries").WithArgs("France", "FR").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	chk.NoError(mdb.Save(db, []Country{{Code: "FR", Name: "France", Persisted: true}, {Code: "CA", Name: "Canada"}}))
+	chk.NoError(mock.ExpectationsWereMet())
+}
+
+func TestModels_SaveStrategy(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	mdb := examples.NewModels()
+	// Negative ids are upserted; other ids use the default decision of InsertOrUpdate models.
+	strategy := model.SaveStrategy(func(elem interface{}) model.SaveMode {
+		if elem.(*Tag).Id < 0 {
+			return model.Upsert
+		}
+		return model.InsertOrUpdate
+	})
+	chk.NoError(mdb.Register(Tag{}, strategy))
+	//
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO tags").WithArgs("new").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectExec("UPDATE tags").WithArgs("old", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	tags := []*Tag{{Id: 1, Name: "old"}, {Name: "new"}}
+	chk.NoError(mdb.Save(db, tags))
+	chk.Equal(3, tags[1].Id)
+	chk.NoError(mock.ExpectationsWereMet())
+	//
+	// Tag has no upsert since its only key is auto.
+	err = mdb.Save(db, &Tag{Id: -1})
+	chk.True(errors.Is(err, model.ErrUnsupported))
+}
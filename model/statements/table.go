@@ -8,6 +8,8 @@ type Table struct {
 	Insert *Query
 	Update *Query
 	Upsert *Query
+	// BulkUpsert upserts the rows of a staging table; see Models.BulkUpsert.
+	BulkUpsert *Query
 }
 
 // String returns the table statements as a friendly string.
@@ -17,6 +19,7 @@ func (me Table) String() string {
 		"UPDATE: " + me.Update.String(),
 		"UPSERT: " + me.Upsert.String(),
 		"DELETE: " + me.Delete.String(),
+		"BULK UPSERT: " + me.BulkUpsert.String(),
 	}
 	return strings.Join(parts, "\n")
 }
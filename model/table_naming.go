@@ -0,0 +1,74 @@
+package model
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// TableNaming returns the table name for a Go type.  Models.TableNaming is consulted during
+// Register when no other table name is found.
+//
+// typ is always the struct type and never a pointer or slice.
+type TableNaming func(typ reflect.Type) string
+
+// SnakeCaseNaming returns the snake_case form of the type name; PersonAddress becomes person_address.
+func SnakeCaseNaming(typ reflect.Type) string {
+	return snakeCase(typ.Name())
+}
+
+// PluralSnakeCaseNaming returns the plural snake_case form of the type name; PersonAddress becomes
+// person_addresses and Category becomes categories.
+func PluralSnakeCaseNaming(typ reflect.Type) string {
+	return plural(snakeCase(typ.Name()))
+}
+
+// PrefixNaming returns a TableNaming that prepends prefix to the name returned by naming; use it
+// to place models in a schema:
+//
+//	PrefixNaming("audit.", PluralSnakeCaseNaming)
+func PrefixNaming(prefix string, naming TableNaming) TableNaming {
+	return func(typ reflect.Type) string {
+		if name := naming(typ); name != "" {
+			return prefix + name
+		}
+		return ""
+	}
+}
+
+// snakeCase converts a Go identifier to snake_case; runs of upper case letters are treated as a
+// single word so UserID becomes user_id and HTTPRequest becomes http_request.
+func snakeCase(name string) string {
+	runes := []rune(name)
+	size := len(runes)
+	var b strings.Builder
+	for k, r := range runes {
+		if unicode.IsUpper(r) {
+			if k > 0 {
+				prev := runes[k-1]
+				nextLower := k+1 < size && unicode.IsLower(runes[k+1])
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+					b.WriteRune('_')
+				}
+			}
+			b.WriteRune(unicode.ToLower(r))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// plural returns the English plural of a lower case word using simple suffix rules.
+func plural(word string) string {
+	switch {
+	case word == "":
+		return ""
+	case strings.HasSuffix(word, "s"), strings.HasSuffix(word, "x"), strings.HasSuffix(word, "z"),
+		strings.HasSuffix(word, "ch"), strings.HasSuffix(word, "sh"):
+		return word + "es"
+	case strings.HasSuffix(word, "y") && len(word) > 1 && !strings.ContainsRune("aeiou", rune(word[len(word)-2])):
+		return word[:len(word)-1] + "ies"
+	}
+	return word + "s"
+}
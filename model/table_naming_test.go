@@ -0,0 +1,91 @@
+package model_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nofeaturesonlybugs/set"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nofeaturesonlybugs/sqlh/grammar"
+	"github.com/nofeaturesonlybugs/sqlh/model"
+)
+
+func TestTableNaming(t *testing.T) {
+	type Person struct{}
+	type PersonAddress struct{}
+	type Category struct{}
+	type Day struct{}
+	type Box struct{}
+	type Batch struct{}
+	type UserID struct{}
+	type HTTPRequest struct{}
+	type Address2Line struct{}
+	//
+	tests := []struct {
+		Value  interface{}
+		Snake  string
+		Plural string
+	}{
+		{Person{}, "person", "persons"},
+		{PersonAddress{}, "person_address", "person_addresses"},
+		{Category{}, "category", "categories"},
+		{Day{}, "day", "days"},
+		{Box{}, "box", "boxes"},
+		{Batch{}, "batch", "batches"},
+		{UserID{}, "user_id", "user_ids"},
+		{HTTPRequest{}, "http_request", "http_requests"},
+		{Address2Line{}, "address2_line", "address2_lines"},
+	}
+	for _, test := range tests {
+		typ := reflect.TypeOf(test.Value)
+		t.Run(typ.Name(), func(t *testing.T) {
+			chk := assert.New(t)
+			chk.Equal(test.Snake, model.SnakeCaseNaming(typ))
+			chk.Equal(test.Plural, model.PluralSnakeCaseNaming(typ))
+			chk.Equal("audit."+test.Plural, model.PrefixNaming("audit.", model.PluralSnakeCaseNaming)(typ))
+		})
+	}
+	//
+	chk := assert.New(t)
+	empty := func(reflect.Type) string { return "" }
+	chk.Equal("", model.PrefixNaming("audit.", empty)(reflect.TypeOf(Person{})))
+}
+
+func TestModels_RegisterTableNaming(t *testing.T) {
+	chk := assert.New(t)
+	//
+	type Embeds struct {
+		model.TableName `model:"embedded_name"`
+		Name            string
+	}
+	type CompanyContact struct {
+		Name string
+	}
+	mdb := &model.Models{
+		Mapper:      &set.Mapper{},
+		Grammar:     grammar.Postgres,
+		TableNaming: model.PrefixNaming("crm.", model.PluralSnakeCaseNaming),
+	}
+	chk.NoError(mdb.Register(Embeds{}))
+	chk.NoError(mdb.Register(&CompanyContact{}))
+	chk.NoError(mdb.Register(namedModel{}))
+	//
+	mdl, err := mdb.Lookup(Embeds{})
+	chk.NoError(err)
+	chk.Equal("embedded_name", mdl.Table.Name)
+	mdl, err = mdb.Lookup(&CompanyContact{})
+	chk.NoError(err)
+	chk.Equal("crm.company_contacts", mdl.Table.QualifiedName())
+	mdl, err = mdb.Lookup(namedModel{})
+	chk.NoError(err)
+	chk.Equal("named_models", mdl.Table.Name)
+	//
+	type Option struct {
+		Name string
+	}
+	chk.NoError(mdb.Register(Option{}, model.TableName("opts")))
+	mdl, err = mdb.Lookup(Option{})
+	chk.NoError(err)
+	chk.Equal("opts", mdl.Table.Name)
+}
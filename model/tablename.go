@@ -6,6 +6,48 @@ import "reflect"
 // the appropriate struct tag to configure the table name.
 type TableName string
 
+// TableNamer is implemented by types that provide their table name with a method rather than
+// an embedded TableName field.
+type TableNamer interface {
+	TableName() string
+}
+
 var (
-	typeTableName = reflect.TypeOf(TableName(""))
+	typeTableName  = reflect.TypeOf(TableName(""))
+	typeTableNamer = reflect.TypeOf((*TableNamer)(nil)).Elem()
 )
+
+// tableNamerName returns the table name from typ's TableName method if typ or *typ
+// implements TableNamer; otherwise it returns an empty string.
+func tableNamerName(typ reflect.Type) string {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if reflect.PtrTo(typ).Implements(typeTableNamer) {
+		return reflect.New(typ).Interface().(TableNamer).TableName()
+	}
+	return ""
+}
+
+// autoRegisterType returns the type that should be registered when typ is passed to Lookup;
+// typ can be T, *T, []T, or []*T.  The returned bool is true if T embeds TableName or implements
+// TableNamer.
+func autoRegisterType(typ reflect.Type) (reflect.Type, bool) {
+	if typ.Kind() == reflect.Slice {
+		typ = typ.Elem()
+	}
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, false
+	} else if reflect.PtrTo(typ).Implements(typeTableNamer) {
+		return typ, true
+	}
+	for k, size := 0, typ.NumField(); k < size; k++ {
+		if typ.Field(k).Type == typeTableName {
+			return typ, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,133 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/nofeaturesonlybugs/errors"
+	"github.com/nofeaturesonlybugs/set"
+
+	"github.com/nofeaturesonlybugs/sqlh/model/statements"
+)
+
+// Validate checks every registered model and returns an error describing every problem found.
+//
+// Validate is intended to be called once at application startup after all models are registered.
+// A model is valid when it has a table name and every column used by its statements is mapped
+// to a field by the Mapper.
+func (me *Models) Validate() error {
+	if me == nil {
+		return errors.NilReceiver()
+	} else if me.Mapper == nil {
+		return errors.NilMember("Mapper").Type(me.Mapper)
+	}
+	var problems Errors
+	registered := me.models()
+	for _, typ := range me.registeredTypes() {
+		problems = append(problems, registered[typ].validate(me.Mapper, typ)...)
+	}
+	if len(problems) == 1 {
+		return problems[0]
+	} else if len(problems) > 0 {
+		return problems
+	}
+	return nil
+}
+
+// registeredTypes returns the types of the registered models sorted by name.  Each model is
+// registered under T, *T, []T, and []*T; only one type is returned per model.
+func (me *Models) registeredTypes() []reflect.Type {
+	registered := me.models()
+	types := make([]reflect.Type, 0, len(registered))
+	for typ := range registered {
+		types = append(types, typ)
+	}
+	sort.Slice(types, func(a, b int) bool {
+		return types[a].String() < types[b].String()
+	})
+	checked := map[*Model]bool{}
+	rv := make([]reflect.Type, 0, len(types)/4)
+	for _, typ := range types {
+		model := registered[typ]
+		if checked[model] || typ.Kind() == reflect.Slice {
+			continue
+		} else if typ.Kind() == reflect.Ptr && registered[typ.Elem()] == model {
+			continue
+		}
+		checked[model] = true
+		rv = append(rv, typ)
+	}
+	return rv
+}
+
+// validate checks the model registered as typ against mapper.
+func (me *Model) validate(mapper *set.Mapper, typ reflect.Type) Errors {
+	var problems Errors
+	if me.Table.Name == "" {
+		problems = append(problems, errors.Go(ErrTableNameRequired).Tag("type", typ.String()))
+	}
+	for _, column := range me.Table.PrimaryKey.Columns {
+		if _, ok := me.Mapping.Lookup(column.Name); !ok {
+			problems = append(problems, errors.Go(ErrUnmappedKey).Tag("type", typ.String()).Tag("column", column.Name))
+		}
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	names := []string{"INSERT", "UPDATE", "DELETE", "UPSERT"}
+	for k, query := range []*statements.Query{me.Statements.Insert, me.Statements.Update, me.Statements.Delete, me.Statements.Upsert} {
+		if query == nil {
+			continue
+		}
+		name := names[k]
+		prepared, err := mapper.Prepare(reflect.New(typ).Interface())
+		if err != nil {
+			problems = append(problems, errors.Go(err).Tag("type", typ.String()).Tag("statement", name))
+			continue
+		}
+		if err = prepared.Plan(query.Arguments...); err != nil {
+			problems = append(problems, errors.Go(err).Tag("type", typ.String()).Tag("statement", name))
+		} else if err = prepared.Plan(query.Scan...); err != nil {
+			problems = append(problems, errors.Go(err).Tag("type", typ.String()).Tag("statement", name))
+		}
+	}
+	return problems
+}
+
+// unmappedKeys returns the names of struct fields in typ tagged as keys that do not appear in mapping.
+func unmappedKeys(typ reflect.Type, tagName string, mapping set.Mapping) []string {
+	mapped := map[string]bool{}
+	for _, index := range mapping.Indeces {
+		mapped[fmt.Sprint(index)] = true
+	}
+	var rv []string
+	var walk func(typ reflect.Type, index []int, visited map[reflect.Type]bool)
+	walk = func(typ reflect.Type, index []int, visited map[reflect.Type]bool) {
+		for typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		if typ.Kind() != reflect.Struct || visited[typ] {
+			return
+		}
+		visited[typ] = true
+		defer delete(visited, typ)
+		for k, size := 0, typ.NumField(); k < size; k++ {
+			field := typ.Field(k)
+			if field.PkgPath != "" && !field.Anonymous {
+				continue // Unexported.
+			} else if field.Type == typeTableName {
+				continue
+			}
+			fieldIndex := append(append([]int{}, index...), k)
+			if tag := parseFieldTag(field.Tag.Get(tagName)); tag.Skip {
+				continue
+			} else if tag.Key && !mapped[fmt.Sprint(fieldIndex)] {
+				rv = append(rv, typ.Name()+"."+field.Name)
+			}
+			walk(field.Type, fieldIndex, visited)
+		}
+	}
+	walk(typ, nil, map[reflect.Type]bool{})
+	return rv
+}
@@ -0,0 +1,370 @@
+package model
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/nofeaturesonlybugs/errors"
+
+	"github.com/nofeaturesonlybugs/sqlh"
+	"github.com/nofeaturesonlybugs/sqlh/internal/types"
+	"github.com/nofeaturesonlybugs/sqlh/schema"
+)
+
+// DriftKind describes how a model differs from its database table.
+type DriftKind int
+
+const (
+	// DriftMissingTable means the model's table does not exist.
+	DriftMissingTable DriftKind = iota + 1
+	// DriftMissingColumn means a model column does not exist in the table.
+	DriftMissingColumn
+	// DriftExtraColumn means the table has a NOT NULL column without a default that is not part
+	// of the model; inserts of the model will fail.
+	DriftExtraColumn
+	// DriftTypeMismatch means a column's Go type can not hold values of the column's SQL type.
+	DriftTypeMismatch
+	// DriftPrimaryKey means the model and table disagree on the primary key columns.
+	DriftPrimaryKey
+	// DriftForeignKey means a model foreign key does not exist in the table or has a different
+	// ON DELETE action.
+	DriftForeignKey
+)
+
+// String returns the DriftKind as a string.
+func (me DriftKind) String() string {
+	switch me {
+	case DriftMissingTable:
+		return "missing table"
+	case DriftMissingColumn:
+		return "missing column"
+	case DriftExtraColumn:
+		return "extra NOT NULL column without default"
+	case DriftTypeMismatch:
+		return "type mismatch"
+	case DriftPrimaryKey:
+		return "primary key mismatch"
+	case DriftForeignKey:
+		return "foreign key mismatch"
+	}
+	return fmt.Sprintf("DriftKind(%d)", int(me))
+}
+
+// Drift is a single difference between a registered model and its database table.
+type Drift struct {
+	// Kind describes the difference.
+	Kind DriftKind
+	// Type is the name of the registered Go type.
+	Type string
+	// Table is the model's table name.
+	Table string
+	// Column is the column name; it is empty when the drift is not specific to a column.
+	Column string
+	// Model and Database describe the model's and the database's side of the difference, such as
+	// the Go and SQL types of a column or the primary key columns.  Either can be empty.
+	Model, Database string
+}
+
+// String describes the drift as a string.
+func (me Drift) String() string {
+	rv := me.Table
+	if me.Column != "" {
+		rv = rv + "." + me.Column
+	}
+	rv = rv + ": " + me.Kind.String()
+	if me.Model != "" || me.Database != "" {
+		rv = rv + ";"
+		if me.Model != "" {
+			rv = rv + " model=" + me.Model
+		}
+		if me.Database != "" {
+			rv = rv + " database=" + me.Database
+		}
+	}
+	return rv
+}
+
+// DriftReport is returned from Models.Verify.
+type DriftReport struct {
+	// Tables contains the names of the tables that were verified.
+	Tables []string
+	// Drift contains every difference found; it is empty when the models match the database.
+	Drift []Drift
+}
+
+// OK returns true if no drift was found.
+func (me DriftReport) OK() bool {
+	return len(me.Drift) == 0
+}
+
+// Err returns nil if no drift was found; otherwise it returns an error matching ErrSchemaDrift
+// for every difference.  The error is of type Errors when more than one difference was found.
+func (me DriftReport) Err() error {
+	var problems Errors
+	for _, drift := range me.Drift {
+		problems = append(problems, errors.Go(ErrSchemaDrift).Tag("type", drift.Type).Tag("drift", drift.String()))
+	}
+	if len(problems) == 1 {
+		return problems[0]
+	} else if len(problems) > 0 {
+		return problems
+	}
+	return nil
+}
+
+// String describes the report as a string with one difference per line.
+func (me DriftReport) String() string {
+	if me.OK() {
+		return fmt.Sprintf("%v tables verified; no drift", len(me.Tables))
+	}
+	lines := make([]string, len(me.Drift))
+	for k, drift := range me.Drift {
+		lines[k] = drift.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Verify inspects the table of every registered model and reports differences between the
+// models and the database:
+//   - tables that do not exist,
+//   - model columns that do not exist in the table,
+//   - NOT NULL columns without a default that are not part of the model,
+//   - columns whose Go type can not hold the column's SQL type,
+//   - primary keys that have different columns, and
+//   - foreign keys that do not exist or have a different ON DELETE action.
+//
+// Grammar must implement schema.Inspector; see schema.Inspect.  The returned error is only
+// non-nil if the database could not be inspected; drift is described by the DriftReport.  Use
+// DriftReport.Err to treat drift as an error, for example in a readiness probe.
+func (me *Models) Verify(Q sqlh.IQueries) (DriftReport, error) {
+	var report DriftReport
+	if me == nil {
+		return report, errors.NilReceiver()
+	} else if me.Grammar == nil {
+		return report, errors.NilMember("Grammar").Type(me.Grammar)
+	} else if Q == nil {
+		return report, errors.NilArgument("Q")
+	}
+	Q = me.queries(Q, "", nil, nil)
+	registered := me.models()
+	for _, typ := range me.registeredTypes() {
+		model := registered[typ]
+		name := model.Table.QualifiedName()
+		report.Tables = append(report.Tables, name)
+		tables, err := schema.Inspect(Q, me.Grammar, name)
+		if errors.Original(err) == schema.ErrTableNotFound {
+			report.Drift = append(report.Drift, Drift{Kind: DriftMissingTable, Type: typ.String(), Table: name})
+			continue
+		} else if err != nil {
+			return report, errors.Go(err).Tag("type", typ.String())
+		}
+		report.Drift = append(report.Drift, tableDrift(typ.String(), model.Table, tables[0])...)
+	}
+	return report, nil
+}
+
+// tableDrift compares a model's table to the inspected table.
+func tableDrift(typ string, model schema.Table, actual schema.Table) []Drift {
+	var rv []Drift
+	name := model.QualifiedName()
+	//
+	// Primary key columns are compared regardless of order.
+	modelKey, actualKey := schema.ColumnNames(model.PrimaryKey.Columns), schema.ColumnNames(actual.PrimaryKey.Columns)
+	sort.Strings(modelKey)
+	sort.Strings(actualKey)
+	if strings.Join(modelKey, ",") != strings.Join(actualKey, ",") {
+		rv = append(rv, Drift{Kind: DriftPrimaryKey, Type: typ, Table: name, Model: strings.Join(modelKey, ","), Database: strings.Join(actualKey, ",")})
+	}
+	//
+	actualColumns := map[string]schema.Column{}
+	for _, column := range append(append([]schema.Column{}, actual.PrimaryKey.Columns...), actual.Columns...) {
+		actualColumns[column.Name] = column
+	}
+	modelColumns := map[string]bool{}
+	for _, column := range append(append([]schema.Column{}, model.PrimaryKey.Columns...), model.Columns...) {
+		modelColumns[column.Name] = true
+		goType := reflect.TypeOf(column.GoType)
+		if found, ok := actualColumns[column.Name]; !ok {
+			rv = append(rv, Drift{Kind: DriftMissingColumn, Type: typ, Table: name, Column: column.Name, Model: fmt.Sprint(goType)})
+		} else if !compatibleType(goType, found.SqlType) {
+			rv = append(rv, Drift{Kind: DriftTypeMismatch, Type: typ, Table: name, Column: column.Name, Model: fmt.Sprint(goType), Database: found.SqlType})
+		}
+	}
+	for _, column := range actual.Columns {
+		if !modelColumns[column.Name] && !column.Nullable && column.Default == "" {
+			rv = append(rv, Drift{Kind: DriftExtraColumn, Type: typ, Table: name, Column: column.Name, Database: column.SqlType})
+		}
+	}
+	//
+	// Foreign keys are matched by their columns and references; names are not compared.
+	for _, foreignKey := range model.ForeignKeys {
+		column := strings.Join(schema.ColumnNames(foreignKey.Columns), ",")
+		found, ok := findForeignKey(model.Schema, foreignKey, actual.ForeignKeys)
+		if !ok {
+			rv = append(rv, Drift{Kind: DriftForeignKey, Type: typ, Table: name, Column: column, Model: foreignKeyReference(foreignKey)})
+		} else if found.OnDelete != foreignKey.OnDelete {
+			rv = append(rv, Drift{Kind: DriftForeignKey, Type: typ, Table: name, Column: column, Model: foreignKeyReference(foreignKey), Database: foreignKeyReference(found)})
+		}
+	}
+	return rv
+}
+
+// findForeignKey returns the foreign key in actual with the same columns and references as
+// foreignKey.  Referenced tables in schemaName match with or without the schema and empty
+// referenced columns, which SQLite reports for implicit primary key references, match any column.
+func findForeignKey(schemaName string, foreignKey schema.ForeignKey, actual []schema.ForeignKey) (schema.ForeignKey, bool) {
+	unqualified := func(table string) string {
+		if schemaName != "" {
+			return strings.TrimPrefix(table, schemaName+".")
+		}
+		return table
+	}
+	columns := strings.Join(schema.ColumnNames(foreignKey.Columns), ",")
+	for _, found := range actual {
+		if strings.Join(schema.ColumnNames(found.Columns), ",") != columns || unqualified(found.RefTable) != unqualified(foreignKey.RefTable) || len(found.RefColumns) != len(foreignKey.RefColumns) {
+			continue
+		}
+		match := true
+		for k, refColumn := range found.RefColumns {
+			match = match && (refColumn == "" || refColumn == foreignKey.RefColumns[k])
+		}
+		if match {
+			return found, true
+		}
+	}
+	return schema.ForeignKey{}, false
+}
+
+// foreignKeyReference describes the reference and ON DELETE action of a foreign key.
+func foreignKeyReference(foreignKey schema.ForeignKey) string {
+	rv := foreignKey.RefTable + "(" + strings.Join(foreignKey.RefColumns, ",") + ")"
+	if foreignKey.OnDelete != "" {
+		rv = rv + " on delete " + foreignKey.OnDelete
+	}
+	return rv
+}
+
+// typeFamily groups Go and SQL types that can be assigned to one another.
+type typeFamily int
+
+const (
+	familyUnknown typeFamily = iota
+	familyInteger
+	familyFloat
+	familyNumeric
+	familyBool
+	familyTime
+	familyText
+	familyBytes
+	familyJSON
+	familyUUID
+)
+
+// compatibleFamilies lists the SQL type families each Go type family can hold.
+var compatibleFamilies = map[typeFamily][]typeFamily{
+	familyInteger: {familyInteger},
+	familyFloat:   {familyInteger, familyFloat, familyNumeric},
+	familyBool:    {familyBool},
+	familyTime:    {familyTime},
+	familyText:    {familyText, familyNumeric, familyJSON, familyUUID},
+	familyBytes:   {familyText, familyBytes, familyJSON, familyUUID},
+}
+
+// compatibleType returns true if values of sqlType can be scanned into goType.  Types that can not
+// be classified, such as types implementing sql.Scanner, are always compatible.
+func compatibleType(goType reflect.Type, sqlType string) bool {
+	goFamily, sqlFamily := goTypeFamily(goType), sqlTypeFamily(sqlType)
+	if goFamily == familyUnknown || sqlFamily == familyUnknown {
+		return true
+	}
+	for _, family := range compatibleFamilies[goFamily] {
+		if family == sqlFamily {
+			return true
+		}
+	}
+	return false
+}
+
+// goTypeFamily classifies a Go type.
+func goTypeFamily(typ reflect.Type) typeFamily {
+	if typ == nil {
+		return familyUnknown
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ {
+	case types.Time, types.NullTime:
+		return familyTime
+	case types.Bytes:
+		return familyBytes
+	case types.NullString:
+		return familyText
+	case types.NullInt64, types.NullInt32:
+		return familyInteger
+	case types.NullFloat64:
+		return familyFloat
+	case types.NullBool:
+		return familyBool
+	}
+	if reflect.PtrTo(typ).Implements(typeScanner) {
+		return familyUnknown
+	}
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return familyInteger
+	case reflect.Float32, reflect.Float64:
+		return familyFloat
+	case reflect.Bool:
+		return familyBool
+	case reflect.String:
+		return familyText
+	}
+	return familyUnknown
+}
+
+// typeScanner is the reflect.Type of sql.Scanner.
+var typeScanner = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// sqlTypeFamily classifies a SQL type as reported by schema.Inspect.
+func sqlTypeFamily(sqlType string) typeFamily {
+	sqlType = strings.ToLower(strings.TrimSpace(sqlType))
+	if n := strings.Index(sqlType, "("); n >= 0 {
+		sqlType = strings.TrimSpace(sqlType[:n]) + sqlType[strings.Index(sqlType, ")")+1:]
+	}
+	hasPrefix := func(prefixes ...string) bool {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(sqlType, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case sqlType == "" || strings.HasSuffix(sqlType, "]") || hasPrefix("interval", "point"):
+		return familyUnknown
+	case hasPrefix("timestamp", "datetime", "date", "time"):
+		return familyTime
+	case hasPrefix("bool"):
+		return familyBool
+	case strings.Contains(sqlType, "int") || hasPrefix("serial", "bigserial", "smallserial"):
+		return familyInteger
+	case hasPrefix("real", "double", "float"):
+		return familyFloat
+	case hasPrefix("numeric", "decimal", "money"):
+		return familyNumeric
+	case hasPrefix("json"):
+		return familyJSON
+	case hasPrefix("uuid"):
+		return familyUUID
+	case hasPrefix("bytea", "blob"):
+		return familyBytes
+	case strings.Contains(sqlType, "char") || hasPrefix("text", "clob", "citext", "name"):
+		return familyText
+	}
+	return familyUnknown
+}
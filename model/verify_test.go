@@ -0,0 +1,176 @@
+package model_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/nofeaturesonlybugs/errors"
+	"github.com/nofeaturesonlybugs/set"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nofeaturesonlybugs/sqlh/grammar"
+	"github.com/nofeaturesonlybugs/sqlh/model"
+	"github.com/nofeaturesonlybugs/sqlh/schema"
+)
+
+// expectPostgresTable adds the queries made by grammar.Postgres to inspect a table.
+func expectPostgresTable(mock sqlmock.Sqlmock, table string, columns *sqlmock.Rows, indexes *sqlmock.Rows) {
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pg_catalog.pg_attribute a")).WithArgs("", table).WillReturnRows(columns)
+	if indexes != nil {
+		mock.ExpectQuery(regexp.QuoteMeta("FROM pg_catalog.pg_index x")).WithArgs("", table).WillReturnRows(indexes)
+		mock.ExpectQuery(regexp.QuoteMeta("FROM pg_catalog.pg_constraint c")).WithArgs("", table).WillReturnRows(foreignKeyRows())
+	}
+}
+
+// foreignKeyRows returns the rows for the query made by grammar.Postgres to inspect foreign keys.
+func foreignKeyRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"conname", "attname", "relname", "refname", "confdeltype"})
+}
+
+func TestModels_Verify(t *testing.T) {
+	chk := assert.New(t)
+	//
+	type Address struct {
+		model.TableName `model:"addresses"`
+		Id              int    `db:"pk" model:"key,auto"`
+		Street          string `db:"street"`
+	}
+	type Person struct {
+		model.TableName `model:"people"`
+		Id              int       `db:"pk" model:"key,auto"`
+		Email           string    `db:"email"`
+		Age             int       `db:"age"`
+		Nickname        *string   `db:"nickname"`
+		Created         time.Time `db:"created" model:"inserted"`
+	}
+	mdb := &model.Models{
+		Mapper:  &set.Mapper{Tags: []string{"db"}},
+		Grammar: grammar.Postgres,
+	}
+	mdb.MustRegister(Address{})
+	mdb.MustRegister(Person{})
+	//
+	columnNames := []string{"attname", "format_type", "nullable", "default"}
+	indexNames := []string{"relname", "indisprimary", "indisunique", "attname"}
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	{
+		// No drift.
+		expectPostgresTable(mock, "addresses",
+			sqlmock.NewRows(columnNames).AddRow("pk", "integer", false, "nextval('addresses_pk_seq'::regclass)").AddRow("street", "text", true, "").AddRow("notes", "text", true, ""),
+			sqlmock.NewRows(indexNames).AddRow("addresses_pkey", true, true, "pk"))
+		expectPostgresTable(mock, "people",
+			sqlmock.NewRows(columnNames).
+				AddRow("pk", "integer", false, "").
+				AddRow("email", "character varying(64)", false, "").
+				AddRow("age", "smallint", true, "").
+				AddRow("nickname", "text", true, "").
+				AddRow("created", "timestamp with time zone", false, "now()"),
+			sqlmock.NewRows(indexNames).AddRow("people_pkey", true, true, "pk"))
+		report, err := mdb.Verify(db)
+		chk.NoError(err)
+		chk.NoError(mock.ExpectationsWereMet())
+		chk.True(report.OK())
+		chk.NoError(report.Err())
+		chk.Equal([]string{"addresses", "people"}, report.Tables)
+		chk.Equal("2 tables verified; no drift", report.String())
+	}
+	{
+		// Drift.
+		expectPostgresTable(mock, "addresses", sqlmock.NewRows(columnNames), nil)
+		expectPostgresTable(mock, "people",
+			sqlmock.NewRows(columnNames).
+				AddRow("pk", "integer", false, "").
+				AddRow("email", "text", false, "").
+				AddRow("age", "text", true, "").
+				AddRow("ssn", "text", false, "").
+				AddRow("notes", "text", false, "''::text"),
+			sqlmock.NewRows(indexNames).
+				AddRow("people_pkey", true, true, "email").
+				AddRow("people_pkey", true, true, "pk"))
+		report, err := mdb.Verify(db)
+		chk.NoError(err)
+		chk.NoError(mock.ExpectationsWereMet())
+		chk.False(report.OK())
+		chk.Equal([]model.Drift{
+			{Kind: model.DriftMissingTable, Type: "model_test.Address", Table: "addresses"},
+			{Kind: model.DriftPrimaryKey, Type: "model_test.Person", Table: "people", Model: "pk", Database: "email,pk"},
+			{Kind: model.DriftTypeMismatch, Type: "model_test.Person", Table: "people", Column: "age", Model: "int", Database: "text"},
+			{Kind: model.DriftMissingColumn, Type: "model_test.Person", Table: "people", Column: "nickname", Model: "*string"},
+			{Kind: model.DriftMissingColumn, Type: "model_test.Person", Table: "people", Column: "created", Model: "time.Time"},
+			{Kind: model.DriftExtraColumn, Type: "model_test.Person", Table: "people", Column: "ssn", Database: "text"},
+		}, report.Drift)
+		chk.Equal("people.age: type mismatch; model=int database=text", report.Drift[2].String())
+		err = report.Err()
+		chk.Error(err)
+		chk.True(errors.Is(err, model.ErrSchemaDrift))
+	}
+	{
+		// Inspection errors are returned.
+		mock.ExpectQuery(regexp.QuoteMeta("FROM pg_catalog.pg_attribute a")).WillReturnError(errors.Errorf("connection lost"))
+		_, err := mdb.Verify(db)
+		chk.Error(err)
+		chk.NoError(mock.ExpectationsWereMet())
+	}
+	{
+		// The grammar must support inspection.
+		var nilModels *model.Models
+		_, err = nilModels.Verify(db)
+		chk.Error(err)
+		unsupported := &model.Models{
+			Mapper:  &set.Mapper{Tags: []string{"db"}},
+			Grammar: unsupportedGrammar{grammar.Postgres},
+		}
+		unsupported.MustRegister(Address{})
+		_, err = unsupported.Verify(db)
+		chk.Equal(schema.ErrInspectUnsupported, errors.Original(err))
+	}
+}
+
+// unsupportedGrammar hides the Inspect method of the embedded grammar.
+type unsupportedGrammar struct {
+	grammar.Grammar
+}
+
+func TestModels_VerifyForeignKeys(t *testing.T) {
+	chk := assert.New(t)
+	//
+	type Order struct {
+		model.TableName `model:"orders"`
+		Id              int `db:"pk" model:"key,auto"`
+		PersonId        int `db:"person_fk" model:"fk(people.pk),ondelete=cascade"`
+		AddressId       int `db:"address_fk" model:"fk(addresses.pk)"`
+		ProductId       int `db:"product_fk" model:"fk(products.pk)"`
+	}
+	mdb := &model.Models{
+		Mapper:  &set.Mapper{Tags: []string{"db"}},
+		Grammar: grammar.Postgres,
+	}
+	mdb.MustRegister(Order{})
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pg_catalog.pg_attribute a")).WithArgs("", "orders").
+		WillReturnRows(sqlmock.NewRows([]string{"attname", "format_type", "nullable", "default"}).
+			AddRow("pk", "integer", false, "").
+			AddRow("person_fk", "integer", false, "").
+			AddRow("address_fk", "integer", false, "").
+			AddRow("product_fk", "integer", false, ""))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pg_catalog.pg_index x")).WithArgs("", "orders").
+		WillReturnRows(sqlmock.NewRows([]string{"relname", "indisprimary", "indisunique", "attname"}).AddRow("orders_pkey", true, true, "pk"))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM pg_catalog.pg_constraint c")).WithArgs("", "orders").
+		WillReturnRows(foreignKeyRows().
+			AddRow("orders_address_fk_fkey", "address_fk", "addresses", "pk", "a").
+			AddRow("orders_person_fk_fkey", "person_fk", "people", "pk", "n"))
+	report, err := mdb.Verify(db)
+	chk.NoError(err)
+	chk.NoError(mock.ExpectationsWereMet())
+	chk.Equal([]model.Drift{
+		{Kind: model.DriftForeignKey, Type: "model_test.Order", Table: "orders", Column: "person_fk", Model: "people(pk) on delete CASCADE", Database: "people(pk) on delete SET NULL"},
+		{Kind: model.DriftForeignKey, Type: "model_test.Order", Table: "orders", Column: "product_fk", Model: "products(pk)"},
+	}, report.Drift)
+}
@@ -0,0 +1,350 @@
+package sqlh
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Observer is notified before and after each database call made through a value returned from Observe.
+//
+// BeforeQuery is called before the call and the context it returns is passed to AfterQuery; observers
+// that do not need per-call state return ctx.  AfterQuery receives the duration of the call, the
+// rows affected, and the error if any.  rowsAffected is -1 when it is not known, such as for Query,
+// QueryRow, Prepare, and transaction control.
+//
+// Transaction control is reported with the query strings BEGIN, COMMIT, and ROLLBACK.
+type Observer interface {
+	BeforeQuery(ctx context.Context, query string, args []interface{}) context.Context
+	AfterQuery(ctx context.Context, query string, args []interface{}, duration time.Duration, rowsAffected int64, err error)
+}
+
+// RowsObserver is implemented by observers that are told how many rows Scanner scanned into a
+// destination.  query is empty for Scanner.ScanRows.
+type RowsObserver interface {
+	ScannedRows(ctx context.Context, query string, n int64)
+}
+
+// Observers notifies every Observer in the slice in order.
+type Observers []Observer
+
+// BeforeQuery calls BeforeQuery of each observer passing along the returned context.
+func (me Observers) BeforeQuery(ctx context.Context, query string, args []interface{}) context.Context {
+	for _, observer := range me {
+		ctx = observer.BeforeQuery(ctx, query, args)
+	}
+	return ctx
+}
+
+// AfterQuery calls AfterQuery of each observer.
+func (me Observers) AfterQuery(ctx context.Context, query string, args []interface{}, duration time.Duration, rowsAffected int64, err error) {
+	for _, observer := range me {
+		observer.AfterQuery(ctx, query, args, duration, rowsAffected, err)
+	}
+}
+
+// ScannedRows calls ScannedRows of each observer that is a RowsObserver.
+func (me Observers) ScannedRows(ctx context.Context, query string, n int64) {
+	for _, observer := range me {
+		if rows, ok := observer.(RowsObserver); ok {
+			rows.ScannedRows(ctx, query, n)
+		}
+	}
+}
+
+// Observe wraps Q so that Exec, Query, QueryRow, Prepare, Begin, and CopyFrom are reported to observer.
+// The returned value implements IPrepares, IBegins, and ICopies only if Q does.
+//
+// Statements returned from Prepare and transactions returned from Begin are not observed; Transact
+// observes the transactions it starts and the queries run inside them.  If observer is nil or Q is
+// already observed by observer then Q is returned.
+func Observe(Q IQueries, observer Observer) IQueries {
+	if Q == nil || observer == nil {
+		return Q
+	} else if current := observedOf(Q); current != nil && sameObserver(current.observer, observer) {
+		return Q
+	}
+	return observeContext(context.Background(), Q, observer)
+}
+
+// ObserveContext is the same as Observe except ctx is the context given to BeforeQuery; use it with
+// WithOperation to describe the queries run through the returned value.  If Q is already observed by
+// observer then the returned value replaces the context of Q.
+func ObserveContext(ctx context.Context, Q IQueries, observer Observer) IQueries {
+	if Q == nil || observer == nil {
+		return Q
+	} else if ctx == nil {
+		ctx = context.Background()
+	}
+	if current := observedOf(Q); current != nil && sameObserver(current.observer, observer) {
+		Q = current.Q
+	}
+	return observeContext(ctx, Q, observer)
+}
+
+// observeContext wraps Q with the capabilities of Q.
+func observeContext(ctx context.Context, Q IQueries, observer Observer) IQueries {
+	q := &observedQueries{Q: Q, observer: observer, ctx: ctx}
+	P, prepares := Q.(IPrepares)
+	B, begins := Q.(IBegins)
+	C, copies := Q.(ICopies)
+	p, b, c := &observedPrepares{q: q, P: P}, &observedBegins{q: q, B: B}, &observedCopies{q: q, C: C}
+	switch {
+	case prepares && begins && copies:
+		return &struct {
+			*observedQueries
+			*observedPrepares
+			*observedBegins
+			*observedCopies
+		}{q, p, b, c}
+	case prepares && begins:
+		return &struct {
+			*observedQueries
+			*observedPrepares
+			*observedBegins
+		}{q, p, b}
+	case prepares && copies:
+		return &struct {
+			*observedQueries
+			*observedPrepares
+			*observedCopies
+		}{q, p, c}
+	case begins && copies:
+		return &struct {
+			*observedQueries
+			*observedBegins
+			*observedCopies
+		}{q, b, c}
+	case prepares:
+		return &struct {
+			*observedQueries
+			*observedPrepares
+		}{q, p}
+	case begins:
+		return &struct {
+			*observedQueries
+			*observedBegins
+		}{q, b}
+	case copies:
+		return &struct {
+			*observedQueries
+			*observedCopies
+		}{q, c}
+	}
+	return q
+}
+
+// ObserverOf returns the Observer of Q if Q was returned from Observe; otherwise it returns nil.
+func ObserverOf(Q IQueries) Observer {
+	if current := observedOf(Q); current != nil {
+		return current.observer
+	}
+	return nil
+}
+
+// ContextOf returns the context given to BeforeQuery for Q if Q was returned from Observe or ObserveContext;
+// otherwise it returns context.Background().
+func ContextOf(Q IQueries) context.Context {
+	if current := observedOf(Q); current != nil {
+		return current.ctx
+	}
+	return context.Background()
+}
+
+// observedOf returns the observedQueries of Q or nil if Q is not observed.
+func observedOf(Q IQueries) *observedQueries {
+	if observed, ok := Q.(interface{ observed() *observedQueries }); ok {
+		return observed.observed()
+	}
+	return nil
+}
+
+// sameObserver returns true if a and b are the same observer; slices such as Observers are the same
+// if they share their backing array and length.
+func sameObserver(a, b Observer) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	if ta != tb {
+		return false
+	} else if ta.Comparable() {
+		return a == b
+	} else if ta.Kind() == reflect.Slice {
+		va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+		return va.Pointer() == vb.Pointer() && va.Len() == vb.Len()
+	}
+	return false
+}
+
+// observe reports the call fn to observer.
+func observe(ctx context.Context, observer Observer, query string, args []interface{}, fn func() (int64, error)) error {
+	ctx = observer.BeforeQuery(ctx, query, args)
+	start := time.Now()
+	rowsAffected, err := fn()
+	observer.AfterQuery(ctx, query, args, time.Since(start), rowsAffected, err)
+	return err
+}
+
+// observedQueries observes the IQueries methods.
+type observedQueries struct {
+	Q        IQueries
+	observer Observer
+	ctx      context.Context
+}
+
+func (me *observedQueries) observed() *observedQueries {
+	return me
+}
+
+func (me *observedQueries) Exec(query string, args ...interface{}) (sql.Result, error) {
+	var rv sql.Result
+	err := observe(me.ctx, me.observer, query, args, func() (int64, error) {
+		var err error
+		if rv, err = me.Q.Exec(query, args...); err != nil {
+			return -1, err
+		} else if n, err := rv.RowsAffected(); err == nil {
+			return n, nil
+		}
+		return -1, nil
+	})
+	return rv, err
+}
+
+func (me *observedQueries) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	var rv *sql.Rows
+	err := observe(me.ctx, me.observer, query, args, func() (int64, error) {
+		var err error
+		rv, err = me.Q.Query(query, args...)
+		return -1, err
+	})
+	return rv, err
+}
+
+func (me *observedQueries) QueryRow(query string, args ...interface{}) *sql.Row {
+	var rv *sql.Row
+	_ = observe(me.ctx, me.observer, query, args, func() (int64, error) {
+		rv = me.Q.QueryRow(query, args...)
+		return -1, rv.Err()
+	})
+	return rv
+}
+
+// observedPrepares observes IPrepares.
+type observedPrepares struct {
+	q *observedQueries
+	P IPrepares
+}
+
+func (me *observedPrepares) Prepare(query string) (*sql.Stmt, error) {
+	var rv *sql.Stmt
+	err := observe(me.q.ctx, me.q.observer, query, nil, func() (int64, error) {
+		var err error
+		rv, err = me.P.Prepare(query)
+		return -1, err
+	})
+	return rv, err
+}
+
+// Stmt is a prepared statement returned from Prepare.  If the statement was prepared through a value
+// returned from Observe then its Exec, Query, and QueryRow calls are reported to the observer with the
+// query the statement was prepared from.
+type Stmt struct {
+	*sql.Stmt
+	query    string
+	observed *observedQueries
+}
+
+// Prepare prepares query with P.  Unlike calling P.Prepare directly the calls of the returned
+// statement are observed when P is observed.
+func Prepare(P IPrepares, query string) (*Stmt, error) {
+	stmt, err := P.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	rv := &Stmt{Stmt: stmt, query: query}
+	if observed, ok := P.(interface{ observed() *observedQueries }); ok {
+		rv.observed = observed.observed()
+	}
+	return rv, nil
+}
+
+// Exec executes the statement with args.
+func (me *Stmt) Exec(args ...interface{}) (sql.Result, error) {
+	if me.observed == nil {
+		return me.Stmt.Exec(args...)
+	}
+	var rv sql.Result
+	err := observe(me.observed.ctx, me.observed.observer, me.query, args, func() (int64, error) {
+		var err error
+		if rv, err = me.Stmt.Exec(args...); err != nil {
+			return -1, err
+		} else if n, err := rv.RowsAffected(); err == nil {
+			return n, nil
+		}
+		return -1, nil
+	})
+	return rv, err
+}
+
+// Query runs the statement with args.
+func (me *Stmt) Query(args ...interface{}) (*sql.Rows, error) {
+	if me.observed == nil {
+		return me.Stmt.Query(args...)
+	}
+	var rv *sql.Rows
+	err := observe(me.observed.ctx, me.observed.observer, me.query, args, func() (int64, error) {
+		var err error
+		rv, err = me.Stmt.Query(args...)
+		return -1, err
+	})
+	return rv, err
+}
+
+// QueryRow runs the statement with args.
+func (me *Stmt) QueryRow(args ...interface{}) *sql.Row {
+	if me.observed == nil {
+		return me.Stmt.QueryRow(args...)
+	}
+	var rv *sql.Row
+	_ = observe(me.observed.ctx, me.observed.observer, me.query, args, func() (int64, error) {
+		rv = me.Stmt.QueryRow(args...)
+		return -1, rv.Err()
+	})
+	return rv
+}
+
+// observedBegins observes IBegins.
+type observedBegins struct {
+	q *observedQueries
+	B IBegins
+}
+
+func (me *observedBegins) Begin() (*sql.Tx, error) {
+	var rv *sql.Tx
+	err := observe(me.q.ctx, me.q.observer, "BEGIN", nil, func() (int64, error) {
+		var err error
+		rv, err = me.B.Begin()
+		return -1, err
+	})
+	return rv, err
+}
+
+// observedCopies observes ICopies; the query is reported as COPY table ( columns ) FROM STDIN.
+type observedCopies struct {
+	q *observedQueries
+	C ICopies
+}
+
+func (me *observedCopies) CopyFrom(table string, columns []string, source ICopySource) (int64, error) {
+	var rv int64
+	query := "COPY " + table + " ( " + strings.Join(columns, ", ") + " ) FROM STDIN"
+	err := observe(me.q.ctx, me.q.observer, query, nil, func() (int64, error) {
+		var err error
+		rv, err = me.C.CopyFrom(table, columns, source)
+		return rv, err
+	})
+	return rv, err
+}
@@ -0,0 +1,321 @@
+package sqlh_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/nofeaturesonlybugs/errors"
+	"github.com/nofeaturesonlybugs/set"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nofeaturesonlybugs/sqlh"
+	"github.com/nofeaturesonlybugs/sqlh/hobbled"
+)
+
+// recorder is an Observer that records calls.
+type recorder struct {
+	before []string
+	after  []string
+}
+
+func (me *recorder) BeforeQuery(ctx context.Context, query string, args []interface{}) context.Context {
+	me.before = append(me.before, query)
+	return context.WithValue(ctx, me, query)
+}
+
+func (me *recorder) AfterQuery(ctx context.Context, query string, args []interface{}, duration time.Duration, rowsAffected int64, err error) {
+	if ctx.Value(me) != query {
+		panic("context from BeforeQuery was not passed to AfterQuery")
+	}
+	me.after = append(me.after, fmt.Sprintf("%v %v %v %v", query, args, rowsAffected, err))
+}
+
+// logger records entries for the log observers.
+type logger struct {
+	entries []string
+}
+
+func (me *logger) Info(msg string, args ...interface{})  { me.log("INFO", msg, args) }
+func (me *logger) Warn(msg string, args ...interface{})  { me.log("WARN", msg, args) }
+func (me *logger) Error(msg string, args ...interface{}) { me.log("ERROR", msg, args) }
+func (me *logger) log(level string, msg string, args []interface{}) {
+	entry := level + " " + msg
+	for k := 0; k+1 < len(args); k += 2 {
+		if args[k] != "duration" {
+			entry = entry + fmt.Sprintf(" %v=%v", args[k], args[k+1])
+		}
+	}
+	me.entries = append(me.entries, entry)
+}
+
+func TestObserve(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	rec := &recorder{}
+	Q := sqlh.Observe(db, rec)
+	chk.Equal(rec, sqlh.ObserverOf(Q))
+	chk.Nil(sqlh.ObserverOf(db))
+	chk.Equal(Q, sqlh.Observe(Q, rec))
+	chk.Equal(db, sqlh.Observe(db, nil))
+	//
+	mock.ExpectExec("UPDATE").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectQuery("SELECT a").WillReturnRows(sqlmock.NewRows([]string{"a"}).AddRow(1))
+	mock.ExpectQuery("SELECT b").WillReturnError(errors.Errorf("bad query"))
+	mock.ExpectPrepare("SELECT c")
+	_, err = Q.Exec("UPDATE t SET a = 1 WHERE b = ?", 1)
+	chk.NoError(err)
+	rows, err := Q.Query("SELECT a FROM t")
+	chk.NoError(err)
+	chk.NoError(rows.Close())
+	var n int
+	chk.Error(Q.QueryRow("SELECT b FROM t").Scan(&n))
+	stmt, err := Q.(sqlh.IPrepares).Prepare("SELECT c FROM t")
+	chk.NoError(err)
+	chk.NoError(stmt.Close())
+	chk.NoError(mock.ExpectationsWereMet())
+	chk.Equal([]string{"UPDATE t SET a = 1 WHERE b = ?", "SELECT a FROM t", "SELECT b FROM t", "SELECT c FROM t"}, rec.before)
+	chk.Equal([]string{
+		"UPDATE t SET a = 1 WHERE b = ? [1] 3 <nil>",
+		"SELECT a FROM t [] -1 <nil>",
+		"SELECT b FROM t [] -1 bad query",
+		"SELECT c FROM t [] -1 <nil>",
+	}, rec.after)
+}
+
+func TestObserveCapabilities(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, _, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	for _, wrapper := range []hobbled.Wrapper{hobbled.Passthru, hobbled.NoBegin, hobbled.NoBeginNoPrepare} {
+		Q := wrapper.WrapDB(db)
+		observed := sqlh.Observe(Q, &recorder{})
+		_, prepares := Q.(sqlh.IPrepares)
+		_, begins := Q.(sqlh.IBegins)
+		_, observedPrepares := observed.(sqlh.IPrepares)
+		_, observedBegins := observed.(sqlh.IBegins)
+		_, observedCopies := observed.(sqlh.ICopies)
+		chk.Equal(prepares, observedPrepares, wrapper.String())
+		chk.Equal(begins, observedBegins, wrapper.String())
+		chk.False(observedCopies, wrapper.String())
+	}
+}
+
+func TestObserveTransact(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	rec := &recorder{}
+	Q := sqlh.Observe(db, rec)
+	//
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	err = sqlh.Transact(Q, func(Q sqlh.IQueries) error {
+		_, err := Q.Exec("INSERT INTO t VALUES ( 1 )")
+		return err
+	})
+	chk.NoError(err)
+	err = sqlh.Transact(Q, func(Q sqlh.IQueries) error {
+		return errors.Errorf("stop")
+	})
+	chk.Error(err)
+	chk.NoError(mock.ExpectationsWereMet())
+	chk.Equal([]string{"BEGIN", "INSERT INTO t VALUES ( 1 )", "COMMIT", "BEGIN", "ROLLBACK"}, rec.before)
+	//
+	rec.before = nil
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	err = sqlh.TransactRollback(Q.(sqlh.IBegins), func(Q sqlh.IQueries) error {
+		return nil
+	})
+	chk.NoError(err)
+	chk.NoError(mock.ExpectationsWereMet())
+	chk.Equal([]string{"BEGIN", "ROLLBACK"}, rec.before)
+}
+
+func TestObservers(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	a, b := &recorder{}, &recorder{}
+	observers := sqlh.Observers{a, b}
+	Q := sqlh.Observe(db, observers)
+	chk.Equal(Q, sqlh.Observe(Q, observers))
+	//
+	mock.ExpectExec("DELETE").WillReturnResult(sqlmock.NewResult(0, 0))
+	_, err = Q.Exec("DELETE FROM t")
+	chk.NoError(err)
+	chk.Equal([]string{"DELETE FROM t"}, a.before)
+	chk.Equal([]string{"DELETE FROM t"}, b.before)
+}
+
+func TestLogObservers(t *testing.T) {
+	chk := assert.New(t)
+	//
+	log := &logger{}
+	observer := &sqlh.LogObserver{Logger: log}
+	ctx := observer.BeforeQuery(context.Background(), "SELECT 1", nil)
+	observer.AfterQuery(ctx, "SELECT 1", []interface{}{"secret"}, time.Millisecond, -1, nil)
+	observer.AfterQuery(ctx, "SELECT 1", nil, time.Millisecond, -1, sql.ErrConnDone)
+	observer.Args = true
+	observer.AfterQuery(ctx, "UPDATE t", []interface{}{42}, time.Millisecond, 2, nil)
+	//
+	slow := &sqlh.SlowQueryObserver{Logger: log, Threshold: time.Second}
+	ctx = slow.BeforeQuery(context.Background(), "SELECT 2", nil)
+	slow.AfterQuery(ctx, "SELECT 2", nil, time.Millisecond, -1, nil)
+	slow.AfterQuery(ctx, "SELECT 2", nil, 2*time.Second, -1, nil)
+	chk.Equal([]string{
+		"INFO sqlh query sql=SELECT 1 rows=-1",
+		"ERROR sqlh query failed sql=SELECT 1 rows=-1 error=sql: connection is already closed",
+		"INFO sqlh query sql=UPDATE t rows=2 args=[42]",
+		"WARN sqlh slow query sql=SELECT 2 rows=-1 threshold=1s",
+	}, log.entries)
+	//
+	// Observers without loggers do nothing.
+	(&sqlh.LogObserver{}).AfterQuery(ctx, "SELECT 3", nil, 0, 0, nil)
+	(&sqlh.SlowQueryObserver{}).AfterQuery(ctx, "SELECT 3", nil, time.Hour, 0, nil)
+}
+
+func TestScanner_Observer(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	rec := &recorder{}
+	scanner := &sqlh.Scanner{
+		Mapper:   &set.Mapper{},
+		Observer: rec,
+	}
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(1).AddRow(2))
+	var numbers []int
+	chk.NoError(scanner.Select(db, &numbers, "SELECT n FROM t"))
+	chk.Equal([]int{1, 2}, numbers)
+	chk.Equal([]string{"SELECT n FROM t"}, rec.before)
+}
+
+func TestObserveContext(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	var operations []sqlh.Operation
+	observer := &operationRecorder{operations: &operations}
+	Q := sqlh.Observe(db, observer)
+	ctx := sqlh.WithOperation(context.Background(), sqlh.Operation{Name: "INSERT", Model: "T", Table: "t"})
+	described := sqlh.ObserveContext(ctx, Q, observer)
+	chk.Equal(observer, sqlh.ObserverOf(described))
+	chk.Equal(ctx, sqlh.ContextOf(described))
+	chk.Equal(context.Background(), sqlh.ContextOf(db))
+	//
+	mock.ExpectExec("INSERT").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT").WillReturnResult(sqlmock.NewResult(1, 1))
+	_, err = described.Exec("INSERT INTO t VALUES ( 1 )")
+	chk.NoError(err)
+	_, err = Q.Exec("INSERT INTO t VALUES ( 2 )")
+	chk.NoError(err)
+	chk.NoError(mock.ExpectationsWereMet())
+	chk.Equal([]sqlh.Operation{{Name: "INSERT", Model: "T", Table: "t"}, {}}, operations)
+	//
+	_, ok := sqlh.OperationFrom(nil)
+	chk.False(ok)
+}
+
+func TestOperationOf(t *testing.T) {
+	chk := assert.New(t)
+	//
+	ctx := sqlh.WithOperation(context.Background(), sqlh.Operation{Name: "UPSERT", Model: "T", Table: "t"})
+	name, op := sqlh.OperationOf(ctx, "INSERT INTO t VALUES ( 1 )")
+	chk.Equal("UPSERT", name)
+	chk.Equal("T", op.Model)
+	name, _ = sqlh.OperationOf(ctx, "commit")
+	chk.Equal("COMMIT", name)
+	name, op = sqlh.OperationOf(context.Background(), "  select 1")
+	chk.Equal("SELECT", name)
+	chk.Equal(sqlh.Operation{}, op)
+	name, _ = sqlh.OperationOf(sqlh.WithOperation(context.Background(), sqlh.Operation{Model: "T"}), "DELETE FROM t")
+	chk.Equal("DELETE", name)
+}
+
+// operationRecorder records the Operation of each query.
+type operationRecorder struct {
+	operations *[]sqlh.Operation
+}
+
+func (me *operationRecorder) BeforeQuery(ctx context.Context, query string, args []interface{}) context.Context {
+	op, _ := sqlh.OperationFrom(ctx)
+	*me.operations = append(*me.operations, op)
+	return ctx
+}
+
+func (me *operationRecorder) AfterQuery(ctx context.Context, query string, args []interface{}, duration time.Duration, rowsAffected int64, err error) {
+}
+
+func TestPrepare(t *testing.T) {
+	chk := assert.New(t)
+	//
+	db, mock, err := sqlmock.New()
+	chk.NoError(err)
+	defer db.Close()
+	rec := &recorder{}
+	Q := sqlh.Observe(db, rec)
+	//
+	prepare := mock.ExpectPrepare("INSERT")
+	prepare.ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
+	prepare.ExpectQuery().WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(2))
+	prepare.ExpectQuery().WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(3))
+	stmt, err := sqlh.Prepare(Q.(sqlh.IPrepares), "INSERT INTO t VALUES ( ? )")
+	chk.NoError(err)
+	_, err = stmt.Exec(1)
+	chk.NoError(err)
+	rows, err := stmt.Query(2)
+	chk.NoError(err)
+	chk.NoError(rows.Close())
+	var n int
+	chk.NoError(stmt.QueryRow(3).Scan(&n))
+	chk.Equal(3, n)
+	chk.NoError(stmt.Close())
+	chk.NoError(mock.ExpectationsWereMet())
+	chk.Equal([]string{
+		"INSERT INTO t VALUES ( ? ) [] -1 <nil>",
+		"INSERT INTO t VALUES ( ? ) [1] 1 <nil>",
+		"INSERT INTO t VALUES ( ? ) [2] -1 <nil>",
+		"INSERT INTO t VALUES ( ? ) [3] -1 <nil>",
+	}, rec.after)
+	//
+	// Statements prepared without an observer are not observed.
+	prepare = mock.ExpectPrepare("INSERT")
+	prepare.ExpectExec().WithArgs(4).WillReturnResult(sqlmock.NewResult(1, 1))
+	prepare.ExpectQuery().WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(5))
+	prepare.ExpectQuery().WithArgs(6).WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(6))
+	stmt, err = sqlh.Prepare(db, "INSERT INTO t VALUES ( ? )")
+	chk.NoError(err)
+	_, err = stmt.Exec(4)
+	chk.NoError(err)
+	rows, err = stmt.Query(5)
+	chk.NoError(err)
+	chk.NoError(rows.Close())
+	chk.NoError(stmt.QueryRow(6).Scan(&n))
+	chk.NoError(mock.ExpectationsWereMet())
+	chk.Len(rec.after, 4)
+	//
+	mock.ExpectPrepare("INSERT").WillReturnError(sql.ErrConnDone)
+	_, err = sqlh.Prepare(db, "INSERT INTO t VALUES ( ? )")
+	chk.Equal(sql.ErrConnDone, err)
+}
@@ -0,0 +1,49 @@
+package sqlh
+
+import (
+	"context"
+	"strings"
+)
+
+// Operation describes the operation that runs the queries given to an Observer; model.Models describes
+// its queries with WithOperation and ObserveContext.
+type Operation struct {
+	// Name is the operation such as INSERT, UPDATE, UPSERT, SELECT, DELETE, or COPY.
+	Name string
+	// Model is the Go type of the model such as examples.Address.
+	Model string
+	// Table is the table of the model.
+	Table string
+}
+
+// operationKey is the context key for Operation.
+type operationKey struct{}
+
+// WithOperation returns a copy of ctx that carries op.
+func WithOperation(ctx context.Context, op Operation) context.Context {
+	return context.WithValue(ctx, operationKey{}, op)
+}
+
+// OperationFrom returns the Operation carried by ctx if any.
+func OperationFrom(ctx context.Context) (Operation, bool) {
+	if ctx == nil {
+		return Operation{}, false
+	}
+	op, ok := ctx.Value(operationKey{}).(Operation)
+	return op, ok
+}
+
+// OperationOf returns the name of the operation running query and the Operation carried by ctx.  The
+// name is the Operation name unless query controls a transaction; otherwise it is the first keyword of
+// query in upper case.
+func OperationOf(ctx context.Context, query string) (string, Operation) {
+	keyword := query
+	if fields := strings.Fields(query); len(fields) > 0 {
+		keyword = strings.ToUpper(fields[0])
+	}
+	op, ok := OperationFrom(ctx)
+	if !ok || op.Name == "" || keyword == "BEGIN" || keyword == "COMMIT" || keyword == "ROLLBACK" {
+		return keyword, op
+	}
+	return op.Name, op
+}
@@ -1,6 +1,7 @@
 package sqlh
 
 import (
+	"context"
 	"database/sql"
 	"reflect"
 	"time"
@@ -28,6 +29,8 @@ func (me scannerDestType) String() string {
 // Scanner facilitates scanning query results into destinations.
 type Scanner struct {
 	*set.Mapper
+	// Observer, if not nil, is notified of the queries run by Select; see Observe.
+	Observer Observer
 }
 
 // inspectValue inspects a query destination and determines if it can be used.
@@ -77,48 +80,12 @@ func (me *Scanner) Select(Q IQueries, dest interface{}, query string, args ...in
 	if err != nil {
 		return errors.Go(err)
 	}
+	Q = Observe(Q, me.Observer)
 	switch T {
-	case destScalar:
-		row := Q.QueryRow(query, args...)
-		if err := row.Scan(dest); err != nil {
-			return errors.Go(err)
-		}
-
-	case destStruct:
-		var rows *sql.Rows
-		var prepared set.PreparedMapping
-		var columns []string
-		var err error
-		// Why not QueryRow()?  Because *sql.Row does not allow us to get the list of columns which we
-		// need for our dynamic Scan().
-		if rows, err = Q.Query(query, args...); err != nil {
-			return errors.Go(err)
-		}
-		defer rows.Close()
-		if columns, err = rows.Columns(); err != nil {
-			return errors.Go(err)
-		}
-		//
-		// Get a prepared mapping and prepare the access plan.  Note that if prepared.Plan()
-		// succeeds we know future calls to prepared.Assignables() will succeed and do not
-		// need to check those errors.
-		if prepared, err = me.Mapper.Prepare(dest); err != nil {
-			return errors.Go(err)
-		} else if err = prepared.Plan(columns...); err != nil {
-			return errors.Go(err)
-		}
-		assignables := make([]interface{}, len(columns))
-		if rows.Next() {
-			_, _ = prepared.Assignables(assignables)
-			if err = rows.Scan(assignables...); err != nil {
-				return errors.Go(err)
-			}
-		} else {
-			// When no rows are returned set dest to the zero value of its type.  Since dest should be a pointer
-			// we need to Indirect(ValueOf(dest)) and set TypeOf(dest).Elem().
-			reflect.Indirect(reflect.ValueOf(dest)).Set(reflect.Zero(reflect.TypeOf(dest).Elem()))
-		}
-		if err = rows.Err(); err != nil {
+	case destScalar, destStruct:
+		if err = me.selectRow(Q, dest, T, query, args...); err == sql.ErrNoRows && T == destStruct {
+			return nil
+		} else if err != nil {
 			return errors.Go(err)
 		}
 
@@ -130,7 +97,10 @@ func (me *Scanner) Select(Q IQueries, dest interface{}, query string, args ...in
 			return errors.Go(err)
 		}
 		defer rows.Close()
-		if err = me.scanRows(rows, dest, V, T); err != nil {
+		counted := &countedRows{IIterates: rows}
+		err = me.scanRows(counted, dest, V, T)
+		me.scanned(Q, query, counted.n)
+		if err != nil {
 			return errors.Go(err)
 		}
 
@@ -139,6 +109,73 @@ func (me *Scanner) Select(Q IQueries, dest interface{}, query string, args ...in
 	return nil
 }
 
+// get uses Q to run the query string with args and scans the first row into dest, which must be a
+// scalar or struct destination.  If there are no rows the error is sql.ErrNoRows.
+func (me *Scanner) get(Q IQueries, dest interface{}, query string, args ...interface{}) error {
+	_, T, err := me.inspectValue(dest)
+	if err != nil {
+		return errors.Go(err)
+	} else if T != destScalar && T != destStruct {
+		return errors.Errorf("%T.get expects dest to be address of scalar or struct; got %T", me, dest)
+	}
+	return me.selectRow(Observe(Q, me.Observer), dest, T, query, args...)
+}
+
+// selectRow uses Q to run the query string with args and scans the first row into dest, which is a
+// scalar or struct destination of type T.  If there are no rows the error is sql.ErrNoRows and a
+// struct dest is set to its zero value.
+func (me *Scanner) selectRow(Q IQueries, dest interface{}, T scannerDestType, query string, args ...interface{}) error {
+	if T == destScalar {
+		row := Q.QueryRow(query, args...)
+		if err := row.Scan(dest); err != nil {
+			return err
+		}
+		me.scanned(Q, query, 1)
+		return nil
+	}
+	var rows *sql.Rows
+	var prepared set.PreparedMapping
+	var columns []string
+	var err error
+	// Why not QueryRow()?  Because *sql.Row does not allow us to get the list of columns which we
+	// need for our dynamic Scan().
+	if rows, err = Q.Query(query, args...); err != nil {
+		return errors.Go(err)
+	}
+	defer rows.Close()
+	if columns, err = rows.Columns(); err != nil {
+		return errors.Go(err)
+	}
+	//
+	// Get a prepared mapping and prepare the access plan.  Note that if prepared.Plan()
+	// succeeds we know future calls to prepared.Assignables() will succeed and do not
+	// need to check those errors.
+	if prepared, err = me.Mapper.Prepare(dest); err != nil {
+		return errors.Go(err)
+	} else if err = prepared.Plan(columns...); err != nil {
+		return errors.Go(err)
+	}
+	assignables := make([]interface{}, len(columns))
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return errors.Go(err)
+		}
+		// When no rows are returned set dest to the zero value of its type.  Since dest should be a pointer
+		// we need to Indirect(ValueOf(dest)) and set TypeOf(dest).Elem().
+		reflect.Indirect(reflect.ValueOf(dest)).Set(reflect.Zero(reflect.TypeOf(dest).Elem()))
+		return sql.ErrNoRows
+	}
+	_, _ = prepared.Assignables(assignables)
+	if err = rows.Scan(assignables...); err != nil {
+		return errors.Go(err)
+	}
+	me.scanned(Q, query, 1)
+	if err = rows.Err(); err != nil {
+		return errors.Go(err)
+	}
+	return nil
+}
+
 // scanRows scans rows is the internal scanRows that assumes dest is safe.
 func (me *Scanner) scanRows(R IIterates, dest interface{}, V set.Value, T scannerDestType) error {
 	if R != nil {
@@ -232,6 +269,37 @@ func (me *Scanner) ScanRows(R IIterates, dest interface{}) error {
 		return errors.Go(err)
 	} else if T != destScalarSlice && T != destStructSlice {
 		return errors.Errorf("%T.ScanRows expects dest to be address of slice; got %T", me, dest)
+	} else if R == nil {
+		return me.scanRows(R, dest, V, T)
+	}
+	counted := &countedRows{IIterates: R}
+	err = me.scanRows(counted, dest, V, T)
+	if observer, ok := me.Observer.(RowsObserver); ok {
+		observer.ScannedRows(context.Background(), "", counted.n)
+	}
+	return err
+}
+
+// scanned reports n rows scanned by query to the observer of Q if it is a RowsObserver.
+func (me *Scanner) scanned(Q IQueries, query string, n int64) {
+	if observed := observedOf(Q); observed != nil {
+		if observer, ok := observed.observer.(RowsObserver); ok {
+			observer.ScannedRows(observed.ctx, query, n)
+		}
+	}
+}
+
+// countedRows counts the rows advanced to by Next.
+type countedRows struct {
+	IIterates
+	n int64
+}
+
+// Next advances to the next row.
+func (me *countedRows) Next() bool {
+	if me.IIterates.Next() {
+		me.n++
+		return true
 	}
-	return me.scanRows(R, dest, V, T)
+	return false
 }
@@ -10,6 +10,14 @@ type Column struct {
 	GoType interface{}
 	// SqlType specifies the type in SQL and can be set to a string describing the SQL type.
 	SqlType string
+	// Nullable is true if the column allows NULL values.
+	Nullable bool
+	// Default is the SQL expression for the column's default value; it is empty if the
+	// column has no default.
+	Default string
+	// Auto is true if the database populates the column, such as auto incrementing keys or
+	// timestamps set during INSERT or UPDATE.
+	Auto bool
 }
 
 // String describes the column as a string.
@@ -18,5 +26,24 @@ func (me Column) String() string {
 	if sqlType == "" {
 		sqlType = "-"
 	}
-	return fmt.Sprintf("%v go(%T) sql(%v)", me.Name, me.GoType, sqlType)
+	rv := fmt.Sprintf("%v go(%T) sql(%v)", me.Name, me.GoType, sqlType)
+	if me.Nullable {
+		rv = rv + " null"
+	}
+	if me.Auto {
+		rv = rv + " auto"
+	}
+	if me.Default != "" {
+		rv = rv + " default(" + me.Default + ")"
+	}
+	return rv
+}
+
+// ColumnNames returns the names of columns.
+func ColumnNames(columns []Column) []string {
+	rv := make([]string, len(columns))
+	for k, column := range columns {
+		rv[k] = column.Name
+	}
+	return rv
 }
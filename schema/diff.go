@@ -0,0 +1,236 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ChangeKind describes a change made to a schema.
+type ChangeKind int
+
+const (
+	// CreateTable creates Change.Table.
+	CreateTable ChangeKind = iota + 1
+	// DropTable drops Change.Table.
+	DropTable
+	// AddColumn adds Change.Column to Change.Table.
+	AddColumn
+	// DropColumn drops Change.Column from Change.Table.
+	DropColumn
+	// AlterColumn changes Change.From into Change.Column.
+	AlterColumn
+	// AddIndex creates Change.Index.
+	AddIndex
+	// DropIndex drops Change.Index.
+	DropIndex
+)
+
+// String returns the ChangeKind as a string.
+func (me ChangeKind) String() string {
+	switch me {
+	case CreateTable:
+		return "create table"
+	case DropTable:
+		return "drop table"
+	case AddColumn:
+		return "add column"
+	case DropColumn:
+		return "drop column"
+	case AlterColumn:
+		return "alter column"
+	case AddIndex:
+		return "add index"
+	case DropIndex:
+		return "drop index"
+	}
+	return fmt.Sprintf("ChangeKind(%d)", int(me))
+}
+
+// Change is a single change made to a schema.  Changes carry the complete definition of what
+// they create or drop so every Change can be reversed; see Inverse.
+type Change struct {
+	// Kind describes the change.
+	Kind ChangeKind
+	// Table is the table that is created, dropped, or altered.
+	Table Table
+	// Column is the column that is added or dropped; for AlterColumn it is the new definition.
+	Column Column
+	// From is the previous column definition for AlterColumn.
+	From Column
+	// Index is the index that is added or dropped.
+	Index Index
+}
+
+// Inverse returns the change that reverts the change.
+func (me Change) Inverse() Change {
+	rv := me
+	switch me.Kind {
+	case CreateTable:
+		rv.Kind = DropTable
+	case DropTable:
+		rv.Kind = CreateTable
+	case AddColumn:
+		rv.Kind = DropColumn
+	case DropColumn:
+		rv.Kind = AddColumn
+	case AlterColumn:
+		rv.Column, rv.From = me.From, me.Column
+	case AddIndex:
+		rv.Kind = DropIndex
+	case DropIndex:
+		rv.Kind = AddIndex
+	}
+	return rv
+}
+
+// String describes the change as a string.
+func (me Change) String() string {
+	switch me.Kind {
+	case AddColumn, DropColumn, AlterColumn:
+		return me.Kind.String() + " " + me.Table.QualifiedName() + "." + me.Column.Name
+	case AddIndex, DropIndex:
+		return me.Kind.String() + " " + me.Table.QualifiedName() + " (" + strings.Join(ColumnNames(me.Index.Columns), ",") + ")"
+	}
+	return me.Kind.String() + " " + me.Table.QualifiedName()
+}
+
+// Diff returns the changes that turn the tables in from into the tables in to.  Tables are matched
+// by their qualified names, columns by name, and indexes by their columns and uniqueness.
+//
+// Changes are ordered so they can be applied in sequence: indexes are dropped, tables are created,
+// columns are added, altered, and dropped, indexes are added, and finally tables are dropped.
+//
+// Column types are compared only when both columns have a SqlType.  Defaults are not compared
+// for Auto columns since their values are managed by the database.  Primary keys are not
+// compared and foreign keys are only created with their tables.
+func Diff(from, to []Table) []Change {
+	fromTables, toTables := map[string]Table{}, map[string]Table{}
+	for _, table := range from {
+		fromTables[table.QualifiedName()] = table
+	}
+	for _, table := range to {
+		toTables[table.QualifiedName()] = table
+	}
+	var rv []Change
+	//
+	// Drop indexes that no longer exist.
+	for _, table := range from {
+		if next, ok := toTables[table.QualifiedName()]; ok {
+			nextIndexes := indexKeys(next)
+			for _, index := range tableIndexes(table) {
+				if !nextIndexes[indexKey(index)] {
+					rv = append(rv, Change{Kind: DropIndex, Table: table, Index: withTable(index, table)})
+				}
+			}
+		}
+	}
+	//
+	// Create new tables; unique indexes are created by the table but other indexes are not.
+	for _, table := range to {
+		if _, ok := fromTables[table.QualifiedName()]; !ok {
+			rv = append(rv, Change{Kind: CreateTable, Table: table})
+			for _, index := range table.Indexes {
+				rv = append(rv, Change{Kind: AddIndex, Table: table, Index: withTable(index, table)})
+			}
+		}
+	}
+	//
+	// Add, alter, and drop columns.
+	for _, table := range to {
+		previous, ok := fromTables[table.QualifiedName()]
+		if !ok {
+			continue
+		}
+		previousColumns := map[string]Column{}
+		for _, column := range tableColumns(previous) {
+			previousColumns[column.Name] = column
+		}
+		nextColumns := map[string]bool{}
+		for _, column := range tableColumns(table) {
+			nextColumns[column.Name] = true
+			if prior, ok := previousColumns[column.Name]; !ok {
+				rv = append(rv, Change{Kind: AddColumn, Table: table, Column: column})
+			} else if columnChanged(prior, column) {
+				rv = append(rv, Change{Kind: AlterColumn, Table: table, Column: column, From: prior})
+			}
+		}
+		for _, column := range tableColumns(previous) {
+			if !nextColumns[column.Name] {
+				rv = append(rv, Change{Kind: DropColumn, Table: previous, Column: column})
+			}
+		}
+	}
+	//
+	// Add new indexes.
+	for _, table := range to {
+		if previous, ok := fromTables[table.QualifiedName()]; ok {
+			previousIndexes := indexKeys(previous)
+			for _, index := range tableIndexes(table) {
+				if !previousIndexes[indexKey(index)] {
+					rv = append(rv, Change{Kind: AddIndex, Table: table, Index: withTable(index, table)})
+				}
+			}
+		}
+	}
+	//
+	// Drop tables that no longer exist.
+	for _, table := range from {
+		if _, ok := toTables[table.QualifiedName()]; !ok {
+			rv = append(rv, Change{Kind: DropTable, Table: table})
+		}
+	}
+	return rv
+}
+
+// Inverse returns the changes that revert changes in the order they must be applied.
+func Inverse(changes []Change) []Change {
+	rv := make([]Change, len(changes))
+	for k, change := range changes {
+		rv[len(changes)-1-k] = change.Inverse()
+	}
+	return rv
+}
+
+// columnChanged returns true if the column definition changed.
+func columnChanged(from, to Column) bool {
+	if from.SqlType != "" && to.SqlType != "" && !strings.EqualFold(from.SqlType, to.SqlType) {
+		return true
+	} else if from.Nullable != to.Nullable {
+		return true
+	} else if !from.Auto && !to.Auto && from.Default != to.Default {
+		return true
+	}
+	return false
+}
+
+// tableColumns returns the primary key columns followed by the other columns of table.
+func tableColumns(table Table) []Column {
+	return append(append([]Column{}, table.PrimaryKey.Columns...), table.Columns...)
+}
+
+// tableIndexes returns the unique and other indexes of table.
+func tableIndexes(table Table) []Index {
+	return append(append([]Index{}, table.Unique...), table.Indexes...)
+}
+
+// indexKey identifies an index by its uniqueness and columns.
+func indexKey(index Index) string {
+	return fmt.Sprintf("%v(%v)", index.IsUnique, strings.Join(ColumnNames(index.Columns), ","))
+}
+
+// indexKeys returns the set of index keys of table.
+func indexKeys(table Table) map[string]bool {
+	rv := map[string]bool{}
+	for _, index := range tableIndexes(table) {
+		rv[indexKey(index)] = true
+	}
+	return rv
+}
+
+// withTable returns index with its Table set to the qualified name of table if it is empty.
+func withTable(index Index, table Table) Index {
+	if index.Table == "" {
+		index.Table = table.QualifiedName()
+	}
+	return index
+}
@@ -0,0 +1,110 @@
+package schema_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nofeaturesonlybugs/sqlh/schema"
+)
+
+func TestDiff(t *testing.T) {
+	chk := assert.New(t)
+	//
+	pk := schema.Index{Columns: []schema.Column{{Name: "pk", SqlType: "integer"}}, IsPrimary: true, IsUnique: true}
+	from := []schema.Table{
+		{
+			Name:       "people",
+			PrimaryKey: pk,
+			Columns: []schema.Column{
+				{Name: "email", SqlType: "text"},
+				{Name: "age", SqlType: "integer", Nullable: true},
+				{Name: "ssn", SqlType: "text"},
+				{Name: "active", SqlType: "boolean", Default: "true"},
+			},
+			Indexes: []schema.Index{
+				{Name: "people_age_idx", Columns: []schema.Column{{Name: "age"}}},
+			},
+		},
+		{Name: "legacy", Columns: []schema.Column{{Name: "value", SqlType: "text"}}},
+	}
+	to := []schema.Table{
+		{
+			Name:       "people",
+			PrimaryKey: pk,
+			Columns: []schema.Column{
+				{Name: "email", SqlType: "TEXT"},
+				{Name: "age", SqlType: "bigint", Nullable: true},
+				{Name: "active", SqlType: "boolean"},
+				{Name: "nickname", SqlType: "text", Nullable: true},
+			},
+			Unique: []schema.Index{
+				{Columns: []schema.Column{{Name: "email"}}, IsUnique: true},
+			},
+		},
+		{
+			Schema:     "audit",
+			Name:       "events",
+			PrimaryKey: pk,
+			Indexes: []schema.Index{
+				{Columns: []schema.Column{{Name: "pk"}, {Name: "kind"}}},
+			},
+		},
+	}
+	changes := schema.Diff(from, to)
+	descriptions := []string{}
+	for _, change := range changes {
+		descriptions = append(descriptions, change.String())
+	}
+	chk.Equal([]string{
+		"drop index people (age)",
+		"create table audit.events",
+		"add index audit.events (pk,kind)",
+		"alter column people.age",
+		"alter column people.active",
+		"add column people.nickname",
+		"drop column people.ssn",
+		"add index people (email)",
+		"drop table legacy",
+	}, descriptions)
+	//
+	chk.Equal("people", changes[0].Index.Table)
+	chk.Equal("audit.events", changes[2].Index.Table)
+	chk.Equal("integer", changes[3].From.SqlType)
+	chk.Equal("bigint", changes[3].Column.SqlType)
+	chk.True(changes[7].Index.IsUnique)
+	//
+	// Diffing in the other direction creates the inverse changes.
+	inverse := schema.Inverse(changes)
+	chk.Len(inverse, len(changes))
+	chk.Equal(schema.CreateTable, inverse[0].Kind)
+	chk.Equal("legacy", inverse[0].Table.Name)
+	chk.Equal(schema.DropIndex, inverse[1].Kind)
+	chk.Equal(schema.AddColumn, inverse[2].Kind)
+	chk.Equal("ssn", inverse[2].Column.Name)
+	chk.Equal(schema.AlterColumn, inverse[5].Kind)
+	chk.Equal("bigint", inverse[5].From.SqlType)
+	chk.Equal("integer", inverse[5].Column.SqlType)
+	chk.Equal(schema.DropTable, inverse[7].Kind)
+	chk.Equal(schema.AddIndex, inverse[8].Kind)
+	//
+	chk.Empty(schema.Diff(to, to))
+}
+
+func TestDiffColumns(t *testing.T) {
+	chk := assert.New(t)
+	//
+	table := func(column schema.Column) []schema.Table {
+		return []schema.Table{{Name: "t", Columns: []schema.Column{column}}}
+	}
+	// Types are only compared when both are known.
+	chk.Empty(schema.Diff(table(schema.Column{Name: "a", SqlType: "integer"}), table(schema.Column{Name: "a", GoType: 0})))
+	// Defaults of auto columns are managed by the database.
+	chk.Empty(schema.Diff(table(schema.Column{Name: "a", Default: "now()"}), table(schema.Column{Name: "a", Auto: true})))
+	// Nullability is always compared.
+	changes := schema.Diff(table(schema.Column{Name: "a", Nullable: true}), table(schema.Column{Name: "a", GoType: 0}))
+	if chk.Len(changes, 1) {
+		chk.Equal(schema.AlterColumn, changes[0].Kind)
+		chk.Equal("alter column", changes[0].Kind.String())
+	}
+}
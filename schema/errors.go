@@ -0,0 +1,10 @@
+package schema
+
+import "errors"
+
+var (
+	// ErrInspectUnsupported is returned from Inspect when the dialect does not implement Inspector.
+	ErrInspectUnsupported error = errors.New("dialect does not support inspection")
+	// ErrTableNotFound is returned when an inspected table does not exist.
+	ErrTableNotFound error = errors.New("table not found")
+)
@@ -0,0 +1,34 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ForeignKey describes a foreign key constraint.
+type ForeignKey struct {
+	// Name specifies the constraint name; it can be empty.
+	Name string
+	// Columns contains the referencing columns in the table that owns the foreign key.
+	Columns []Column
+	// RefTable is the name of the referenced table and can be schema qualified.
+	RefTable string
+	// RefColumns contains the names of the referenced columns in the same order as Columns.
+	RefColumns []string
+	// OnDelete is the referential action when a referenced row is deleted such as CASCADE,
+	// SET NULL, SET DEFAULT, or RESTRICT; it is empty for the database default of NO ACTION.
+	OnDelete string
+}
+
+// String describes the foreign key as a string.
+func (me ForeignKey) String() string {
+	name := me.Name
+	if name == "" {
+		name = "-"
+	}
+	rv := fmt.Sprintf("foreign key name=%v (%v) references %v(%v)", name, strings.Join(ColumnNames(me.Columns), ","), me.RefTable, strings.Join(me.RefColumns, ","))
+	if me.OnDelete != "" {
+		rv = rv + " on delete " + me.OnDelete
+	}
+	return rv
+}
@@ -9,6 +9,8 @@ import (
 type Index struct {
 	// Name specifies the index name.
 	Name string
+	// Table is the name of the indexed table and can be schema qualified.
+	Table string
 	// Columns contains the columns in the index.
 	Columns []Column
 	// IsPrimary is true if the index represents a primary key; if IsPrimary is true then
@@ -24,7 +26,10 @@ func (me Index) String() string {
 		return ""
 	}
 	//
-	describe := "unique"
+	describe := "index"
+	if me.IsUnique {
+		describe = "unique"
+	}
 	if me.IsPrimary {
 		if len(me.Columns) > 1 {
 			describe = "primary composite key"
@@ -0,0 +1,34 @@
+package schema
+
+import (
+	"github.com/nofeaturesonlybugs/errors"
+
+	"github.com/nofeaturesonlybugs/sqlh"
+)
+
+// Inspector reads table definitions from a live database.  The grammars in package grammar
+// implement Inspector.
+type Inspector interface {
+	// Inspect returns the named tables; if no names are given then every table in the current
+	// schema is returned.  Table names can be schema qualified as schema.table.
+	Inspect(Q sqlh.IQueries, tables ...string) ([]Table, error)
+}
+
+// Inspect reads table definitions from the database Q is connected to.  dialect is typically a
+// grammar.Grammar and must implement Inspector.
+//
+// If no table names are given every table in the current schema is returned.  Tables requested
+// with a schema qualified name have Schema set; otherwise Schema is empty.
+//
+// Primary key columns are described by Table.PrimaryKey and all other columns by Table.Columns.
+func Inspect(Q sqlh.IQueries, dialect interface{}, tables ...string) ([]Table, error) {
+	inspector, ok := dialect.(Inspector)
+	if !ok {
+		return nil, errors.Go(ErrInspectUnsupported).Type(dialect)
+	}
+	rv, err := inspector.Inspect(Q, tables...)
+	if err != nil {
+		return nil, errors.Go(err)
+	}
+	return rv, nil
+}
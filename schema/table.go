@@ -2,6 +2,9 @@ package schema
 
 // Table describes a database table.
 type Table struct {
+	// Schema specifies the database schema containing the table; it is empty if the
+	// table name is not schema qualified.
+	Schema string
 	// Name specifies the database table name.
 	Name string
 	// Columns represents the table columns.
@@ -12,13 +15,26 @@ type Table struct {
 	PrimaryKey Index
 	// Unique is a slice of unique indexes on the table.
 	Unique []Index
+	// Indexes is a slice of indexes on the table that are not unique.
+	Indexes []Index
+	// ForeignKeys is a slice of foreign keys owned by the table.
+	ForeignKeys []ForeignKey
+}
+
+// QualifiedName returns the table name qualified by its schema as schema.name; if Schema is
+// empty then the table name is returned.
+func (me Table) QualifiedName() string {
+	if me.Schema == "" {
+		return me.Name
+	}
+	return me.Schema + "." + me.Name
 }
 
 // String describes the table as a string.
 func (me Table) String() string {
 	rv := ""
 	//
-	name := me.Name
+	name := me.QualifiedName()
 	if name == "" {
 		name = "- (table, name unknown)"
 	}
@@ -46,5 +62,21 @@ func (me Table) String() string {
 		}
 	}
 	//
+	// other indexes
+	if len(me.Indexes) > 0 {
+		rv = rv + "\n\tindexes"
+		for _, index := range me.Indexes {
+			rv = rv + "\n\t\t" + index.String()
+		}
+	}
+	//
+	// foreign keys
+	if len(me.ForeignKeys) > 0 {
+		rv = rv + "\n\tforeign keys"
+		for _, foreignKey := range me.ForeignKeys {
+			rv = rv + "\n\t\t" + foreignKey.String()
+		}
+	}
+	//
 	return rv
 }
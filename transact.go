@@ -8,6 +8,9 @@ import (
 
 // Transact runs fn inside a transaction if Q supports transactions; otherwise it just calls fn(Q).  If a transaction
 // is started and fn returns a non-nil error then the transaction is rolled back.
+//
+// If Q was returned from Observe then fn receives the transaction observed by the same Observer and the
+// commit or rollback is reported to it.
 func Transact(Q IQueries, fn func(Q IQueries) error) error {
 	var B IBegins
 	var T *sql.Tx
@@ -17,13 +20,15 @@ func Transact(Q IQueries, fn func(Q IQueries) error) error {
 		return fn(Q)
 	} else if T, err = B.Begin(); err != nil {
 		return errors.Go(err)
-	} else if err = fn(T); err != nil {
+	}
+	observed := observedOf(Q)
+	if err = fn(observed.wrap(T)); err != nil {
 		err = errors.Go(err)
-		if txnErr = T.Rollback(); txnErr != nil {
+		if txnErr = observed.end("ROLLBACK", T.Rollback); txnErr != nil {
 			err.(errors.Error).Tag("transaction-rollback", txnErr.Error())
 		}
 		return err
-	} else if err = T.Commit(); err != nil {
+	} else if err = observed.end("COMMIT", T.Commit); err != nil {
 		return errors.Go(err)
 	}
 	return nil
@@ -39,10 +44,15 @@ func TransactRollback(B IBegins, fn func(Q IQueries) error) error {
 	var err error
 	if T, err = B.Begin(); err != nil {
 		return errors.Go(err)
-	} else if err = fn(T); err != nil {
+	}
+	var observed *observedQueries
+	if Q, ok := B.(IQueries); ok {
+		observed = observedOf(Q)
+	}
+	if err = fn(observed.wrap(T)); err != nil {
 		err = errors.Go(err)
 	}
-	if e2 := T.Rollback(); e2 != nil {
+	if e2 := observed.end("ROLLBACK", T.Rollback); e2 != nil {
 		if err == nil {
 			err = errors.Go(e2)
 		} else {
@@ -51,3 +61,21 @@ func TransactRollback(B IBegins, fn func(Q IQueries) error) error {
 	}
 	return err
 }
+
+// wrap returns T observed with the observer and context of me; if me is nil then T is returned.
+func (me *observedQueries) wrap(T *sql.Tx) IQueries {
+	if me == nil {
+		return T
+	}
+	return observeContext(me.ctx, T, me.observer)
+}
+
+// end calls end, which commits or rolls back a transaction, and reports it as query if me is not nil.
+func (me *observedQueries) end(query string, end func() error) error {
+	if me == nil {
+		return end()
+	}
+	return observe(me.ctx, me.observer, query, nil, func() (int64, error) {
+		return -1, end()
+	})
+}